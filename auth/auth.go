@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	METHOD_BEARER = "bearer"
+	METHOD_HMAC   = "hmac"
+	METHOD_JWT    = "jwt"
+	METHOD_MTLS   = "mtls"
+
+	HMAC_HEADER_ACCESS_KEY = "X-Auth-Access-Key"
+	HMAC_HEADER_TIMESTAMP  = "X-Auth-Timestamp"
+	HMAC_HEADER_SIGNATURE  = "X-Auth-Signature"
+	HMAC_HEADER_NONCE      = "X-Auth-Nonce"
+	HMAC_MIN_NONCE_LENGTH  = 8
+	HMAC_MAX_NONCE_LENGTH  = 128
+	DEFAULT_HMAC_MAX_SKEW  = 5 * time.Minute
+
+	ROLE_PRINCIPAL_PREFIX = "role:"
+)
+
+var ErrUnauthenticated = errors.New("request is not authenticated")
+
+//Principal is named as <method>:<name>, so a jwt subject can't take the name of a bearer or hmac principal
+type Principal struct {
+	Name   string
+	Roles  []string
+	Method string
+}
+
+func newPrincipal(method string, name string, roles []string) *Principal {
+	return &Principal{Name: method + ":" + name, Roles: roles, Method: method}
+}
+
+//Authenticator returns a nil principal without error when the request carries no credential of its method
+type Authenticator interface {
+	Authenticate(r *http.Request, body []byte) (*Principal, error)
+}
+
+type Config struct {
+	Methods []string
+	//token -> principal name
+	BearerTokens map[string]string
+	//access key -> secret, the access key is the principal name
+	HmacKeys map[string]string
+	//DEFAULT_HMAC_MAX_SKEW is used when it's not positive
+	HmacMaxSkew   time.Duration
+	JwtSigningKey []byte
+	PolicyFile    string
+}
+
+//Guard authenticates requests with the configured methods and authorizes them with the policy file
+type Guard struct {
+	authenticators []Authenticator
+	policy         *Policy
+}
+
+func NewGuard(config Config) (*Guard, error) {
+	guard := &Guard{}
+	for _, method := range config.Methods {
+		switch strings.ToLower(strings.TrimSpace(method)) {
+		case "":
+			continue
+		case METHOD_BEARER:
+			if len(config.BearerTokens) == 0 {
+				return nil, fmt.Errorf("auth method %s is enabled without any token", METHOD_BEARER)
+			}
+			guard.authenticators = append(guard.authenticators, &BearerAuthenticator{Tokens: config.BearerTokens})
+		case METHOD_HMAC:
+			if len(config.HmacKeys) == 0 {
+				return nil, fmt.Errorf("auth method %s is enabled without any key", METHOD_HMAC)
+			}
+			guard.authenticators = append(guard.authenticators, &HmacAuthenticator{Keys: config.HmacKeys, MaxSkew: config.HmacMaxSkew})
+		case METHOD_MTLS:
+			guard.authenticators = append(guard.authenticators, &MtlsAuthenticator{})
+		case METHOD_JWT:
+			if len(config.JwtSigningKey) == 0 {
+				return nil, fmt.Errorf("auth method %s is enabled without signing key", METHOD_JWT)
+			}
+			guard.authenticators = append(guard.authenticators, &JwtAuthenticator{SigningKey: config.JwtSigningKey})
+		default:
+			return nil, fmt.Errorf("auth method(%s) is not supported", method)
+		}
+	}
+
+	if config.PolicyFile != "" {
+		policy, err := LoadPolicy(config.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		guard.policy = policy
+	}
+	return guard, nil
+}
+
+//Enabled reports whether any authentication method is configured
+func (guard *Guard) Enabled() bool {
+	return len(guard.authenticators) > 0
+}
+
+func (guard *Guard) HasPolicy() bool {
+	return guard.policy != nil
+}
+
+func (guard *Guard) Authenticate(r *http.Request, body []byte) (*Principal, error) {
+	for _, authenticator := range guard.authenticators {
+		principal, err := authenticator.Authenticate(r, body)
+		if err != nil {
+			return nil, err
+		}
+		if principal != nil {
+			return principal, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
+
+//Authorize checks the principal may run the target in all the regions, every principal is allowed without policy file
+func (guard *Guard) Authorize(principal *Principal, target Target, regions []string) error {
+	if guard.policy == nil {
+		return nil
+	}
+	return guard.policy.Authorize(principal, target, regions)
+}
+
+type BearerAuthenticator struct {
+	Tokens map[string]string
+}
+
+func (authenticator *BearerAuthenticator) Authenticate(r *http.Request, body []byte) (*Principal, error) {
+	token := getBearerToken(r)
+	if token == "" {
+		return nil, nil
+	}
+	for validToken, name := range authenticator.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(validToken)) == 1 {
+			return newPrincipal(METHOD_BEARER, name, nil), nil
+		}
+	}
+	//the token may be a jwt checked by another authenticator
+	return nil, nil
+}
+
+//HmacAuthenticator checks X-Auth-Signature = hex(HMAC-SHA256(secret, method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + body)),
+//every nonce of an access key is accepted once within the skew window
+type HmacAuthenticator struct {
+	Keys    map[string]string
+	MaxSkew time.Duration
+
+	nonces nonceCache
+}
+
+func SignRequest(secret string, method string, path string, timestamp string, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (authenticator *HmacAuthenticator) Authenticate(r *http.Request, body []byte) (*Principal, error) {
+	accessKey := r.Header.Get(HMAC_HEADER_ACCESS_KEY)
+	if accessKey == "" {
+		return nil, nil
+	}
+
+	secret, found := authenticator.Keys[accessKey]
+	if !found {
+		return nil, fmt.Errorf("access key(%s) is unknown", accessKey)
+	}
+
+	timestamp := r.Header.Get(HMAC_HEADER_TIMESTAMP)
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("header %s(%s) is invalid", HMAC_HEADER_TIMESTAMP, timestamp)
+	}
+	maxSkew := authenticator.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = DEFAULT_HMAC_MAX_SKEW
+	}
+	now := time.Now()
+	skew := now.Sub(time.Unix(unixTime, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxSkew {
+		return nil, fmt.Errorf("header %s(%s) is out of the allowed skew %v", HMAC_HEADER_TIMESTAMP, timestamp, maxSkew)
+	}
+
+	nonce := r.Header.Get(HMAC_HEADER_NONCE)
+	if len(nonce) < HMAC_MIN_NONCE_LENGTH || len(nonce) > HMAC_MAX_NONCE_LENGTH {
+		return nil, fmt.Errorf("header %s should have %d to %d characters", HMAC_HEADER_NONCE, HMAC_MIN_NONCE_LENGTH, HMAC_MAX_NONCE_LENGTH)
+	}
+
+	signature := SignRequest(secret, r.Method, r.URL.Path, timestamp, nonce, body)
+	if !hmac.Equal([]byte(signature), []byte(strings.ToLower(r.Header.Get(HMAC_HEADER_SIGNATURE)))) {
+		return nil, fmt.Errorf("signature of access key(%s) mismatch", accessKey)
+	}
+	//the timestamp of the request is valid until now + maxSkew at most, so is the nonce
+	if !authenticator.nonces.add(accessKey+"\n"+nonce, now, 2*maxSkew) {
+		return nil, fmt.Errorf("nonce of access key(%s) has been used", accessKey)
+	}
+	return newPrincipal(METHOD_HMAC, accessKey, nil), nil
+}
+
+//nonceCache remembers the nonces until they expire, the expired ones are pruned once in a ttl
+type nonceCache struct {
+	mutex     sync.Mutex
+	expires   map[string]time.Time
+	lastPrune time.Time
+}
+
+//add returns false when the nonce is already in the cache
+func (cache *nonceCache) add(nonce string, now time.Time, ttl time.Duration) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.expires == nil {
+		cache.expires = make(map[string]time.Time)
+	}
+	if now.Sub(cache.lastPrune) > ttl {
+		for key, expire := range cache.expires {
+			if !now.Before(expire) {
+				delete(cache.expires, key)
+			}
+		}
+		cache.lastPrune = now
+	}
+
+	if expire, found := cache.expires[nonce]; found && now.Before(expire) {
+		return false
+	}
+	cache.expires[nonce] = now.Add(ttl)
+	return true
+}
+
+func getBearerToken(r *http.Request) string {
+	authorization := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(authorization[7:])
+}
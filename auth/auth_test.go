@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newJwt(signingKey []byte, claims string) string {
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + encoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestUnitGuardAuthenticate(t *testing.T) {
+	signingKey := []byte("platform-key")
+	guard, err := NewGuard(Config{
+		Methods:       []string{METHOD_BEARER, METHOD_HMAC, METHOD_JWT},
+		BearerTokens:  map[string]string{"static-token": "ops"},
+		HmacKeys:      map[string]string{"ak": "sk"},
+		HmacMaxSkew:   time.Minute,
+		JwtSigningKey: signingKey,
+	})
+	if err != nil {
+		t.Fatalf("new guard meet err=%v", err)
+	}
+
+	body := []byte(`{"inputs":[]}`)
+	r, _ := http.NewRequest(http.MethodPost, "/v1/qcloud/vm/create", bytes.NewReader(body))
+	if _, err = guard.Authenticate(r, body); err != ErrUnauthenticated {
+		t.Errorf("request without credential should be unauthenticated, err=%v", err)
+	}
+
+	r.Header.Set("Authorization", "Bearer static-token")
+	if principal, err := guard.Authenticate(r, body); err != nil || principal.Name != "bearer:ops" {
+		t.Errorf("bearer token meet principal=%v err=%v", principal, err)
+	}
+
+	exp := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	r.Header.Set("Authorization", "Bearer "+newJwt(signingKey, `{"sub":"admin","exp":`+exp+`,"authority":"[SUPER_ADMIN,CMDB_ADMIN]"}`))
+	if principal, err := guard.Authenticate(r, body); err != nil || principal.Name != "jwt:admin" || len(principal.Roles) != 2 {
+		t.Errorf("jwt meet principal=%v err=%v", principal, err)
+	}
+
+	r.Header.Set("Authorization", "Bearer "+newJwt([]byte("other-key"), `{"sub":"admin","exp":`+exp+`}`))
+	if _, err = guard.Authenticate(r, body); err == nil {
+		t.Errorf("jwt signed by other key should be rejected")
+	}
+
+	r.Header.Set("Authorization", "Bearer "+newJwt(signingKey, `{"sub":"admin"}`))
+	if _, err = guard.Authenticate(r, body); err == nil {
+		t.Errorf("jwt without exp should be rejected")
+	}
+
+	r.Header.Del("Authorization")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	r.Header.Set(HMAC_HEADER_ACCESS_KEY, "ak")
+	r.Header.Set(HMAC_HEADER_TIMESTAMP, timestamp)
+	r.Header.Set(HMAC_HEADER_NONCE, "nonce-0001")
+	r.Header.Set(HMAC_HEADER_SIGNATURE, SignRequest("sk", r.Method, r.URL.Path, timestamp, "nonce-0001", body))
+	if _, err = guard.Authenticate(r, []byte(`{"inputs":[{}]}`)); err == nil {
+		t.Errorf("hmac of a modified body should be rejected")
+	}
+	if principal, err := guard.Authenticate(r, body); err != nil || principal.Name != "hmac:ak" {
+		t.Errorf("hmac meet principal=%v err=%v", principal, err)
+	}
+	if _, err = guard.Authenticate(r, body); err == nil {
+		t.Errorf("replayed hmac request should be rejected")
+	}
+}
+
+func TestUnitHmacDefaultSkew(t *testing.T) {
+	authenticator := &HmacAuthenticator{Keys: map[string]string{"ak": "sk"}}
+	body := []byte(`{"inputs":[]}`)
+	r, _ := http.NewRequest(http.MethodPost, "/v1/qcloud/vm/create", bytes.NewReader(body))
+	timestamp := strconv.FormatInt(time.Now().Add(-2*DEFAULT_HMAC_MAX_SKEW).Unix(), 10)
+	r.Header.Set(HMAC_HEADER_ACCESS_KEY, "ak")
+	r.Header.Set(HMAC_HEADER_TIMESTAMP, timestamp)
+	r.Header.Set(HMAC_HEADER_NONCE, "nonce-0001")
+	r.Header.Set(HMAC_HEADER_SIGNATURE, SignRequest("sk", r.Method, r.URL.Path, timestamp, "nonce-0001", body))
+	if _, err := authenticator.Authenticate(r, body); err == nil {
+		t.Errorf("stale timestamp should be rejected without MaxSkew")
+	}
+}
+
+func TestUnitPolicyAuthorize(t *testing.T) {
+	policy := &Policy{Principals: map[string][]Grant{
+		"role:SUPER_ADMIN": {{Provider: "*", Version: "*", Plugin: "*", Actions: []string{"*"}}},
+		"bearer:wecube": {
+			{Provider: "qcloud", Version: "v1", Plugin: "vm", Actions: []string{"create"}, Regions: []string{"ap-guangzhou"}},
+			{Provider: "qcloud", Version: "v1", Plugin: "vm", Actions: []string{"*"}, Regions: []string{"ap-shanghai"}},
+		},
+	}}
+	target := func(plugin, action string) Target {
+		return Target{Provider: "qcloud", Version: "v1", Plugin: plugin, Action: action}
+	}
+
+	wecube := &Principal{Name: "bearer:wecube"}
+	if err := policy.Authorize(wecube, target("vm", "create"), []string{"ap-guangzhou", "ap-shanghai"}); err != nil {
+		t.Errorf("regions allowed by two grants meet err=%v", err)
+	}
+	if err := policy.Authorize(wecube, target("vm", "terminate"), []string{"ap-guangzhou"}); err == nil {
+		t.Errorf("terminate in ap-guangzhou should be denied")
+	}
+	if err := policy.Authorize(wecube, target("storage", "create"), nil); err == nil {
+		t.Errorf("storage plugin should be denied")
+	}
+	if err := policy.Authorize(wecube, target("vm", "create"), nil); err == nil {
+		t.Errorf("request without region should be denied by region limited grants")
+	}
+	if err := policy.Authorize(wecube, Target{Provider: "other", Version: "v1", Plugin: "vm", Action: "create"}, []string{"ap-guangzhou"}); err == nil {
+		t.Errorf("vm plugin of other provider should be denied")
+	}
+	if err := policy.Authorize(wecube, Target{Provider: "qcloud", Version: "v2", Plugin: "vm", Action: "create"}, []string{"ap-guangzhou"}); err == nil {
+		t.Errorf("vm plugin of other version should be denied")
+	}
+	if err := policy.Authorize(&Principal{Name: "jwt:wecube"}, target("vm", "create"), []string{"ap-guangzhou"}); err == nil {
+		t.Errorf("jwt subject should not match the bearer principal")
+	}
+	if err := policy.Authorize(&Principal{Name: "jwt:admin", Roles: []string{"SUPER_ADMIN"}}, target("storage", "terminate"), []string{"ap-beijing"}); err != nil {
+		t.Errorf("role grant meet err=%v", err)
+	}
+	if err := policy.Authorize(&Principal{Name: "jwt:admin", Roles: []string{"SUPER_ADMIN"}}, target("storage", "terminate"), nil); err != nil {
+		t.Errorf("request without region should be allowed by unlimited grants, err=%v", err)
+	}
+}
+
+func TestUnitLoadPolicy(t *testing.T) {
+	policy, err := LoadPolicy("../conf/auth_policy.json.example")
+	if err != nil {
+		t.Fatalf("load example policy meet err=%v", err)
+	}
+	target := Target{Provider: "qcloud", Version: "v1", Plugin: "vm", Action: "create"}
+	if err = policy.Authorize(&Principal{Name: "bearer:wecube"}, target, []string{"ap-guangzhou"}); err != nil {
+		t.Errorf("example policy should allow bearer:wecube, err=%v", err)
+	}
+
+	file, err := ioutil.TempFile("", "auth_policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"principals": {"bearer:wecube": [{"plugin": "vm", "actions": ["*"]}]}}`)
+	file.Close()
+	if _, err = LoadPolicy(file.Name()); err == nil {
+		t.Errorf("grant without provider and version should be rejected")
+	}
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//JwtAuthenticator verifies the HMAC signed jwt issued by the wecube platform auth server
+type JwtAuthenticator struct {
+	SigningKey []byte
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject   string      `json:"sub"`
+	ExpiresAt int64       `json:"exp"`
+	NotBefore int64       `json:"nbf"`
+	Authority interface{} `json:"authority"`
+}
+
+func (authenticator *JwtAuthenticator) Authenticate(r *http.Request, body []byte) (*Principal, error) {
+	token := getBearerToken(r)
+	if strings.Count(token, ".") != 2 {
+		return nil, nil
+	}
+
+	claims, err := ParseJwt(token, authenticator.SigningKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return newPrincipal(METHOD_JWT, claims.Subject, getJwtRoles(claims.Authority)), nil
+}
+
+func ParseJwt(token string, signingKey []byte, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("jwt should have 3 parts")
+	}
+
+	header := jwtHeader{}
+	if err := decodeJwtPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("decode jwt header meet error=%v", err)
+	}
+
+	var newHash func() hash.Hash
+	switch header.Alg {
+	case "HS256":
+		newHash = sha256.New
+	case "HS384":
+		newHash = sha512.New384
+	case "HS512":
+		newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("jwt alg(%s) is not supported", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode jwt signature meet error=%v", err)
+	}
+	mac := hmac.New(newHash, signingKey)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("jwt signature mismatch")
+	}
+
+	claims := jwtClaims{}
+	if err = decodeJwtPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("decode jwt claims meet error=%v", err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("jwt subject is empty")
+	}
+	if claims.ExpiresAt == 0 {
+		return nil, fmt.Errorf("jwt of %s has no exp", claims.Subject)
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("jwt of %s is expired", claims.Subject)
+	}
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore {
+		return nil, fmt.Errorf("jwt of %s is not valid yet", claims.Subject)
+	}
+	return &claims, nil
+}
+
+func decodeJwtPart(part string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+//getJwtRoles accepts authority as a list or as the "[ROLE_A,ROLE_B]" string written by the platform
+func getJwtRoles(authority interface{}) []string {
+	roles := []string{}
+	switch v := authority.(type) {
+	case string:
+		for _, role := range strings.Split(strings.Trim(v, "[]"), ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+	case []interface{}:
+		for _, role := range v {
+			if str, ok := role.(string); ok && str != "" {
+				roles = append(roles, str)
+			}
+		}
+	}
+	return roles
+}
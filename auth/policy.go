@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+const WILDCARD = "*"
+
+//Grant allows the actions of a plugin of the provider and api version in the regions, empty regions mean any region,
+//provider and version should be named or be the wildcard so a grant doesn't cover the providers registered later
+type Grant struct {
+	Provider string   `json:"provider"`
+	Version  string   `json:"version"`
+	Plugin   string   `json:"plugin"`
+	Actions  []string `json:"actions"`
+	Regions  []string `json:"regions,omitempty"`
+}
+
+//Target is the plugin action run by a request
+type Target struct {
+	Provider string
+	Version  string
+	Plugin   string
+	Action   string
+}
+
+func (target Target) String() string {
+	return target.Version + "/" + target.Provider + "/" + target.Plugin + "/" + target.Action
+}
+
+func (grant Grant) matches(target Target) bool {
+	return matchAny([]string{grant.Provider}, target.Provider) && matchAny([]string{grant.Version}, target.Version) &&
+		matchAny([]string{grant.Plugin}, target.Plugin) && matchAny(grant.Actions, target.Action)
+}
+
+//Policy maps principal names like bearer:<name>, hmac:<access key>, jwt:<subject> and mtls:<common name>,
+//or role:<ROLE> for jwt roles, to their grants
+type Policy struct {
+	Principals map[string][]Grant `json:"principals"`
+}
+
+func LoadPolicy(fileName string) (*Policy, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("read auth policy file(%s) meet error=%v", fileName, err)
+	}
+
+	policy := &Policy{}
+	if err = json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("unmarshal auth policy file(%s) meet error=%v", fileName, err)
+	}
+	for principal, grants := range policy.Principals {
+		for _, grant := range grants {
+			if grant.Provider == "" || grant.Version == "" || grant.Plugin == "" || len(grant.Actions) == 0 {
+				return nil, fmt.Errorf("auth policy of %s has grant without provider, version, plugin or actions", principal)
+			}
+		}
+	}
+	return policy, nil
+}
+
+func (policy *Policy) Authorize(principal *Principal, target Target, regions []string) error {
+	grants := append([]Grant{}, policy.Principals[principal.Name]...)
+	for _, role := range principal.Roles {
+		grants = append(grants, policy.Principals[ROLE_PRINCIPAL_PREFIX+role]...)
+	}
+
+	//every region may be allowed by a different grant,
+	//a request without region is only allowed by the grants without region limits
+	allowed := false
+	unlimited := false
+	uncovered := map[string]bool{}
+	for _, region := range regions {
+		uncovered[region] = true
+	}
+	for _, grant := range grants {
+		if !grant.matches(target) {
+			continue
+		}
+		allowed = true
+		unlimited = unlimited || len(grant.Regions) == 0
+		for region := range uncovered {
+			if len(grant.Regions) == 0 || matchAny(grant.Regions, region) {
+				delete(uncovered, region)
+			}
+		}
+	}
+
+	if !allowed {
+		return fmt.Errorf("principal %s is not allowed to run %s", principal.Name, target)
+	}
+	if len(regions) == 0 && !unlimited {
+		return fmt.Errorf("principal %s is only allowed to run %s in some regions, but the request has no region", principal.Name, target)
+	}
+	for _, region := range regions {
+		if uncovered[region] {
+			return fmt.Errorf("principal %s is not allowed to run %s in region %s", principal.Name, target, region)
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == WILDCARD || pattern == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	//client certificates are required and verified against the bundle when it is set
+	ClientCAFile   string
+	ReloadInterval time.Duration
+}
+
+//CertReloader serves the certificate and client CA bundle of the files, which are reloaded when they are modified
+type CertReloader struct {
+	config TLSConfig
+
+	mutex         sync.RWMutex
+	certificate   *tls.Certificate
+	clientCAs     *x509.CertPool
+	modTimes      map[string]time.Time
+	lastCheckTime time.Time
+}
+
+func NewCertReloader(config TLSConfig) (*CertReloader, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, fmt.Errorf("tls cert file and key file should both be set")
+	}
+
+	reloader := &CertReloader{config: config}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+//TLSConfig returns the server config, every handshake uses the latest certificate and client CA bundle
+func (reloader *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			reloader.reloadIfModified()
+
+			reloader.mutex.RLock()
+			defer reloader.mutex.RUnlock()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*reloader.certificate},
+			}
+			if reloader.clientCAs != nil {
+				config.ClientCAs = reloader.clientCAs
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return config, nil
+		},
+	}
+}
+
+func (reloader *CertReloader) files() []string {
+	files := []string{reloader.config.CertFile, reloader.config.KeyFile}
+	if reloader.config.ClientCAFile != "" {
+		files = append(files, reloader.config.ClientCAFile)
+	}
+	return files
+}
+
+func (reloader *CertReloader) reloadIfModified() {
+	reloader.mutex.Lock()
+	if time.Since(reloader.lastCheckTime) < reloader.config.ReloadInterval {
+		reloader.mutex.Unlock()
+		return
+	}
+	reloader.lastCheckTime = time.Now()
+	modified := false
+	for _, file := range reloader.files() {
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Equal(reloader.modTimes[file]) {
+			modified = true
+		}
+	}
+	reloader.mutex.Unlock()
+
+	if !modified {
+		return
+	}
+	//keep serving the old certificate when the new files are broken or half written
+	if err := reloader.load(); err != nil {
+		logrus.Errorf("reload tls certificate meet error=%v", err)
+		return
+	}
+	logrus.Infof("tls certificate %s is reloaded", reloader.config.CertFile)
+}
+
+func (reloader *CertReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, file := range reloader.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("stat tls file(%s) meet error=%v", file, err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	certificate, err := tls.LoadX509KeyPair(reloader.config.CertFile, reloader.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load tls key pair(%s, %s) meet error=%v", reloader.config.CertFile, reloader.config.KeyFile, err)
+	}
+
+	var clientCAs *x509.CertPool
+	if reloader.config.ClientCAFile != "" {
+		data, err := ioutil.ReadFile(reloader.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read tls client ca file(%s) meet error=%v", reloader.config.ClientCAFile, err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("tls client ca file(%s) has no valid certificate", reloader.config.ClientCAFile)
+		}
+	}
+
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	reloader.certificate = &certificate
+	reloader.clientCAs = clientCAs
+	reloader.modTimes = modTimes
+	reloader.lastCheckTime = time.Now()
+	return nil
+}
+
+//MtlsAuthenticator uses the common name of the verified client certificate as principal
+type MtlsAuthenticator struct {
+}
+
+func (authenticator *MtlsAuthenticator) Authenticate(r *http.Request, body []byte) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+
+	commonName := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if commonName == "" {
+		return nil, fmt.Errorf("client certificate has no common name")
+	}
+	return newPrincipal(METHOD_MTLS, commonName, nil), nil
+}
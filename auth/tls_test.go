@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSelfSignedCert(t *testing.T, dir string, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	os.Chtimes(certFile, modTime, modTime)
+	os.Chtimes(keyFile, modTime, modTime)
+}
+
+func getServedCommonName(t *testing.T, config *tls.Config) string {
+	served, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(served.Certificates[0].Certificate[0])
+	return cert.Subject.CommonName
+}
+
+func TestUnitCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-reloader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSelfSignedCert(t, dir, "old", time.Now().Add(-time.Minute))
+	reloader, err := NewCertReloader(TLSConfig{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	})
+	if err != nil {
+		t.Fatalf("new cert reloader meet err=%v", err)
+	}
+	config := reloader.TLSConfig()
+	if name := getServedCommonName(t, config); name != "old" {
+		t.Errorf("served cert is %s, want old", name)
+	}
+
+	writeSelfSignedCert(t, dir, "new", time.Now())
+	if name := getServedCommonName(t, config); name != "new" {
+		t.Errorf("served cert is %s after rotation, want new", name)
+	}
+}
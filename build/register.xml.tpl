@@ -6,7 +6,7 @@
     <container-port>8081</container-port>
     <container-config-directory>/home/app/wecube-plugins-qcloud/conf</container-config-directory>
     <container-log-directory>/home/app/wecube-plugins-qcloud/log</container-log-directory>
-    <container-start-param>-v /etc/localtime:/etc/localtime -v /home/app/wecube-plugins-qcloud/logs:/home/app/wecube-plugins-qcloud/logs</container-start-param>
+    <container-start-param>-v /etc/localtime:/etc/localtime -v /home/app/wecube-plugins-qcloud/logs:/home/app/wecube-plugins-qcloud/logs -v /home/app/wecube-plugins-qcloud/data:/home/app/wecube-plugins-qcloud/data</container-start-param>
     <plugin id="vpc" name="Vpc Management" >
         <interface name="create" path="/v1/qcloud/vpc/create">
             <input-parameters>
@@ -221,12 +221,25 @@
                 <parameter datatype="string">instance_type</parameter>
                 <parameter datatype="string">image_id</parameter>
                 <parameter datatype="number">system_disk_size</parameter>
+                <parameter datatype="string">system_disk_type</parameter>
+                <parameter datatype="string">data_disks</parameter>
                 <parameter datatype="string">instance_charge_type</parameter>
                 <parameter datatype="number">instance_charge_period</parameter>
                 <parameter datatype="string">instance_private_ip</parameter>
                 <parameter datatype="string">password</parameter>
+                <parameter datatype="string">key_ids</parameter>
                 <parameter datatype="string">id</parameter>
                 <parameter datatype="string">project_id</parameter>
+                <parameter datatype="string">public_ip_assigned</parameter>
+                <parameter datatype="string">internet_charge_type</parameter>
+                <parameter datatype="number">internet_max_bandwidth_out</parameter>
+                <parameter datatype="string">host_name</parameter>
+                <parameter datatype="string">user_data</parameter>
+                <parameter datatype="string">user_data_template</parameter>
+                <parameter datatype="string">wait_ssh_ready</parameter>
+                <parameter datatype="number">count</parameter>
+                <parameter datatype="string">zones</parameter>
+                <parameter datatype="string">placement_strategy</parameter>
             </input-parameters>
             <output-parameters>
                 <parameter datatype="string">guid</parameter>
@@ -236,6 +249,10 @@
                 <parameter datatype="string">password</parameter>
                 <parameter datatype="string">instance_state</parameter>
                 <parameter datatype="string">instance_private_ip</parameter>
+                <parameter datatype="number">index</parameter>
+                <parameter datatype="string">zone</parameter>
+                <parameter datatype="string">instance_name</parameter>
+                <parameter datatype="string">error_message</parameter>
             </output-parameters>
         </interface>
         <interface name="terminate" path="/v1/qcloud/vm/terminate">
@@ -268,7 +285,91 @@
                 <parameter datatype="string">guid</parameter>
             </output-parameters>
         </interface>
+        <interface name="resize" path="/v1/qcloud/vm/resize">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">instance_type</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">cpu</parameter>
+                <parameter datatype="string">memory</parameter>
+                <parameter datatype="string">instance_state</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="reboot" path="/v1/qcloud/vm/reboot">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">reboot_mode</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">instance_state</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="reinstall" path="/v1/qcloud/vm/reinstall">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">seed</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">image_id</parameter>
+                <parameter datatype="string">password</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">cpu</parameter>
+                <parameter datatype="string">memory</parameter>
+                <parameter datatype="string">password</parameter>
+                <parameter datatype="string">instance_state</parameter>
+                <parameter datatype="string">instance_private_ip</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="reset-password" path="/v1/qcloud/vm/reset-password">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">seed</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">password</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">password</parameter>
+            </output-parameters>
+        </interface>
 
+        <interface name="query" path="/v1/qcloud/vm/query">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">instance_ids</parameter>
+                <parameter datatype="string">instance_name</parameter>
+                <parameter datatype="string">private_ips</parameter>
+                <parameter datatype="string">public_ips</parameter>
+                <parameter datatype="string">vpc_id</parameter>
+                <parameter datatype="string">subnet_id</parameter>
+                <parameter datatype="string">zone</parameter>
+                <parameter datatype="string">security_group_id</parameter>
+                <parameter datatype="string">instance_state</parameter>
+                <parameter datatype="string">instance_charge_type</parameter>
+                <parameter datatype="string">tags</parameter>
+                <parameter datatype="number">offset</parameter>
+                <parameter datatype="number">limit</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="number">total_count</parameter>
+                <parameter datatype="string">instances</parameter>
+            </output-parameters>
+        </interface>
         <interface name="bind security group to vm" path="/v1/qcloud/vm/bind-security-groups">
             <input-parameters>
                 <parameter datatype="string">guid</parameter>
@@ -323,8 +424,11 @@
                 <parameter datatype="string">instance_guid</parameter>
                 <parameter datatype="string">seed</parameter>
                 <parameter datatype="string">password</parameter>
+                <parameter datatype="string">private_key</parameter>
                 <parameter datatype="string">file_system_type</parameter>
                 <parameter datatype="string">mount_dir</parameter>
+                <parameter datatype="number">disk_count</parameter>
+                <parameter datatype="string">volume_layout</parameter>
             </input-parameters>
             <output-parameters>
                 <parameter datatype="string">guid</parameter>
@@ -343,9 +447,35 @@
                 <parameter datatype="string">instance_guid</parameter>
                 <parameter datatype="string">seed</parameter>
                 <parameter datatype="string">password</parameter>
+                <parameter datatype="string">private_key</parameter>
+                <parameter datatype="string">snapshot_before_terminate</parameter>
+                <parameter datatype="string">snapshot_name</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">snapshot_id</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="expand cbs disk and grow file system" path="/v1/qcloud/cbs/expand">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="number">disk_size</parameter>
+                <parameter datatype="string">volume_name</parameter>
+                <parameter datatype="string">mount_dir</parameter>
+                <parameter datatype="string">file_system_type</parameter>
+                <parameter datatype="string">instance_id</parameter>
+                <parameter datatype="string">instance_guid</parameter>
+                <parameter datatype="string">seed</parameter>
+                <parameter datatype="string">password</parameter>
+                <parameter datatype="string">private_key</parameter>
             </input-parameters>
             <output-parameters>
                 <parameter datatype="string">guid</parameter>
+                <parameter datatype="number">disk_size</parameter>
+                <parameter datatype="string">file_system_type</parameter>
+                <parameter datatype="string">os_disk_size</parameter>
             </output-parameters>
         </interface>
 
@@ -564,4 +694,285 @@
             </output-parameters>
         </interface>
     </plugin>
+    <plugin id="key-pair" name="Key Pair Management">
+        <interface name="create" path="/v1/qcloud/key-pair/create">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">seed</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">name</parameter>
+                <parameter datatype="number">project_id</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">public_key</parameter>
+                <parameter datatype="string">private_key</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="import" path="/v1/qcloud/key-pair/import">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">name</parameter>
+                <parameter datatype="number">project_id</parameter>
+                <parameter datatype="string">public_key</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">public_key</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="terminate" path="/v1/qcloud/key-pair/terminate">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="bind" path="/v1/qcloud/key-pair/bind">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">instance_ids</parameter>
+                <parameter datatype="string">force_stop</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="unbind" path="/v1/qcloud/key-pair/unbind">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">instance_ids</parameter>
+                <parameter datatype="string">force_stop</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+            </output-parameters>
+        </interface>
+    </plugin>
+    <plugin id="image" name="Image Management">
+        <interface name="create" path="/v1/qcloud/image/create">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">name</parameter>
+                <parameter datatype="string">description</parameter>
+                <parameter datatype="string">instance_id</parameter>
+                <parameter datatype="string">data_disk_ids</parameter>
+                <parameter datatype="string">force_power_off</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">image_state</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="copy" path="/v1/qcloud/image/copy">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">destination_regions</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">image_state</parameter>
+                <parameter datatype="string">copied_image_ids</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="share" path="/v1/qcloud/image/share">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">account_ids</parameter>
+                <parameter datatype="string">permission</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">image_state</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="terminate" path="/v1/qcloud/image/terminate">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="query" path="/v1/qcloud/image/query">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">name</parameter>
+                <parameter datatype="string">image_type</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">images</parameter>
+            </output-parameters>
+        </interface>
+    </plugin>
+    <plugin id="snapshot" name="Snapshot Management">
+        <interface name="create" path="/v1/qcloud/snapshot/create">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">name</parameter>
+                <parameter datatype="string">disk_id</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">snapshot_state</parameter>
+                <parameter datatype="string">disk_id</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="terminate" path="/v1/qcloud/snapshot/terminate">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="query" path="/v1/qcloud/snapshot/query">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">name</parameter>
+                <parameter datatype="string">disk_id</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">snapshots</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="rollback" path="/v1/qcloud/snapshot/rollback">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">disk_id</parameter>
+                <parameter datatype="string">stop_instance</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">disk_id</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="create-disk" path="/v1/qcloud/snapshot/create-disk">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">disk_type</parameter>
+                <parameter datatype="number">disk_size</parameter>
+                <parameter datatype="string">disk_name</parameter>
+                <parameter datatype="string">disk_charge_type</parameter>
+                <parameter datatype="string">disk_charge_period</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">id</parameter>
+                <parameter datatype="string">disk_id</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="create-policy" path="/v1/qcloud/snapshot/create-policy">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">policy_id</parameter>
+                <parameter datatype="string">policy_name</parameter>
+                <parameter datatype="string">days_of_week</parameter>
+                <parameter datatype="string">hours</parameter>
+                <parameter datatype="number">retention_days</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">policy_id</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="terminate-policy" path="/v1/qcloud/snapshot/terminate-policy">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">policy_id</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">policy_id</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="bind-policy" path="/v1/qcloud/snapshot/bind-policy">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">policy_id</parameter>
+                <parameter datatype="string">disk_ids</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">policy_id</parameter>
+            </output-parameters>
+        </interface>
+        <interface name="unbind-policy" path="/v1/qcloud/snapshot/unbind-policy">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">policy_id</parameter>
+                <parameter datatype="string">disk_ids</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">policy_id</parameter>
+            </output-parameters>
+        </interface>
+    </plugin>
+    <plugin id="host" name="Host Management">
+        <interface name="run-script" path="/v1/qcloud/host/run-script">
+            <input-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">provider_params</parameter>
+                <parameter datatype="string">instance_ids</parameter>
+                <parameter datatype="string">ips</parameter>
+                <parameter datatype="string">instance_guid</parameter>
+                <parameter datatype="string">seed</parameter>
+                <parameter datatype="string">password</parameter>
+                <parameter datatype="string">private_key</parameter>
+                <parameter datatype="string">script</parameter>
+                <parameter datatype="string">script_file</parameter>
+                <parameter datatype="string">script_args</parameter>
+                <parameter datatype="number">timeout</parameter>
+                <parameter datatype="number">concurrency</parameter>
+            </input-parameters>
+            <output-parameters>
+                <parameter datatype="string">guid</parameter>
+                <parameter datatype="string">results</parameter>
+            </output-parameters>
+        </interface>
+    </plugin>
 </package>
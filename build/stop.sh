@@ -1,3 +1,4 @@
 #!/bin/bash
 
-kill -9 `pidof wecube-plugins-qcloud`
\ No newline at end of file
+#SIGTERM lets running actions finish, see shutdown_timeout in conf/app.conf
+kill `pidof wecube-plugins-qcloud`
\ No newline at end of file
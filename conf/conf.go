@@ -15,6 +15,30 @@ type AppConfig struct {
 	HttpPort        string
 	CMDBLink        string
 	CMDBUserAuthKey string
+	StrictJson      bool
+
+	CallbackSecret        string
+	CallbackStoreDir      string
+	CallbackMaxRetries    int
+	CallbackRetryInterval int
+	CallbackAllowedHosts  string
+
+	ShutdownTimeout int
+	TaskStoreDir    string
+	TaskSecret      string
+	MetricsAddr     string
+
+	AuthMethods       string
+	AuthBearerTokens  string
+	AuthHmacKeys      string
+	AuthHmacMaxSkew   int
+	AuthJwtSigningKey string
+	AuthPolicyFile    string
+
+	TlsCertFile       string
+	TlsKeyFile        string
+	TlsClientCAFile   string
+	TlsReloadInterval int
 }
 
 type AppConfigMgr struct {
@@ -45,6 +69,31 @@ func InitConfig(file string) {
 		return
 	}
 
+	GobalAppConfig.StrictJson = conf.GetIStringDefault("strict_json", "false") == "true"
+
+	GobalAppConfig.CallbackSecret = conf.GetIStringDefault("callback_secret", "")
+	GobalAppConfig.CallbackStoreDir = conf.GetIStringDefault("callback_store_dir", "./data/callbacks")
+	GobalAppConfig.CallbackMaxRetries = conf.GetIntDefault("callback_max_retries", 10)
+	GobalAppConfig.CallbackRetryInterval = conf.GetIntDefault("callback_retry_interval", 5)
+	GobalAppConfig.CallbackAllowedHosts = conf.GetIStringDefault("callback_allowed_hosts", "")
+
+	GobalAppConfig.ShutdownTimeout = conf.GetIntDefault("shutdown_timeout", 60)
+	GobalAppConfig.TaskStoreDir = conf.GetIStringDefault("task_store_dir", "./data/tasks")
+	GobalAppConfig.TaskSecret = conf.GetIStringDefault("task_secret", "")
+	GobalAppConfig.MetricsAddr = conf.GetIStringDefault("metrics_addr", "")
+
+	GobalAppConfig.AuthMethods = conf.GetIStringDefault("auth_methods", "")
+	GobalAppConfig.AuthBearerTokens = conf.GetIStringDefault("auth_bearer_tokens", "")
+	GobalAppConfig.AuthHmacKeys = conf.GetIStringDefault("auth_hmac_keys", "")
+	GobalAppConfig.AuthHmacMaxSkew = conf.GetIntDefault("auth_hmac_max_skew", 300)
+	GobalAppConfig.AuthJwtSigningKey = conf.GetIStringDefault("auth_jwt_signing_key", "")
+	GobalAppConfig.AuthPolicyFile = conf.GetIStringDefault("auth_policy_file", "")
+
+	GobalAppConfig.TlsCertFile = conf.GetIStringDefault("tls_cert_file", "")
+	GobalAppConfig.TlsKeyFile = conf.GetIStringDefault("tls_key_file", "")
+	GobalAppConfig.TlsClientCAFile = conf.GetIStringDefault("tls_client_ca_file", "")
+	GobalAppConfig.TlsReloadInterval = conf.GetIntDefault("tls_reload_interval", 60)
+
 	AppConfMgr.Config.Store(GobalAppConfig)
 }
 
@@ -101,7 +150,7 @@ func lineParse(lineNo *int, line *string, m *map[string]string) {
 		return
 	}
 
-	itemSlice := strings.Split(l, "=")
+	itemSlice := strings.SplitN(l, "=", 2)
 	if len(itemSlice) == 0 {
 		fmt.Printf("invalid config, line:%d", lineNo)
 		return
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"expvar"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	_ "github.com/WeBankPartners/wecube-plugins-qcloud/plugins/bussiness_plugins/security_group"
 
+	"github.com/WeBankPartners/wecube-plugins-qcloud/auth"
 	"github.com/WeBankPartners/wecube-plugins-qcloud/conf"
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
 	"github.com/sirupsen/logrus"
@@ -18,17 +28,136 @@ const (
 	CONF_FILE_PATH = "./conf/app.conf"
 )
 
+var callbackNotifier *plugins.CallbackNotifier
+var authGuard *auth.Guard
+var taskTracker *plugins.TaskTracker
+
+//router serves the plugin actions only, expvar registers /debug/vars on http.DefaultServeMux
+var router = http.NewServeMux()
+
 func init() {
 	initConfig()
 	initLogger()
+	initCallbackNotifier()
+	initTaskTracker()
+	initAuthGuard()
 	initRouter()
 }
 
 func main() {
 	logrus.Infof("Start WeCube-Plungins-Qcloud Service ... ")
 
-	if err := http.ListenAndServe(":"+conf.GobalAppConfig.HttpPort, nil); err != nil {
-		logrus.Fatalf("ListenAndServe meet err = %v", err)
+	server := &http.Server{Addr: ":" + conf.GobalAppConfig.HttpPort, Handler: router}
+	if conf.GobalAppConfig.TlsCertFile != "" {
+		certReloader, err := auth.NewCertReloader(auth.TLSConfig{
+			CertFile:       conf.GobalAppConfig.TlsCertFile,
+			KeyFile:        conf.GobalAppConfig.TlsKeyFile,
+			ClientCAFile:   conf.GobalAppConfig.TlsClientCAFile,
+			ReloadInterval: time.Duration(conf.GobalAppConfig.TlsReloadInterval) * time.Second,
+		})
+		if err != nil {
+			logrus.Fatalf("init tls meet err = %v", err)
+		}
+		server.TLSConfig = certReloader.TLSConfig()
+	} else {
+		logrus.Warnf("tls_cert_file is empty, serve in plaintext")
+	}
+
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logrus.Fatalf("ListenAndServe meet err = %v", err)
+		}
+	}()
+
+	serveMetrics()
+	resumeUnfinishedTasks()
+	waitForShutdown(server)
+}
+
+//serveMetrics serves expvar on metrics_addr apart from the plugin actions, which should not be reachable from outside
+func serveMetrics() {
+	if conf.GobalAppConfig.MetricsAddr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	go func() {
+		if err := http.ListenAndServe(conf.GobalAppConfig.MetricsAddr, mux); err != nil {
+			logrus.Errorf("serve metrics on %s meet err = %v", conf.GobalAppConfig.MetricsAddr, err)
+		}
+	}()
+}
+
+//waitForShutdown stops accepting requests on SIGTERM or SIGINT and waits for running actions until shutdown_timeout,
+//the unfinished actions stay in task_store_dir and are resumed or reported as failed on next start
+func waitForShutdown(server *http.Server) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-signals
+
+	timeout := time.Duration(conf.GobalAppConfig.ShutdownTimeout) * time.Second
+	logrus.Infof("receive signal %v, shutdown in %v", sig, timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		if err := server.Shutdown(ctx); err != nil {
+			logrus.Errorf("shutdown http server meet err = %v", err)
+		}
+	}()
+
+	unfinished := taskTracker.Drain(timeout)
+	for _, task := range unfinished {
+		logrus.Warnf("%v is unfinished, created resources=%++v", task, task.Inputs)
+	}
+	logrus.Infof("shutdown done, %d tasks unfinished", len(unfinished))
+}
+
+//resumeUnfinishedTasks runs the resumable tasks interrupted by the previous process again,
+//the others are posted to their callback_url as failed with the created resource ids in the results
+func resumeUnfinishedTasks() {
+	tasks, err := taskTracker.LoadUnfinished()
+	if err != nil {
+		logrus.Errorf("load unfinished tasks meet err = %v", err)
+		return
+	}
+
+	for _, task := range tasks {
+		if task.Resumable() {
+			logrus.Infof("resume %v, inputs=%++v", task, task.Inputs)
+			go resumeTask(task)
+			continue
+		}
+
+		logrus.Warnf("%v was interrupted, inputs=%++v", task, task.Inputs)
+		//the caller of a request without callback_url is gone, the log keeps the created resources
+		if task.CallbackUrl == "" {
+			taskTracker.Remove(task)
+			continue
+		}
+		if err = callbackNotifier.Notify(task.Id, task.CallbackUrl, plugins.NewInterruptedTaskResponse(task)); err != nil {
+			logrus.Errorf("notify callback of request(%s) meet err = %v", task.Id, err)
+			continue
+		}
+		taskTracker.Remove(task)
+	}
+}
+
+func resumeTask(task *plugins.Task) {
+	pluginResponse, _ := taskTracker.Run(task)
+	if task.CallbackUrl == "" {
+		logrus.Infof("resumed %v is finished, response=%++v", task, pluginResponse)
+		return
+	}
+	if err := callbackNotifier.Notify(task.Id, task.CallbackUrl, pluginResponse); err != nil {
+		logrus.Errorf("notify callback of request(%s) meet err = %v", task.Id, err)
 	}
 }
 
@@ -55,18 +184,217 @@ func initConfig() {
 	conf.InitConfig(CONF_FILE_PATH)
 }
 
+func initCallbackNotifier() {
+	var err error
+	callbackNotifier, err = plugins.NewCallbackNotifier(plugins.CallbackConfig{
+		Secret:        conf.GobalAppConfig.CallbackSecret,
+		StoreDir:      conf.GobalAppConfig.CallbackStoreDir,
+		MaxRetries:    conf.GobalAppConfig.CallbackMaxRetries,
+		RetryInterval: time.Duration(conf.GobalAppConfig.CallbackRetryInterval) * time.Second,
+		AllowedHosts:  strings.Split(conf.GobalAppConfig.CallbackAllowedHosts, ","),
+	})
+	if err != nil {
+		logrus.Fatalf("init callback notifier meet err = %v", err)
+	}
+	if conf.GobalAppConfig.CallbackSecret == "" || conf.GobalAppConfig.CallbackAllowedHosts == "" {
+		logrus.Warnf("callback_secret or callback_allowed_hosts is empty, requests with callback_url are rejected")
+	}
+
+	if err = callbackNotifier.Resume(); err != nil {
+		logrus.Errorf("resume callback deliveries meet err = %v", err)
+	}
+}
+
+func initAuthGuard() {
+	jwtSigningKey, err := base64.StdEncoding.DecodeString(conf.GobalAppConfig.AuthJwtSigningKey)
+	if err != nil {
+		logrus.Fatalf("auth_jwt_signing_key should be base64 encoded, err = %v", err)
+	}
+
+	authGuard, err = auth.NewGuard(auth.Config{
+		Methods:       strings.Split(conf.GobalAppConfig.AuthMethods, ","),
+		BearerTokens:  parseCredentialPairs(conf.GobalAppConfig.AuthBearerTokens, true),
+		HmacKeys:      parseCredentialPairs(conf.GobalAppConfig.AuthHmacKeys, false),
+		HmacMaxSkew:   time.Duration(conf.GobalAppConfig.AuthHmacMaxSkew) * time.Second,
+		JwtSigningKey: jwtSigningKey,
+		PolicyFile:    conf.GobalAppConfig.AuthPolicyFile,
+	})
+	if err != nil {
+		logrus.Fatalf("init auth guard meet err = %v", err)
+	}
+	if !authGuard.Enabled() {
+		logrus.Warnf("auth_methods is empty, requests are not authenticated")
+	} else if !authGuard.HasPolicy() {
+		logrus.Warnf("auth_policy_file is empty, every authenticated principal may run any action")
+	}
+}
+
+//parseCredentialPairs parses "a:b,c:d", the map is keyed by the second item when valueFirst is true
+func parseCredentialPairs(str string, valueFirst bool) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range strings.Split(str, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			continue
+		}
+		if valueFirst {
+			pairs[kv[1]] = kv[0]
+		} else {
+			pairs[kv[0]] = kv[1]
+		}
+	}
+	return pairs
+}
+
+func initTaskTracker() {
+	var err error
+	taskTracker, err = plugins.NewTaskTracker(conf.GobalAppConfig.TaskStoreDir, conf.GobalAppConfig.TaskSecret)
+	if err != nil {
+		logrus.Fatalf("init task tracker meet err = %v", err)
+	}
+}
+
 func initRouter() {
 	//path should be defined as "/[version]/[provider]/[plugin]/[action]"
-	http.HandleFunc("/v1/qcloud/", routeDispatcher)
+	//unknown providers and versions are rejected by plugins.Process
+	//metrics such as resource lock waits are served by serveMetrics on metrics_addr
+	router.HandleFunc("/", routeDispatcher)
 }
 
 func routeDispatcher(w http.ResponseWriter, r *http.Request) {
-	pluginRequest := parsePluginRequest(r)
-	pluginResponse, _ := plugins.Process(pluginRequest)
+	pluginRequest, body := parsePluginRequest(r)
+	if statusCode, err := authorizeRequest(r, pluginRequest, body); err != nil {
+		logrus.Errorf("reject request of plugin[%v]-action[%v], err = %v", pluginRequest.Name, pluginRequest.Action, err)
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(statusCode)
+		write(w, &plugins.PluginResponse{ResultCode: "1", ResultMsg: err.Error()})
+		return
+	}
+
+	if pluginRequest.CallbackUrl != "" {
+		processWithCallback(w, pluginRequest, body)
+		return
+	}
+
+	requestId, err := plugins.NewCallbackRequestId()
+	if err != nil {
+		write(w, &plugins.PluginResponse{ResultCode: "1", ResultMsg: fmt.Sprintf("create request id meet error(%v)", err)})
+		return
+	}
+	pluginResponse, _ := taskTracker.Run(newTask(requestId, pluginRequest, body))
 	logrus.Infof("write data to client response=%++v", pluginResponse)
 	write(w, pluginResponse)
 }
 
+//processWithCallback accepts the request at once and posts the response to callback_url when the action is finished
+func processWithCallback(w http.ResponseWriter, pluginRequest *plugins.PluginRequest, body []byte) {
+	pluginResponse := &plugins.PluginResponse{ResultCode: "1"}
+	if err := callbackNotifier.CheckCallbackUrl(pluginRequest.CallbackUrl); err != nil {
+		pluginResponse.ResultMsg = err.Error()
+		write(w, pluginResponse)
+		return
+	}
+
+	requestId, err := plugins.NewCallbackRequestId()
+	if err != nil {
+		pluginResponse.ResultMsg = fmt.Sprintf("create callback request id meet error(%v)", err)
+		write(w, pluginResponse)
+		return
+	}
+
+	go func() {
+		actionResponse, _ := taskTracker.Run(newTask(requestId, pluginRequest, body))
+		if err := callbackNotifier.Notify(requestId, pluginRequest.CallbackUrl, actionResponse); err != nil {
+			logrus.Errorf("notify callback of request(%s) meet err = %v", requestId, err)
+		}
+	}()
+
+	pluginResponse.ResultCode = "0"
+	pluginResponse.ResultMsg = "accepted"
+	pluginResponse.Results = map[string]string{"request_id": requestId}
+	logrus.Infof("request(%s) accepted, response will be posted to %s", requestId, pluginRequest.CallbackUrl)
+	write(w, pluginResponse)
+}
+
+func newTask(id string, pluginRequest *plugins.PluginRequest, body []byte) *plugins.Task {
+	return &plugins.Task{
+		Id:           id,
+		ProviderName: pluginRequest.ProviderName,
+		Version:      pluginRequest.Version,
+		Name:         pluginRequest.Name,
+		Action:       pluginRequest.Action,
+		Parameters:   body,
+		CallbackUrl:  pluginRequest.CallbackUrl,
+		StrictJson:   pluginRequest.StrictJson,
+	}
+}
+
+//authorizeRequest authenticates the request and checks the policy before plugins.Process runs
+func authorizeRequest(r *http.Request, pluginRequest *plugins.PluginRequest, body []byte) (int, error) {
+	if !authGuard.Enabled() {
+		return http.StatusOK, nil
+	}
+
+	principal, err := authGuard.Authenticate(r, body)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+
+	target := auth.Target{
+		Provider: pluginRequest.ProviderName,
+		Version:  pluginRequest.Version,
+		Plugin:   pluginRequest.Name,
+		Action:   pluginRequest.Action,
+	}
+	if err = authGuard.Authorize(principal, target, getRequestRegions(body)); err != nil {
+		return http.StatusForbidden, err
+	}
+	logrus.Infof("principal[%s] authenticated by %s runs plugin[%v]-action[%v]", principal.Name, principal.Method, pluginRequest.Name, pluginRequest.Action)
+	return http.StatusOK, nil
+}
+
+//regionInputKeys are the inputs naming regions other than provider params, the regions are separated by comma
+var regionInputKeys = []string{"destination_regions"}
+
+//getRequestRegions collects the Region of every provider params and the regions of regionInputKeys in the inputs,
+//keys are matched case-insensitively as the json decoder of the actions does
+func getRequestRegions(body []byte) []string {
+	request := struct {
+		Inputs []map[string]interface{} `json:"inputs"`
+	}{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil
+	}
+
+	regions := []string{}
+	for _, input := range request.Inputs {
+		for key, value := range input {
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			key = strings.ToLower(key)
+			if strings.HasSuffix(key, "provider_params") {
+				paramsMap, err := plugins.GetMapFromProviderParams(str)
+				if err == nil && paramsMap["Region"] != "" {
+					regions = append(regions, paramsMap["Region"])
+				}
+			}
+			for _, regionKey := range regionInputKeys {
+				if key != regionKey {
+					continue
+				}
+				for _, region := range strings.Split(str, ",") {
+					if region = strings.TrimSpace(region); region != "" {
+						regions = append(regions, region)
+					}
+				}
+			}
+		}
+	}
+	return regions
+}
+
 func write(w http.ResponseWriter, output *plugins.PluginResponse) {
 	w.Header().Set("content-type", "application/json")
 	b, err := json.Marshal(output)
@@ -76,7 +404,7 @@ func write(w http.ResponseWriter, output *plugins.PluginResponse) {
 	w.Write(b)
 }
 
-func parsePluginRequest(r *http.Request) *plugins.PluginRequest {
+func parsePluginRequest(r *http.Request) (*plugins.PluginRequest, []byte) {
 	var pluginInput = plugins.PluginRequest{}
 	pathStrings := strings.Split(r.URL.Path, "/")
 	logrus.Infof("path strings = %v", pathStrings)
@@ -86,7 +414,24 @@ func parsePluginRequest(r *http.Request) *plugins.PluginRequest {
 		pluginInput.Name = pathStrings[3]
 		pluginInput.Action = pathStrings[4]
 	}
-	pluginInput.Parameters = r.Body
+
+	//read the body once to pick up the optional callback_url and strict, actions read the parameters again
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logrus.Errorf("read http request body meet error (%v)", err)
+	}
+	requestOptions := struct {
+		CallbackUrl string `json:"callback_url"`
+		Strict      *bool  `json:"strict"`
+	}{}
+	pluginInput.StrictJson = conf.GobalAppConfig.StrictJson
+	if err = json.Unmarshal(body, &requestOptions); err == nil {
+		pluginInput.CallbackUrl = requestOptions.CallbackUrl
+		if requestOptions.Strict != nil {
+			pluginInput.StrictJson = *requestOptions.Strict
+		}
+	}
+	pluginInput.Parameters = bytes.NewReader(body)
 	logrus.Infof("parsed request = %v", pluginInput)
-	return &pluginInput
+	return &pluginInput, body
 }
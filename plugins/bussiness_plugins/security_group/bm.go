@@ -176,16 +176,15 @@ func (instance BmInstance) GetIp() string {
 }
 
 func createBmClient(region, secretId, secretKey string) (client *bm.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = QCLOUD_ENDPOINT_BM
-
-	client, err = bm.NewClient(credential, region, clientProfile)
+	cachedClient, err := plugins.GetCachedClient("bm", QCLOUD_ENDPOINT_BM, region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return bm.NewClient(credential, region, clientProfile)
+	})
 	if err != nil {
 		logrus.Errorf("createBmClient: failed to create Qcloud bm client, err=%v", err)
+		return nil, err
 	}
 
-	return client, err
+	return cachedClient.(*bm.Client), nil
 }
 
 func QueryBmInstance(providerParams string, filter plugins.Filter) ([]*bm.DeviceInfo, error) {
@@ -210,26 +209,32 @@ func QueryBmInstance(providerParams string, filter plugins.Filter) ([]*bm.Device
 		return nil, err
 	}
 
-	request := bm.NewDescribeDevicesRequest()
-	var offset, limit uint64 = 0, uint64(len(filterValues))
-	request.Limit = &limit
-	request.Offset = &offset
-	request.Limit = &limit
-	if filter.Name == "instanceId" {
-		request.InstanceIds = filterValues
-	}
-	if filter.Name == "lanIp" {
-		request.LanIps = filterValues
-	}
+	devices := []*bm.DeviceInfo{}
+	err = plugins.PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := bm.NewDescribeDevicesRequest()
+		request.Offset = common.Uint64Ptr(uint64(offset))
+		request.Limit = common.Uint64Ptr(uint64(limit))
+		if filter.Name == "instanceId" {
+			request.InstanceIds = filterValues
+		}
+		if filter.Name == "lanIp" {
+			request.LanIps = filterValues
+		}
 
-	response, err := client.DescribeDevices(request)
+		response, err := client.DescribeDevices(request)
+		if err != nil {
+			return 0, 0, err
+		}
+		devices = append(devices, response.Response.DeviceInfoSet...)
+		return len(response.Response.DeviceInfoSet), int(*response.Response.TotalCount), nil
+	})
 	if err != nil {
 		logrus.Errorf("QueryBmInstance DescribeDevices meet error=%v", err)
 		return nil, err
 	}
 
-	logrus.Infof("QueryBmInstance: return=%++v", response.Response.DeviceInfoSet)
-	return response.Response.DeviceInfoSet, nil
+	logrus.Infof("QueryBmInstance: return=%++v", devices)
+	return devices, nil
 }
 
 func QueryBmInstanceSecurityGroups(providerParams string, instanceId string) ([]string, error) {
@@ -213,17 +213,15 @@ func (instance BmlbInstance) GetBackendTargets(providerParams string, protocol s
 }
 
 func createBmlbClient(region, secretId, secretKey string) (client *bmlb.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = QCLOUD_ENDPOINT_BMLB
-
-	client, err = bmlb.NewClient(credential, region, clientProfile)
+	cachedClient, err := plugins.GetCachedClient("bmlb", QCLOUD_ENDPOINT_BMLB, region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return bmlb.NewClient(credential, region, clientProfile)
+	})
 	if err != nil {
 		logrus.Errorf("createBmlbClient: failed to create Qcloud bm client, err=%v", err)
+		return nil, err
 	}
 
-	return client, err
+	return cachedClient.(*bmlb.Client), nil
 }
 
 func QueryBmlbInstance(providerParams string, filter plugins.Filter) ([]*bmlb.LoadBalancer, error) {
@@ -246,23 +244,30 @@ func QueryBmlbInstance(providerParams string, filter plugins.Filter) ([]*bmlb.Lo
 	if err := plugins.IsValidValue(filter.Name, validFilterNames); err != nil {
 		return nil, err
 	}
-	request := bmlb.NewDescribeLoadBalancersRequest()
-	var offset, limit uint64 = 0, uint64(len(filterValues))
-	request.Limit = &limit
-	request.Offset = &offset
-	if filter.Name == "instanceId" {
-		request.LoadBalancerIds = filterValues
-	}
-	if filter.Name == "vip" {
-		request.LoadBalancerVips = filterValues
-	}
+	loadBalancers := []*bmlb.LoadBalancer{}
+	err = plugins.PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := bmlb.NewDescribeLoadBalancersRequest()
+		request.Offset = common.Uint64Ptr(uint64(offset))
+		request.Limit = common.Uint64Ptr(uint64(limit))
+		if filter.Name == "instanceId" {
+			request.LoadBalancerIds = filterValues
+		}
+		if filter.Name == "vip" {
+			request.LoadBalancerVips = filterValues
+		}
 
-	response, err := client.DescribeLoadBalancers(request)
+		response, err := client.DescribeLoadBalancers(request)
+		if err != nil {
+			return 0, 0, err
+		}
+		loadBalancers = append(loadBalancers, response.Response.LoadBalancerSet...)
+		return len(response.Response.LoadBalancerSet), int(*response.Response.TotalCount), nil
+	})
 	if err != nil {
 		logrus.Errorf("QueryBmlbInstance DescribeLoadBalancers meet error=%v", err)
 		return nil, err
 	}
 
-	logrus.Infof("QueryBmlbInstance: return=%++v", response.Response.LoadBalancerSet)
-	return response.Response.LoadBalancerSet, nil
+	logrus.Infof("QueryBmlbInstance: return=%++v", loadBalancers)
+	return loadBalancers, nil
 }
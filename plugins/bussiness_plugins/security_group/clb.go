@@ -31,11 +31,13 @@ func createClbClient(providerParams string) (client *clb.Client, err error) {
 		return nil, err
 	}
 
-	credential := common.NewCredential(paramsMap["SecretID"], paramsMap["SecretKey"])
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "clb.tencentcloudapi.com"
-
-	return clb.NewClient(credential, paramsMap["Region"], clientProfile)
+	cachedClient, err := plugins.GetCachedClient("clb", "clb.tencentcloudapi.com", paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"], func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return clb.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*clb.Client), nil
 }
 
 func (resourceType *ClbResourceType) IsSupportEgressPolicy() bool {
@@ -43,6 +45,30 @@ func (resourceType *ClbResourceType) IsSupportEgressPolicy() bool {
 	return false
 }
 
+//describeClbLoadBalancers returns the load balancers of the ids or the vips
+func describeClbLoadBalancers(client *clb.Client, instanceIds []string, vips []string) ([]*clb.LoadBalancer, error) {
+	loadBalancers := []*clb.LoadBalancer{}
+	err := plugins.PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := clb.NewDescribeLoadBalancersRequest()
+		if len(instanceIds) > 0 {
+			request.LoadBalancerIds = common.StringPtrs(instanceIds)
+		}
+		if len(vips) > 0 {
+			request.LoadBalancerVips = common.StringPtrs(vips)
+		}
+		request.Offset = common.Int64Ptr(int64(offset))
+		request.Limit = common.Int64Ptr(int64(limit))
+
+		resp, err := client.DescribeLoadBalancers(request)
+		if err != nil {
+			return 0, 0, err
+		}
+		loadBalancers = append(loadBalancers, resp.Response.LoadBalancerSet...)
+		return len(resp.Response.LoadBalancerSet), int(*resp.Response.TotalCount), nil
+	})
+	return loadBalancers, err
+}
+
 func (resourceType *ClbResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
 	logrus.Infof("ClbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
 
@@ -55,21 +81,15 @@ func (resourceType *ClbResourceType) QueryInstancesById(providerParams string, i
 	}
 
 	client, _ := createClbClient(providerParams)
-	var offset, limit int64 = 0, int64(len(instanceIds))
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
-	request := clb.NewDescribeLoadBalancersRequest()
-	request.LoadBalancerIds = common.StringPtrs(instanceIds)
-	request.Offset = &offset
-	request.Limit = &limit
-
-	resp, err := client.DescribeLoadBalancers(request)
+	loadBalancers, err := describeClbLoadBalancers(client, instanceIds, nil)
 	if err != nil {
 		logrus.Errorf("ClbResourceType QueryInstancesById DescribeLoadBalancers meet err0r=%v", err)
 		return result, err
 	}
 
-	for _, lb := range resp.Response.LoadBalancerSet {
+	for _, lb := range loadBalancers {
 		instance := ClbInstance{
 			Id:      *lb.LoadBalancerId,
 			Name:    *lb.LoadBalancerName,
@@ -98,21 +118,15 @@ func (resourceType *ClbResourceType) QueryInstancesByIp(providerParams string, i
 	}
 
 	client, _ := createClbClient(providerParams)
-	var offset, limit int64 = 0, int64(len(ips))
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
-	request := clb.NewDescribeLoadBalancersRequest()
-	request.LoadBalancerVips = common.StringPtrs(ips)
-	request.Offset = &offset
-	request.Limit = &limit
-
-	resp, err := client.DescribeLoadBalancers(request)
+	loadBalancers, err := describeClbLoadBalancers(client, nil, ips)
 	if err != nil {
 		logrus.Errorf("ClbResourceType QueryInstancesByIp DescribeLoadBalancers meet error=%v", err)
 		return result, err
 	}
 
-	for _, lb := range resp.Response.LoadBalancerSet {
+	for _, lb := range loadBalancers {
 		instance := ClbInstance{
 			Id:      *lb.LoadBalancerId,
 			Name:    *lb.LoadBalancerName,
@@ -27,11 +27,13 @@ func createMongodbClient(providerParams string) (client *mongodb.Client, err err
 		return nil, err
 	}
 
-	credential := common.NewCredential(paramsMap["SecretID"], paramsMap["SecretKey"])
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "mongodb.tencentcloudapi.com"
-
-	return mongodb.NewClient(credential, paramsMap["Region"], clientProfile)
+	cachedClient, err := plugins.GetCachedClient("mongodb", "mongodb.tencentcloudapi.com", paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"], func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return mongodb.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*mongodb.Client), nil
 }
 
 func (resourceType *MongodbResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
@@ -45,27 +47,14 @@ func (resourceType *MongodbResourceType) QueryInstancesById(providerParams strin
 		return result, err
 	}
 
-	client, _ := createMongodbClient(providerParams)
-	var offset, limit uint64 = 0, uint64(len(instanceIds))
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
-
-	request := mongodb.NewDescribeDBInstancesRequest()
-	request.InstanceIds = common.StringPtrs(instanceIds)
-	request.Offset = &offset
-	request.Limit = &limit
-
-	resp, err := client.DescribeDBInstances(request)
+	mongodbs, err := queryMongodbInstances(providerParams, instanceIds)
 	if err != nil {
 		logrus.Errorf("MongodbResourceType QueryInstancesById DescribeDBInstances meet error=%v", err)
 		return result, err
 	}
 
-	if *resp.Response.TotalCount == 0 {
-		logrus.Infof("MongodbResourceType QueryInstancesById DescribeDBInstances: Response.TotalCount==0")
-		return result, nil
-	}
-
-	for _, mongodb := range resp.Response.InstanceDetails {
+	for _, mongodb := range mongodbs {
 		instance := MongodbInstance{
 			Id:     *mongodb.InstanceId,
 			Name:   *mongodb.InstanceName,
@@ -79,32 +68,37 @@ func (resourceType *MongodbResourceType) QueryInstancesById(providerParams strin
 	return result, nil
 }
 
-func queryMongodbInstances(providerParams string, offset uint64, limit uint64) ([]*mongodb.MongoDBInstanceDetail, uint64, error) {
+//queryMongodbInstances returns all the instances when instanceIds is empty
+func queryMongodbInstances(providerParams string, instanceIds []string) ([]*mongodb.MongoDBInstanceDetail, error) {
 	client, _ := createMongodbClient(providerParams)
 	result := []*mongodb.MongoDBInstanceDetail{}
-	request := mongodb.NewDescribeDBInstancesRequest()
-	request.Offset = &offset
-	request.Limit = &limit
+	err := plugins.PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := mongodb.NewDescribeDBInstancesRequest()
+		if len(instanceIds) > 0 {
+			request.InstanceIds = common.StringPtrs(instanceIds)
+		}
+		request.Offset = common.Uint64Ptr(uint64(offset))
+		request.Limit = common.Uint64Ptr(uint64(limit))
 
-	resp, err := client.DescribeDBInstances(request)
+		resp, err := client.DescribeDBInstances(request)
+		if err != nil {
+			return 0, 0, err
+		}
+		result = append(result, resp.Response.InstanceDetails...)
+		return len(resp.Response.InstanceDetails), int(*resp.Response.TotalCount), nil
+	})
 	if err != nil {
 		logrus.Errorf("queryMongodbInstances DescribeDBInstances meet error=%v", err)
-		return result, 0, err
-	}
-
-	if *resp.Response.TotalCount == 0 {
-		logrus.Infof("queryMongodbInstances DescribeDBInstances: Response.TotalCount==0")
-		return result, 0, nil
+		return result, err
 	}
 
-	logrus.Infof("queryMongodbInstances: return Response.InstanceDetails=%++v", resp.Response.InstanceDetails)
-	return resp.Response.InstanceDetails, *resp.Response.TotalCount, nil
+	logrus.Infof("queryMongodbInstances: return InstanceDetails=%++v", result)
+	return result, nil
 }
 
 func (resourceType *MongodbResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
 	logrus.Infof("MongodbResourceType QueryInstancesByIp: request ips=%++v", ips)
 
-	var offset, limit uint64 = 0, 100
 	result := make(map[string]ResourceInstance)
 	if len(ips) == 0 {
 		err := fmt.Errorf("ips is empty")
@@ -115,32 +109,25 @@ func (resourceType *MongodbResourceType) QueryInstancesByIp(providerParams strin
 
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
-	for {
-		mongodbs, total, err := queryMongodbInstances(providerParams, offset, limit)
-		if err != nil {
-			logrus.Errorf("MongodbResourceType queryMongodbInstances meet error=%v", err)
-			return result, err
-		}
+	mongodbs, err := queryMongodbInstances(providerParams, nil)
+	if err != nil {
+		logrus.Errorf("MongodbResourceType queryMongodbInstances meet error=%v", err)
+		return result, err
+	}
 
-		for _, db := range mongodbs {
-			for _, ip := range ips {
-				if ip == *db.Vip {
-					instance := MongodbInstance{
-						Id:     *db.InstanceId,
-						Name:   *db.InstanceName,
-						Region: region,
-						Vip:    *db.Vip,
-					}
-					result[ip] = instance
-					break
+	for _, db := range mongodbs {
+		for _, ip := range ips {
+			if ip == *db.Vip {
+				instance := MongodbInstance{
+					Id:     *db.InstanceId,
+					Name:   *db.InstanceName,
+					Region: region,
+					Vip:    *db.Vip,
 				}
+				result[ip] = instance
+				break
 			}
 		}
-		if total > offset+limit {
-			offset = offset + limit
-		} else {
-			break
-		}
 	}
 
 	logrus.Infof("MongodbResourceType: result=%++v", result)
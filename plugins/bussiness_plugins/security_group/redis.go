@@ -32,11 +32,13 @@ func createRedisClient(providerParams string) (client *redis.Client, err error)
 		return nil, err
 	}
 
-	credential := common.NewCredential(paramsMap["SecretID"], paramsMap["SecretKey"])
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "redis.tencentcloudapi.com"
-
-	return redis.NewClient(credential, paramsMap["Region"], clientProfile)
+	cachedClient, err := plugins.GetCachedClient("redis", "redis.tencentcloudapi.com", paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"], func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return redis.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*redis.Client), nil
 }
 
 func redisQueryInstances(providerParams string, searchKeys []string, searchKeyType string) (map[string]ResourceInstance, error) {
@@ -44,7 +46,6 @@ func redisQueryInstances(providerParams string, searchKeys []string, searchKeyTy
 
 	result := make(map[string]ResourceInstance)
 	client, _ := createRedisClient(providerParams)
-	var offset, limit uint64 = 0, uint64(len(searchKeys))
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
 	if searchKeyType != REDIS_SEARCH_KEY_IP && searchKeyType != REDIS_SEARCH_KEY_ID {
@@ -54,23 +55,26 @@ func redisQueryInstances(providerParams string, searchKeys []string, searchKeyTy
 		return result, err
 	}
 
-	request := redis.NewDescribeInstancesRequest()
-	request.SearchKeys = common.StringPtrs(searchKeys)
-	request.Offset = &offset
-	request.Limit = &limit
+	redisInstances := []*redis.InstanceSet{}
+	err := plugins.PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := redis.NewDescribeInstancesRequest()
+		request.SearchKeys = common.StringPtrs(searchKeys)
+		request.Offset = common.Uint64Ptr(uint64(offset))
+		request.Limit = common.Uint64Ptr(uint64(limit))
 
-	resp, err := client.DescribeInstances(request)
+		resp, err := client.DescribeInstances(request)
+		if err != nil {
+			return 0, 0, err
+		}
+		redisInstances = append(redisInstances, resp.Response.InstanceSet...)
+		return len(resp.Response.InstanceSet), int(*resp.Response.TotalCount), nil
+	})
 	if err != nil {
 		logrus.Errorf("redisQueryInstances DescribeInstances meet error=%v", err)
 		return result, err
 	}
 
-	if *resp.Response.TotalCount == 0 {
-		logrus.Infof("redisQueryInstances DescribeInstances: Response.TotalCount==0")
-		return result, nil
-	}
-
-	for _, redis := range resp.Response.InstanceSet {
+	for _, redis := range redisInstances {
 		instance := RedisInstance{
 			Id:     *redis.InstanceId,
 			Name:   *redis.InstanceName,
@@ -841,6 +841,8 @@ func addPoliciesToSecurityGroup(providerParams string, securityGroupId string, p
 
 	securityGroupPolicySet := newSecurityPolicySet(policies, direction, true)
 	req.SecurityGroupPolicySet = &securityGroupPolicySet
+	unlock := plugins.LockResources("bs-security-group/add-policies", securityGroupId)
+	defer unlock()
 	if _, err = client.CreateSecurityGroupPolicies(req); err == nil {
 		for _, policy := range policies {
 			policy.SecurityGroupId = securityGroupId
@@ -953,7 +955,9 @@ func destroyPolicies(providerParams string, policies []*SecurityPolicy, directio
 		req.SecurityGroupId = &securityGroupId
 		req.SecurityGroupPolicySet = &securityGroupPolicySet
 
+		unlock := plugins.LockResources("bs-security-group/destroy-policies", securityGroupId)
 		_, err := client.DeleteSecurityGroupPolicies(req)
+		unlock()
 		if err != nil {
 			logrus.Errorf("destroyPolicies DeleteSecurityGroupPolicies meet error=%v,req=%++v", err, *req)
 			return err
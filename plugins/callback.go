@@ -0,0 +1,298 @@
+package plugins
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	CALLBACK_HEADER_SIGNATURE  = "X-Plugin-Signature"
+	CALLBACK_HEADER_TIMESTAMP  = "X-Plugin-Timestamp"
+	CALLBACK_HEADER_REQUEST_ID = "X-Plugin-Request-Id"
+
+	CALLBACK_PENDING_FILE_SUFFIX = ".json"
+	CALLBACK_DEAD_FILE_SUFFIX    = ".dead"
+
+	CALLBACK_MAX_RETRY_INTERVAL = 10 * time.Minute
+	CALLBACK_POST_TIMEOUT       = 30 * time.Second
+)
+
+//CallbackConfig rejects every callback_url when Secret or AllowedHosts is empty,
+//AllowedHosts are host names or host:port that the callback_url must match
+type CallbackConfig struct {
+	Secret        string
+	StoreDir      string
+	MaxRetries    int
+	RetryInterval time.Duration
+	AllowedHosts  []string
+}
+
+type callbackDelivery struct {
+	RequestId  string          `json:"request_id"`
+	Url        string          `json:"url"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"last_error,omitempty"`
+	CreateTime time.Time       `json:"create_time"`
+}
+
+//CallbackNotifier posts finished PluginResponses to the callback_url of a request.
+//every delivery is persisted in StoreDir until it succeeds, so pending deliveries survive restarts.
+type CallbackNotifier struct {
+	config       CallbackConfig
+	allowedHosts map[string]bool
+	httpClient   *http.Client
+	fileMutex    sync.Mutex
+}
+
+func NewCallbackNotifier(config CallbackConfig) (*CallbackNotifier, error) {
+	if config.StoreDir == "" {
+		return nil, fmt.Errorf("callback store dir is empty")
+	}
+	if config.MaxRetries <= 0 {
+		return nil, fmt.Errorf("callback max retries(%d) should be positive", config.MaxRetries)
+	}
+	if config.RetryInterval <= 0 {
+		return nil, fmt.Errorf("callback retry interval(%v) should be positive", config.RetryInterval)
+	}
+	if err := os.MkdirAll(config.StoreDir, 0700); err != nil {
+		return nil, fmt.Errorf("create callback store dir(%s) meet error=%v", config.StoreDir, err)
+	}
+
+	allowedHosts := map[string]bool{}
+	for _, host := range config.AllowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			allowedHosts[host] = true
+		}
+	}
+
+	return &CallbackNotifier{
+		config:       config,
+		allowedHosts: allowedHosts,
+		//redirects are not followed, they may lead to the hosts out of the allowed ones
+		httpClient: &http.Client{
+			Timeout: CALLBACK_POST_TIMEOUT,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+func NewCallbackRequestId() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+//CheckCallbackUrl rejects the callback_url when there is no secret to sign the deliveries or its host is not allowed
+func (notifier *CallbackNotifier) CheckCallbackUrl(callbackUrl string) error {
+	if notifier.config.Secret == "" {
+		return fmt.Errorf("callback_url is not accepted since callback_secret is not configured")
+	}
+
+	u, err := url.Parse(callbackUrl)
+	if err != nil {
+		return fmt.Errorf("callback_url(%s) is invalid, err=%v", callbackUrl, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("callback_url(%s) scheme should be http or https", callbackUrl)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("callback_url(%s) host is empty", callbackUrl)
+	}
+	if !notifier.allowedHosts[strings.ToLower(u.Host)] && !notifier.allowedHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("callback_url(%s) host is not in callback_allowed_hosts", callbackUrl)
+	}
+	return nil
+}
+
+//SignCallbackPayload returns hex(HMAC-SHA256(secret, timestamp + "." + payload))
+func SignCallbackPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+//Notify persists the delivery and posts it in background
+func (notifier *CallbackNotifier) Notify(requestId string, callbackUrl string, response *PluginResponse) error {
+	if err := notifier.CheckCallbackUrl(callbackUrl); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("marshal callback payload of request(%s) meet error=%v", requestId, err)
+	}
+
+	delivery := &callbackDelivery{
+		RequestId:  requestId,
+		Url:        callbackUrl,
+		Payload:    payload,
+		CreateTime: time.Now(),
+	}
+	if err = notifier.persist(delivery); err != nil {
+		return err
+	}
+
+	go notifier.deliver(delivery)
+	return nil
+}
+
+//Resume restarts the deliveries left in StoreDir by a previous process
+func (notifier *CallbackNotifier) Resume() error {
+	files, err := ioutil.ReadDir(notifier.config.StoreDir)
+	if err != nil {
+		return fmt.Errorf("read callback store dir(%s) meet error=%v", notifier.config.StoreDir, err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), CALLBACK_PENDING_FILE_SUFFIX) {
+			continue
+		}
+
+		fileName := filepath.Join(notifier.config.StoreDir, file.Name())
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			logrus.Errorf("read callback delivery file(%s) meet error=%v", fileName, err)
+			continue
+		}
+
+		delivery := &callbackDelivery{}
+		if err = json.Unmarshal(data, delivery); err != nil {
+			logrus.Errorf("unmarshal callback delivery file(%s) meet error=%v", fileName, err)
+			continue
+		}
+
+		//the settings may be changed since the delivery is accepted
+		if err = notifier.CheckCallbackUrl(delivery.Url); err != nil {
+			logrus.Errorf("give up callback delivery of request(%s) meet error=%v", delivery.RequestId, err)
+			notifier.bury(delivery)
+			continue
+		}
+
+		logrus.Infof("resume callback delivery of request(%s) to %s, attempts=%d", delivery.RequestId, delivery.Url, delivery.Attempts)
+		go notifier.deliver(delivery)
+	}
+	return nil
+}
+
+func (notifier *CallbackNotifier) deliver(delivery *callbackDelivery) {
+	for delivery.Attempts < notifier.config.MaxRetries {
+		if delivery.Attempts > 0 {
+			time.Sleep(notifier.retryInterval(delivery.Attempts))
+		}
+
+		err := notifier.post(delivery)
+		delivery.Attempts++
+		if err == nil {
+			logrus.Infof("callback of request(%s) has been delivered to %s, attempts=%d", delivery.RequestId, delivery.Url, delivery.Attempts)
+			notifier.remove(delivery, CALLBACK_PENDING_FILE_SUFFIX)
+			return
+		}
+
+		delivery.LastError = err.Error()
+		logrus.Errorf("deliver callback of request(%s) to %s meet error=%v, attempts=%d", delivery.RequestId, delivery.Url, err, delivery.Attempts)
+		if err = notifier.persist(delivery); err != nil {
+			logrus.Errorf("persist callback delivery of request(%s) meet error=%v", delivery.RequestId, err)
+		}
+	}
+
+	logrus.Errorf("give up callback of request(%s) to %s after %d attempts", delivery.RequestId, delivery.Url, delivery.Attempts)
+	notifier.bury(delivery)
+}
+
+//bury keeps the delivery on disk for manual inspection
+func (notifier *CallbackNotifier) bury(delivery *callbackDelivery) {
+	oldName := notifier.fileName(delivery, CALLBACK_PENDING_FILE_SUFFIX)
+	if err := os.Rename(oldName, notifier.fileName(delivery, CALLBACK_DEAD_FILE_SUFFIX)); err != nil {
+		logrus.Errorf("rename callback delivery file(%s) meet error=%v", oldName, err)
+	}
+}
+
+func (notifier *CallbackNotifier) retryInterval(attempts int) time.Duration {
+	interval := notifier.config.RetryInterval
+	for i := 1; i < attempts; i++ {
+		interval *= 2
+		if interval >= CALLBACK_MAX_RETRY_INTERVAL {
+			return CALLBACK_MAX_RETRY_INTERVAL
+		}
+	}
+	return interval
+}
+
+func (notifier *CallbackNotifier) post(delivery *callbackDelivery) error {
+	request, err := http.NewRequest(http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(CALLBACK_HEADER_REQUEST_ID, delivery.RequestId)
+	request.Header.Set(CALLBACK_HEADER_TIMESTAMP, timestamp)
+	request.Header.Set(CALLBACK_HEADER_SIGNATURE, SignCallbackPayload(notifier.config.Secret, timestamp, delivery.Payload))
+
+	response, err := notifier.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	ioutil.ReadAll(response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("callback server return status code %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (notifier *CallbackNotifier) fileName(delivery *callbackDelivery, suffix string) string {
+	return filepath.Join(notifier.config.StoreDir, delivery.RequestId+suffix)
+}
+
+func (notifier *CallbackNotifier) persist(delivery *callbackDelivery) error {
+	notifier.fileMutex.Lock()
+	defer notifier.fileMutex.Unlock()
+
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	fileName := notifier.fileName(delivery, CALLBACK_PENDING_FILE_SUFFIX)
+	tmpFileName := fileName + ".tmp"
+	if err = ioutil.WriteFile(tmpFileName, data, 0600); err != nil {
+		return fmt.Errorf("write callback delivery file(%s) meet error=%v", tmpFileName, err)
+	}
+	return os.Rename(tmpFileName, fileName)
+}
+
+func (notifier *CallbackNotifier) remove(delivery *callbackDelivery, suffix string) {
+	notifier.fileMutex.Lock()
+	defer notifier.fileMutex.Unlock()
+
+	fileName := notifier.fileName(delivery, suffix)
+	if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		logrus.Errorf("remove callback delivery file(%s) meet error=%v", fileName, err)
+	}
+}
@@ -0,0 +1,46 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUnitCheckCallbackUrl(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", "callbacks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storeDir)
+
+	config := CallbackConfig{
+		Secret:        "secret",
+		StoreDir:      storeDir,
+		MaxRetries:    1,
+		RetryInterval: time.Second,
+		AllowedHosts:  []string{" WeCube-Core ", "10.0.0.8:19090"},
+	}
+	notifier, err := NewCallbackNotifier(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, callbackUrl := range []string{"http://wecube-core/callback", "https://wecube-core:8443/callback", "http://10.0.0.8:19090/callback"} {
+		if err = notifier.CheckCallbackUrl(callbackUrl); err != nil {
+			t.Errorf("callback_url(%s) meet error=%v", callbackUrl, err)
+		}
+	}
+	for _, callbackUrl := range []string{"http://169.254.169.254/latest", "http://10.0.0.8:80/callback", "ftp://wecube-core/callback", "wecube-core"} {
+		if err = notifier.CheckCallbackUrl(callbackUrl); err == nil {
+			t.Errorf("callback_url(%s) should be rejected", callbackUrl)
+		}
+	}
+
+	config.Secret = ""
+	if notifier, err = NewCallbackNotifier(config); err != nil {
+		t.Fatal(err)
+	}
+	if err = notifier.CheckCallbackUrl("http://wecube-core/callback"); err == nil {
+		t.Errorf("callback_url without callback_secret should be rejected")
+	}
+}
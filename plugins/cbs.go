@@ -2,7 +2,6 @@ package plugins
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
@@ -11,6 +10,7 @@ import (
 	"golang.org/x/crypto/ssh"
 	"net"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -20,6 +20,7 @@ var cbsActions = make(map[string]Action)
 func init() {
 	cbsActions["create-mount"] = new(CreateAndMountCbsDiskAction)
 	cbsActions["umount-terminate"] = new(UmountAndTerminateDiskAction)
+	cbsActions["expand"] = new(ExpandCbsDiskAction)
 }
 
 type CbsPlugin struct {
@@ -42,21 +43,27 @@ type CreateAndMountCbsDiskInputs struct {
 
 type CreateAndMountCbsDiskInput struct {
 	Guid             string `json:"guid,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	DiskType         string `json:"disk_type,omitempty"`
-	DiskSize         uint64 `json:"disk_size,omitempty"`
+	ProviderParams   string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	DiskType         string `json:"disk_type,omitempty" validate:"enum=CLOUD_BASIC|CLOUD_PREMIUM|CLOUD_SSD"`
+	DiskSize         uint64 `json:"disk_size,omitempty" validate:"required"`
 	DiskName         string `json:"disk_name,omitempty"`
 	Id               string `json:"id,omitempty"`
-	DiskChargeType   string `json:"disk_charge_type,omitempty"`
-	DiskChargePeriod string `json:"disk_charge_period,omitempty"`
+	DiskChargeType   string `json:"disk_charge_type,omitempty" validate:"required,enum=PREPAID|POSTPAID_BY_HOUR"`
+	DiskChargePeriod string `json:"disk_charge_period,omitempty" validate:"range=1-36"`
 
 	//use to attch and format
-	InstanceId       string `json:"instance_id,omitempty"`
-	InstanceGuid     string `json:"instance_guid,omitempty"`
-	InstanceSeed     string `json:"seed,omitempty"`
+	InstanceId       string `json:"instance_id,omitempty" validate:"required"`
+	InstanceGuid     string `json:"instance_guid,omitempty" validate:"required"`
+	InstanceSeed     string `json:"seed,omitempty" validate:"required"`
 	InstancePassword string `json:"password,omitempty"`
-	FileSystemType   string `json:"file_system_type,omitempty"`
-	MountDir         string `json:"mount_dir,omitempty"`
+	FileSystemType   string `json:"file_system_type,omitempty" validate:"required,enum=ext3|ext4|xfs"`
+	MountDir         string `json:"mount_dir,omitempty" validate:"required"`
+	//private key of the key pair bound to the instance, encrypted like the password
+	InstancePrivateKey string `json:"private_key,omitempty"`
+
+	//more than 1 disk are combined into one logical volume by lvm, id can be the disk ids separated by comma
+	DiskCount    int64  `json:"disk_count,omitempty" validate:"range=0-20"`
+	VolumeLayout string `json:"volume_layout,omitempty" validate:"enum=single|lvm-linear|lvm-striped"`
 }
 
 type CreateAndMountCbsDiskOutputs struct {
@@ -66,7 +73,8 @@ type CreateAndMountCbsDiskOutputs struct {
 type CreateAndMountCbsDiskOutput struct {
 	Guid       string `json:"guid,omitempty"`
 	VolumeName string `json:"volume_name,omitempty"`
-	DiskId     string `json:"disk_id,omitempty"`
+	//disk ids separated by comma when there are more than 1 disk
+	DiskId string `json:"disk_id,omitempty"`
 }
 
 func (action *CreateAndMountCbsDiskAction) ReadParam(param interface{}) (interface{}, error) {
@@ -85,39 +93,24 @@ func (action *CreateAndMountCbsDiskAction) CheckParam(input interface{}) error {
 	}
 
 	for _, input := range inputs.Inputs {
-		if input.ProviderParams == "" {
-			return errors.New("providerParams is empty")
-		}
-		if input.DiskSize == 0 {
-			return errors.New("diskSize is empty")
-		}
-
-		if input.DiskChargeType == "" {
-			return errors.New("diskCharge param is empty")
+		if input.InstancePassword == "" && input.InstancePrivateKey == "" {
+			return fmt.Errorf("CreateAndMountCbsDiskAction:password or private_key should be set")
 		}
-
-		if input.InstanceId == "" || input.InstanceGuid == "" || input.InstanceSeed == "" {
-			return errors.New("instanceId、instanceGuid  or instanceSeed is empty")
-		}
-
-		if input.InstancePassword == "" {
-			return errors.New("instancePassword is empty")
-		}
-
-		if input.MountDir == "" {
-			return errors.New(" mountDir is empty")
-		}
-
-		if err := IsValidValue(input.FileSystemType, []string{"ext3", "ext4", "xfs"}); err != nil {
-			return fmt.Errorf("%s is not valid file system type", input.FileSystemType)
+		if err := checkVolumeLayout(getCbsDiskCount(input), input.VolumeLayout); err != nil {
+			return fmt.Errorf("CreateAndMountCbsDiskAction:%v", err)
 		}
 	}
-	return nil
+	return ValidateInputs(inputs, "")
 }
 
-func buyCbsAndAttachToVm(input CreateAndMountCbsDiskInput) (string, error) {
-	storageAction := StorageCreateAction{}
+func getCbsDiskCount(input CreateAndMountCbsDiskInput) int64 {
+	if input.DiskCount <= 0 {
+		return 1
+	}
+	return input.DiskCount
+}
 
+func newCbsStorageInput(input CreateAndMountCbsDiskInput, id string) StorageInput {
 	storageInput := StorageInput{
 		Guid:             input.Guid,
 		ProviderParams:   input.ProviderParams,
@@ -128,25 +121,22 @@ func buyCbsAndAttachToVm(input CreateAndMountCbsDiskInput) (string, error) {
 		DiskChargePeriod: input.DiskChargePeriod,
 		InstanceId:       input.InstanceId,
 	}
-	if input.Id != "" {
-		storageInput.Id = input.Id
+	if id != "" {
+		storageInput.Id = id
 	}
-	storageInputs := StorageInputs{}
-	storageInputs.Inputs = append(storageInputs.Inputs, storageInput)
+	return storageInput
+}
 
-	outputs, err := storageAction.Do(storageInputs)
-	if err != nil {
-		return "", err
-	}
-	storageOutputs := outputs.(*StorageOutputs)
+//buyCbsAndAttachToVm attaches the existing disk, a new disk is bought when existStorage is nil
+func buyCbsAndAttachToVm(storageInput StorageInput, existStorage *StorageOutput) (string, error) {
+	storageAction := StorageCreateAction{}
+
+	//the instance is locked by createAndMountCbsDisk
+	output, err := storageAction.attachOrCreateStorage(&storageInput, existStorage)
 	if err != nil {
 		return "", err
 	}
-
-	if len(storageOutputs.Outputs) != 1 {
-		return "", fmt.Errorf("storage outputs have %d entries", len(storageOutputs.Outputs))
-	}
-	return storageOutputs.Outputs[0].Id, nil
+	return output.Id, nil
 }
 
 func getInstancePrivateIp(providerParam string, instanceId string) (string, error) {
@@ -166,8 +156,37 @@ func getInstancePrivateIp(providerParam string, instanceId string) (string, erro
 	return *items[0].PrivateIpAddresses[0], nil
 }
 
-func createSshClient(ip string, password string) (*ssh.Client, error) {
-	auth := []ssh.AuthMethod{ssh.Password(password)}
+//decryptInstanceLoginSecret returns the private key if it's set, otherwise the password
+func decryptInstanceLoginSecret(guid, seed, password, privateKey string) (string, error) {
+	secret := password
+	if privateKey != "" {
+		secret = privateKey
+	}
+
+	md5sum := utils.Md5Encode(guid + seed)
+	return utils.AesDecode(md5sum[0:16], secret)
+}
+
+//getSshAuthMethod logins with the private key if passwordOrKey is a PEM encoded private key, otherwise with the password
+func getSshAuthMethod(passwordOrKey string) (ssh.AuthMethod, error) {
+	if !strings.Contains(passwordOrKey, "PRIVATE KEY-----") {
+		return ssh.Password(passwordOrKey), nil
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(passwordOrKey))
+	if err != nil {
+		logrus.Errorf("ssh ParsePrivateKey meet error=%v", err)
+		return nil, fmt.Errorf("private key is invalid")
+	}
+	return ssh.PublicKeys(signer), nil
+}
+
+func createSshClient(ip string, passwordOrKey string) (*ssh.Client, error) {
+	authMethod, err := getSshAuthMethod(passwordOrKey)
+	if err != nil {
+		return nil, err
+	}
+	auth := []ssh.AuthMethod{authMethod}
 	addr := fmt.Sprintf("%s:%d", ip, 22)
 	config := &ssh.ClientConfig{
 		User:            "root",
@@ -238,55 +257,21 @@ func runRemoteHostScript(ip string, password string, remoteFile string) (string,
 	session.Stdout = &stdout
 	session.Stderr = &stderr
 	if err := session.Run(remoteFile); err != nil {
-		logrus.Errorf("runRemoteHostScript stdout=%s,stderr=%s\n", stdout, stderr)
+		logrus.Errorf("runRemoteHostScript stdout=%s,stderr=%s\n", stdout.String(), stderr.String())
 		return "", err
 	}
 	return stdout.String(), nil
 }
 
-type UnformatedDisks struct {
-	Volumes []string `json:"unformatedDisks,omitempty"`
-}
-
-func getUnformatDisks(privateIp string, password string) ([]string, error) {
-	if err := copyFileToRemoteHost(privateIp, password, "./scripts/getUnformatedDisk.py", "/tmp/getUnformatedDisk.py"); err != nil {
-		return []string{}, err
-	}
-	output, err := runRemoteHostScript(privateIp, password, "python /tmp/getUnformatedDisk.py")
-	if err != nil {
-		return []string{}, err
-	}
-
-	unformatedDisks := UnformatedDisks{}
-	if err := json.Unmarshal([]byte(output), &unformatedDisks); err != nil {
-		return []string{}, err
-	}
-
-	return unformatedDisks.Volumes, nil
-}
-
-func formatAndMountDisk(ip, password, volumeName, fileSystemType, mountDir string) error {
-	if err := copyFileToRemoteHost(ip, password, "./scripts/formatAndMountDisk.py", "/tmp/formatAndMountDisk.py"); err != nil {
-		return err
-	}
-
-	execArgs := " -d " + volumeName + " -f " + fileSystemType + " -m " + mountDir
-	_, err := runRemoteHostScript(ip, password, "python /tmp/formatAndMountDisk.py"+execArgs)
-	return err
-}
-
-func getNewCreateDiskVolumeName(ip, password string, lastUnformatedDisks []string) (string, error) {
-	lastUnformatedDiskNum := len(lastUnformatedDisks)
-
+//getNewCreateDiskVolumeNames waits until count new unformated disks are found on the host
+func getNewCreateDiskVolumeNames(ip, password string, lastUnformatedDisks []string, count int) ([]string, error) {
 	for i := 0; i < 20; i++ {
 		newDisks, err := getUnformatDisks(ip, password)
 		if err != nil {
-			return "", err
-		}
-		if len(newDisks) == lastUnformatedDiskNum {
-			time.Sleep(5 * time.Second)
-			continue
+			return nil, err
 		}
+
+		volumeNames := []string{}
 		for _, volumeName := range newDisks {
 			bFind := false
 			for _, oldDisk := range lastUnformatedDisks {
@@ -296,12 +281,16 @@ func getNewCreateDiskVolumeName(ip, password string, lastUnformatedDisks []strin
 				}
 			}
 			if bFind == false {
-				return volumeName, nil
+				volumeNames = append(volumeNames, volumeName)
 			}
 		}
+		if len(volumeNames) >= count {
+			return volumeNames[:count], nil
+		}
+		time.Sleep(5 * time.Second)
 	}
 
-	return "", errors.New("getNewCreateDiskVolumeName timeout")
+	return nil, errors.New("getNewCreateDiskVolumeNames timeout")
 }
 
 func createAndMountCbsDisk(input CreateAndMountCbsDiskInput) (CreateAndMountCbsDiskOutput, error) {
@@ -310,13 +299,17 @@ func createAndMountCbsDisk(input CreateAndMountCbsDiskInput) (CreateAndMountCbsD
 		Guid: input.Guid,
 	}
 
+	//new disks are found by comparing the unformated disks before and after attaching,
+	//so disks can't be attached to the instance concurrently
+	unlock := LockResources("cbs/create-mount", input.InstanceId)
+	defer unlock()
+
 	privateIp, err := getInstancePrivateIp(input.ProviderParams, input.InstanceId)
 	if err != nil {
 		return output, err
 	}
 
-	md5sum := utils.Md5Encode(input.InstanceGuid + input.InstanceSeed)
-	password, err := utils.AesDecode(md5sum[0:16], input.InstancePassword)
+	password, err := decryptInstanceLoginSecret(input.InstanceGuid, input.InstanceSeed, input.InstancePassword, input.InstancePrivateKey)
 	if err != nil {
 		return output, err
 	}
@@ -327,16 +320,44 @@ func createAndMountCbsDisk(input CreateAndMountCbsDiskInput) (CreateAndMountCbsD
 		return output, err
 	}
 
-	//buy and attach disk to vm
-	output.DiskId, err = buyCbsAndAttachToVm(input)
+	//buy and attach disks to vm, the disks of ids are checked at once
+	ids := splitAndTrim(input.Id, ",")
+	storageInputs := []StorageInput{}
+	for i := 0; i < int(getCbsDiskCount(input)); i++ {
+		id := ""
+		if i < len(ids) {
+			id = ids[i]
+		}
+		storageInputs = append(storageInputs, newCbsStorageInput(input, id))
+	}
+	existStorages, err := queryStorageInfo(storageInputs)
 	if err != nil {
 		return output, err
 	}
 
-	output.VolumeName, err = getNewCreateDiskVolumeName(privateIp, password, oldUnformatDisks)
+	diskIds := []string{}
+	for i, storageInput := range storageInputs {
+		diskId, err := buyCbsAndAttachToVm(storageInput, existStorages[i])
+		if err != nil {
+			output.DiskId = strings.Join(diskIds, ",")
+			return output, err
+		}
+		diskIds = append(diskIds, diskId)
+	}
+	output.DiskId = strings.Join(diskIds, ",")
+
+	volumeNames, err := getNewCreateDiskVolumeNames(privateIp, password, oldUnformatDisks, len(diskIds))
 	if err != nil {
 		return output, err
 	}
+	output.VolumeName = volumeNames[0]
+
+	if isLvmVolumeLayout(input.VolumeLayout) {
+		output.VolumeName, err = createLvmVolume(privateIp, password, volumeNames, input.VolumeLayout, input.MountDir, diskIds[0])
+		if err != nil {
+			return output, err
+		}
+	}
 
 	//format and mount
 	err = formatAndMountDisk(privateIp, password, output.VolumeName, input.FileSystemType, input.MountDir)
@@ -353,6 +374,10 @@ func (action *CreateAndMountCbsDiskAction) Do(input interface{}) (interface{}, e
 	for _, input := range inputs.Inputs {
 		output, err := createAndMountCbsDisk(input)
 		if err != nil {
+			//the disks may have been bought and attached, the caller needs their ids to retry or terminate them
+			if output.DiskId != "" {
+				outputs.Outputs = append(outputs.Outputs, output)
+			}
 			return outputs, err
 		}
 		outputs.Outputs = append(outputs.Outputs, output)
@@ -370,16 +395,22 @@ type UmountCbsDiskInputs struct {
 
 type UmountCbsDiskInput struct {
 	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	VolumeName     string `json:"volume_name,omitempty"`
-	MountDir       string `json:"mount_dir,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Id             string `json:"id,omitempty" validate:"required"`
+	VolumeName     string `json:"volume_name,omitempty" validate:"required"`
+	MountDir       string `json:"mount_dir,omitempty" validate:"required"`
 
 	//use to attch and format
-	InstanceId       string `json:"instance_id,omitempty"`
-	InstanceGuid     string `json:"instance_guid,omitempty"`
-	InstanceSeed     string `json:"seed,omitempty"`
+	InstanceId       string `json:"instance_id,omitempty" validate:"required"`
+	InstanceGuid     string `json:"instance_guid,omitempty" validate:"required"`
+	InstanceSeed     string `json:"seed,omitempty" validate:"required"`
 	InstancePassword string `json:"password,omitempty"`
+	//private key of the key pair bound to the instance, encrypted like the password
+	InstancePrivateKey string `json:"private_key,omitempty"`
+
+	//take a snapshot of the disk after it's umounted and before it's terminated
+	SnapshotBeforeTerminate bool   `json:"snapshot_before_terminate,omitempty"`
+	SnapshotName            string `json:"snapshot_name,omitempty"`
 }
 
 type UmountCbsDiskOutputs struct {
@@ -388,6 +419,8 @@ type UmountCbsDiskOutputs struct {
 
 type UmountCbsDiskOutput struct {
 	Guid string `json:"guid,omitempty"`
+	//snapshot ids separated by comma in the order of the disk ids
+	SnapshotId string `json:"snapshot_id,omitempty"`
 }
 
 func (action *UmountAndTerminateDiskAction) ReadParam(param interface{}) (interface{}, error) {
@@ -406,36 +439,11 @@ func (action *UmountAndTerminateDiskAction) CheckParam(input interface{}) error
 	}
 
 	for _, input := range inputs.Inputs {
-		if input.ProviderParams == "" {
-			return errors.New("providerParams is empty")
-		}
-
-		if input.Id == "" {
-			return errors.New("id is empty")
-		}
-
-		if input.InstanceId == "" || input.InstanceGuid == "" || input.InstanceSeed == "" {
-			return errors.New("instanceId、instanceGuid  or instanceSeed is empty")
-		}
-
-		if input.InstancePassword == "" {
-			return errors.New("instancePassword is empty")
-		}
-
-		if input.MountDir == "" || input.VolumeName == "" {
-			return errors.New("mountDir or volume name is empty")
+		if input.InstancePassword == "" && input.InstancePrivateKey == "" {
+			return fmt.Errorf("UmountAndTerminateDiskAction:password or private_key should be set")
 		}
 	}
-	return nil
-}
-func umountDisk(ip, password, volumeName, mountDir string) error {
-	if err := copyFileToRemoteHost(ip, password, "./scripts/umountDisk.py", "/tmp/umountDisk.py"); err != nil {
-		return err
-	}
-
-	execArgs := " -d " + volumeName + " -m " + mountDir
-	_, err := runRemoteHostScript(ip, password, "python /tmp/umountDisk.py"+execArgs)
-	return err
+	return ValidateInputs(inputs, "")
 }
 
 func terminateDisk(providerParams, id string) error {
@@ -451,23 +459,58 @@ func terminateDisk(providerParams, id string) error {
 	return err
 }
 
-func umountAndTerminateCbsDisk(input UmountCbsDiskInput) error {
+//umountAndTerminateCbsDisk returns the ids of the snapshots taken before terminating,
+//the logical volume is removed after the snapshots are taken when the disks are combined by lvm
+func umountAndTerminateCbsDisk(input UmountCbsDiskInput) (string, error) {
+	unlock := LockResources("cbs/umount-terminate", input.InstanceId)
+	defer unlock()
+
 	privateIp, err := getInstancePrivateIp(input.ProviderParams, input.InstanceId)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	md5sum := utils.Md5Encode(input.InstanceGuid + input.InstanceSeed)
-	password, err := utils.AesDecode(md5sum[0:16], input.InstancePassword)
+	password, err := decryptInstanceLoginSecret(input.InstanceGuid, input.InstanceSeed, input.InstancePassword, input.InstancePrivateKey)
 	if err != nil {
-		return err
+		return "", err
 	}
 
+	//the volume may be gone already, it's treated as a disk then
+	device, err := getRemoteBlockDevice(privateIp, password, input.VolumeName)
+	isLvmVolume := err == nil && device.Type == DISK_TYPE_LVM
+
 	if err = umountDisk(privateIp, password, input.VolumeName, input.MountDir); err != nil {
-		return err
+		return "", err
 	}
 
-	return terminateDisk(input.ProviderParams, input.Id)
+	diskIds := splitAndTrim(input.Id, ",")
+	snapshotIds := []string{}
+	if input.SnapshotBeforeTerminate {
+		client, err := createSnapshotClient(input.ProviderParams)
+		if err != nil {
+			return "", err
+		}
+		for _, diskId := range diskIds {
+			snapshotId, _, err := createSnapshotAndWait(client, diskId, input.SnapshotName)
+			if err != nil {
+				return strings.Join(snapshotIds, ","), err
+			}
+			snapshotIds = append(snapshotIds, snapshotId)
+		}
+	}
+
+	if isLvmVolume {
+		if err = removeLvmVolume(privateIp, password, input.VolumeName); err != nil {
+			return strings.Join(snapshotIds, ","), err
+		}
+	}
+
+	for _, diskId := range diskIds {
+		if err = terminateDisk(input.ProviderParams, diskId); err != nil {
+			return strings.Join(snapshotIds, ","), err
+		}
+	}
+	return strings.Join(snapshotIds, ","), nil
 }
 
 func (action *UmountAndTerminateDiskAction) Do(input interface{}) (interface{}, error) {
@@ -475,12 +518,13 @@ func (action *UmountAndTerminateDiskAction) Do(input interface{}) (interface{},
 	outputs := UmountCbsDiskOutputs{}
 
 	for _, input := range inputs.Inputs {
-		err := umountAndTerminateCbsDisk(input)
+		snapshotId, err := umountAndTerminateCbsDisk(input)
 		if err != nil {
 			return outputs, err
 		}
 		output := UmountCbsDiskOutput{
-			Guid: input.Guid,
+			Guid:       input.Guid,
+			SnapshotId: snapshotId,
 		}
 		outputs.Outputs = append(outputs.Outputs, output)
 	}
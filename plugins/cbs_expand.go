@@ -0,0 +1,266 @@
+package plugins
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
+)
+
+const (
+	BYTES_PER_GB = 1024 * 1024 * 1024
+)
+
+//--------------expand cbs disk--------------------//
+type ExpandCbsDiskAction struct {
+}
+
+type ExpandCbsDiskInputs struct {
+	Inputs []ExpandCbsDiskInput `json:"inputs,omitempty"`
+}
+
+type ExpandCbsDiskInput struct {
+	Guid           string `json:"guid,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Id             string `json:"id,omitempty" validate:"required"`
+	DiskSize       uint64 `json:"disk_size,omitempty" validate:"required"`
+	//the disk or the logical volume made by create-mount with volume_layout lvm-linear or lvm-striped
+	VolumeName string `json:"volume_name,omitempty" validate:"required"`
+	MountDir   string `json:"mount_dir,omitempty" validate:"required"`
+	//detected on the instance when it's empty
+	FileSystemType string `json:"file_system_type,omitempty" validate:"enum=ext3|ext4|xfs"`
+
+	//use to grow the file system
+	InstanceId         string `json:"instance_id,omitempty" validate:"required"`
+	InstanceGuid       string `json:"instance_guid,omitempty" validate:"required"`
+	InstanceSeed       string `json:"seed,omitempty" validate:"required"`
+	InstancePassword   string `json:"password,omitempty"`
+	InstancePrivateKey string `json:"private_key,omitempty"`
+}
+
+type ExpandCbsDiskOutputs struct {
+	Outputs []ExpandCbsDiskOutput `json:"outputs,omitempty"`
+}
+
+type ExpandCbsDiskOutput struct {
+	Guid           string `json:"guid,omitempty"`
+	DiskSize       uint64 `json:"disk_size,omitempty"`
+	FileSystemType string `json:"file_system_type,omitempty"`
+	//size of the file system seen by the os in GB
+	OsDiskSize string `json:"os_disk_size,omitempty"`
+}
+
+func (action *ExpandCbsDiskAction) ReadParam(param interface{}) (interface{}, error) {
+	var inputs ExpandCbsDiskInputs
+	err := UnmarshalJson(param, &inputs)
+	if err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+func (action *ExpandCbsDiskAction) CheckParam(input interface{}) error {
+	inputs, ok := input.(ExpandCbsDiskInputs)
+	if !ok {
+		return fmt.Errorf("ExpandCbsDiskAction:input type=%T not right", input)
+	}
+
+	for _, input := range inputs.Inputs {
+		if input.InstancePassword == "" && input.InstancePrivateKey == "" {
+			return fmt.Errorf("ExpandCbsDiskAction:password or private_key should be set")
+		}
+	}
+	return ValidateInputs(inputs, "")
+}
+
+func (action *ExpandCbsDiskAction) Do(input interface{}) (interface{}, error) {
+	inputs, _ := input.(ExpandCbsDiskInputs)
+	outputs := ExpandCbsDiskOutputs{}
+
+	for _, input := range inputs.Inputs {
+		output, err := expandCbsDisk(input)
+		if err != nil {
+			return outputs, err
+		}
+		outputs.Outputs = append(outputs.Outputs, output)
+	}
+	return outputs, nil
+}
+
+//resizeCbsDisk expands the disk to diskSize, it does nothing when the disk is already in that size
+func resizeCbsDisk(client *cbs.Client, disk *cbs.Disk, diskSize uint64) error {
+	if *disk.DiskSize == diskSize {
+		logrus.Infof("storage(id = %v) is already in size %d", *disk.DiskId, diskSize)
+		return nil
+	}
+	if *disk.DiskSize > diskSize {
+		return fmt.Errorf("storage(id = %v) can't be shrunk from %d to %d", *disk.DiskId, *disk.DiskSize, diskSize)
+	}
+
+	request := cbs.NewResizeDiskRequest()
+	request.DiskId = disk.DiskId
+	request.DiskSize = &diskSize
+	response, err := client.ResizeDisk(request)
+	if err != nil {
+		logrus.Errorf("cbs ResizeDisk(diskId=%s) meet error=%v", *disk.DiskId, err)
+		return err
+	}
+	logrus.Infof("resize storage(id = %v) to %d has been submitted, RequestID is [%v]", *disk.DiskId, diskSize, *response.Response.RequestId)
+
+	return waitCbsDiskExpanded(client, *disk.DiskId, diskSize, DISK_WAIT_TIMEOUT)
+}
+
+//waitCbsDiskExpanded waits until the disk is attached again in the new size,
+//the disk may still be in the old size and attached just after ResizeDisk returns
+func waitCbsDiskExpanded(client *cbs.Client, diskId string, diskSize uint64, timeout int) error {
+	count := 0
+	for {
+		disk, err := getCbsDisk(client, diskId)
+		if err != nil {
+			return err
+		}
+		if *disk.DiskState == DISK_STATE_ATTACHED && *disk.DiskSize >= diskSize {
+			return nil
+		}
+
+		count++
+		if count*5 > timeout {
+			logrus.Errorf("wait storage(id = %v) expanded to %d timeout, current state is %s, size is %d", diskId, diskSize, *disk.DiskState, *disk.DiskSize)
+			return fmt.Errorf("wait storage(id = %v) expanded to %d timeout", diskId, diskSize)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+//growDiskFileSystem returns the file system type and the size of the file system after growing it,
+//the physical volumes and the logical volume are grown first when the volume is a logical volume
+func growDiskFileSystem(ip, password, volumeName, fileSystemType, mountDir string) (string, uint64, error) {
+	device, err := getRemoteBlockDevice(ip, password, volumeName)
+	if err != nil {
+		return "", 0, err
+	}
+	if fileSystemType == "" {
+		fileSystemType = device.FsType
+	}
+
+	dir := shellQuote(mountDir)
+	growCommands := map[string]string{
+		"ext3": "resize2fs " + shellQuote(volumeName),
+		"ext4": "resize2fs " + shellQuote(volumeName),
+		"xfs":  "xfs_growfs " + dir,
+	}
+	growCommand, ok := growCommands[fileSystemType]
+	if !ok {
+		return "", 0, fmt.Errorf("file system type(%s) of %s is not supported", fileSystemType, volumeName)
+	}
+
+	//virtio disks may keep the old size until the device is rescanned
+	prepareCommand := buildRescanCommand(volumeName)
+	if device.Type == DISK_TYPE_LVM {
+		volumeGroup, physicalVolumes, err := getLvmPhysicalVolumes(ip, password, volumeName)
+		if err != nil {
+			return "", 0, err
+		}
+		if len(physicalVolumes) == 0 {
+			return "", 0, fmt.Errorf("physical volumes of %s not found", volumeName)
+		}
+
+		rescanCommands := []string{}
+		for _, physicalVolume := range physicalVolumes {
+			rescanCommands = append(rescanCommands, buildRescanCommand(physicalVolume))
+		}
+		prepareCommand = strings.Join(rescanCommands, " && ") + " && " + buildLvmGrowCommand(physicalVolumes, volumeGroup, volumeName)
+	}
+
+	cmd := "mountpoint -q " + dir +
+		" && " + prepareCommand +
+		" && " + growCommand + " >/dev/null" +
+		" && stat -f -c '%b %S' " + dir
+	output, err := runRemoteHostScript(ip, password, cmd)
+	if err != nil {
+		logrus.Errorf("grow file system of %s on %s meet error=%v", volumeName, ip, err)
+		return "", 0, err
+	}
+
+	size, err := parseFileSystemSize(output)
+	return fileSystemType, size, err
+}
+
+func buildRescanCommand(volumeName string) string {
+	rescanFile := shellQuote("/sys/class/block/" + path.Base(volumeName) + "/device/rescan")
+	return "{ if [ -e " + rescanFile + " ]; then echo 1 > " + rescanFile + "; fi; }"
+}
+
+//parseFileSystemSize reads the output of stat -f -c '%b %S', which are the total blocks and the block size
+func parseFileSystemSize(output string) (uint64, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("invalid file system stat output(%s)", output)
+	}
+	blocks, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file system blocks(%s)", fields[0])
+	}
+	blockSize, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file system block size(%s)", fields[1])
+	}
+	return blocks * blockSize, nil
+}
+
+func formatSizeInGB(size uint64) string {
+	gb := fmt.Sprintf("%.2f", float64(size)/BYTES_PER_GB)
+	return strings.TrimSuffix(strings.TrimRight(gb, "0"), ".")
+}
+
+func expandCbsDisk(input ExpandCbsDiskInput) (ExpandCbsDiskOutput, error) {
+	output := ExpandCbsDiskOutput{
+		Guid: input.Guid,
+	}
+
+	unlock := LockResources("cbs/expand", input.InstanceId)
+	defer unlock()
+
+	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
+	if err != nil {
+		return output, err
+	}
+	client, err := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return output, err
+	}
+	disk, err := getCbsDisk(client, input.Id)
+	if err != nil {
+		return output, err
+	}
+	if disk.InstanceId == nil || *disk.InstanceId != input.InstanceId {
+		return output, fmt.Errorf("storage(id = %v) is not attached to instance(%s)", input.Id, input.InstanceId)
+	}
+
+	if err = resizeCbsDisk(client, disk, input.DiskSize); err != nil {
+		return output, err
+	}
+	output.DiskSize = input.DiskSize
+
+	privateIp, err := getInstancePrivateIp(input.ProviderParams, input.InstanceId)
+	if err != nil {
+		return output, err
+	}
+	password, err := decryptInstanceLoginSecret(input.InstanceGuid, input.InstanceSeed, input.InstancePassword, input.InstancePrivateKey)
+	if err != nil {
+		return output, err
+	}
+
+	fileSystemType, size, err := growDiskFileSystem(privateIp, password, input.VolumeName, input.FileSystemType, input.MountDir)
+	if err != nil {
+		return output, err
+	}
+	output.FileSystemType = fileSystemType
+	output.OsDiskSize = formatSizeInGB(size)
+
+	return output, nil
+}
@@ -0,0 +1,35 @@
+package plugins
+
+import (
+	"testing"
+)
+
+func TestUnitParseFileSystemSize(t *testing.T) {
+	size, err := parseFileSystemSize("25770320 4096\n")
+	if err != nil {
+		t.Fatalf("parseFileSystemSize meet error=%v", err)
+	}
+	if size != 25770320*4096 {
+		t.Errorf("unexpected size %d", size)
+	}
+
+	for _, output := range []string{"", "25770320", "blocks 4096", "25770320 size"} {
+		if _, err := parseFileSystemSize(output); err == nil {
+			t.Errorf("output(%s) should be an error", output)
+		}
+	}
+}
+
+func TestUnitFormatSizeInGB(t *testing.T) {
+	cases := map[uint64]string{
+		0:                  "0",
+		100 * BYTES_PER_GB: "100",
+		BYTES_PER_GB / 2:   "0.5",
+		105555197952:       "98.31",
+	}
+	for size, expected := range cases {
+		if got := formatSizeInGB(size); got != expected {
+			t.Errorf("formatSizeInGB(%d)=%s, expected %s", size, got, expected)
+		}
+	}
+}
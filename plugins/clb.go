@@ -28,12 +28,13 @@ func init() {
 }
 
 func createClbClient(region, secretId, secretKey string) (client *clb.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "clb.tencentcloudapi.com"
-
-	return clb.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("clb", "clb.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return clb.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*clb.Client), nil
 }
 
 type ClbPlugin struct {
@@ -57,10 +58,10 @@ type CreateClbInputs struct {
 
 type CreateClbInput struct {
 	Guid           string `json:"guid"`
-	ProviderParams string `json:"provider_params"`
+	ProviderParams string `json:"provider_params" validate:"required,provider_params"`
 	Name           string `json:"name"`
-	Type           string `json:"type"`
-	VpcId          string `json:"vpc_id"`
+	Type           string `json:"type" validate:"required,enum=external_lb|internal_lb"`
+	VpcId          string `json:"vpc_id" validate:"required"`
 	SubnetId       string `json:"subnet_id"`
 	Id             string `json:"id"`
 }
@@ -90,20 +91,11 @@ func (action *CreateClbAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("CreateClbAction:input type=%T not right", input)
 	}
 
-	for _, input := range inputs.Inputs {
-		if input.ProviderParams == "" {
-			return errors.New("ProviderParams is empty")
-		}
-		if input.Type == "" {
-			return errors.New("Type is empty")
-		}
-		if input.VpcId == "" {
-			return errors.New("VpcId is empty")
-		}
+	if err := ValidateInputs(inputs, ""); err != nil {
+		return err
+	}
 
-		if input.Type != LB_TYPE_EXTERNAL && input.Type != LB_TYPE_INTERNAL {
-			return fmt.Errorf("invalid lbType(%v)", input.Type)
-		}
+	for _, input := range inputs.Inputs {
 		if input.Type == LB_TYPE_INTERNAL && input.SubnetId == "" {
 			return errors.New("SubnetId is empty")
 		}
@@ -251,8 +243,8 @@ type TerminateClbInputs struct {
 
 type TerminateClbInput struct {
 	Guid           string `json:"guid"`
-	ProviderParams string `json:"provider_params"`
-	Id             string `json:"id"`
+	ProviderParams string `json:"provider_params" validate:"required,provider_params"`
+	Id             string `json:"id" validate:"required"`
 }
 
 type TerminateClbOutputs struct {
@@ -278,12 +270,7 @@ func (action *TerminateClbAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("TerminateClbAction:input type=%T not right", input)
 	}
 
-	for _, input := range inputs.Inputs {
-		if input.Id == "" {
-			return errors.New("empty input id")
-		}
-	}
-	return nil
+	return ValidateInputs(inputs, "")
 }
 
 func terminateClb(client *clb.Client, input TerminateClbInput) error {
@@ -329,12 +316,12 @@ type BackTargetInputs struct {
 
 type BackTargetInput struct {
 	Guid           string `json:"guid"`
-	ProviderParams string `json:"provider_params"`
-	LbId           string `json:"lb_id"`
-	Port           string `json:"lb_port"`
-	Protocol       string `json:"protocol"`
-	HostId         string `json:"host_id"`
-	HostPort       string `json:"host_port"`
+	ProviderParams string `json:"provider_params" validate:"required,provider_params"`
+	LbId           string `json:"lb_id" validate:"required"`
+	Port           string `json:"lb_port" validate:"required,range=1-65534"`
+	Protocol       string `json:"protocol" validate:"required,enum=TCP|UDP,ignorecase"`
+	HostId         string `json:"host_id" validate:"required"`
+	HostPort       string `json:"host_port" validate:"required,range=1-65534"`
 }
 
 type BackTargetOutputs struct {
@@ -354,51 +341,17 @@ func (action *AddBackTargetAction) ReadParam(param interface{}) (interface{}, er
 	return inputs, nil
 }
 
-func isValidPort(port string) error {
-	if port == "" {
-		return errors.New("port is empty")
-	}
-
-	portInt, err := strconv.Atoi(port)
-	if err != nil || portInt >= 65535 || portInt <= 0 {
-		return fmt.Errorf("port(%s) is invalid", port)
-	}
-	return nil
-}
-
-func isValidProtocol(protocol string) error {
-	if protocol == "" {
-		return errors.New("protocol is empty")
-	}
-
-	if !strings.EqualFold(protocol, "TCP") && !strings.EqualFold(protocol, "UDP") {
-		return fmt.Errorf("protocol(%s) is invalid", protocol)
-	}
-	return nil
-}
-
 func (action *AddBackTargetAction) CheckParam(input interface{}) error {
 	inputs, ok := input.(BackTargetInputs)
 	if !ok {
 		return fmt.Errorf("input type=%T not right", input)
 	}
 
+	if err := ValidateInputs(inputs, ""); err != nil {
+		return err
+	}
+
 	for _, input := range inputs.Inputs {
-		if input.LbId == "" {
-			return errors.New("empty lb id")
-		}
-		if input.HostId == "" {
-			return errors.New("empty host id")
-		}
-		if err := isValidPort(input.Port); err != nil {
-			return fmt.Errorf("port(%v) is invalid", input.Port)
-		}
-		if err := isValidPort(input.HostPort); err != nil {
-			return fmt.Errorf("hostPort(%v) is invalid", input.HostPort)
-		}
-		if err := isValidProtocol(input.Protocol); err != nil {
-			return fmt.Errorf("protocol(%v) is invalid", input.Protocol)
-		}
 		//check if lb exist
 		paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
 		client, _ := createClbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
@@ -0,0 +1,149 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+)
+
+const CLIENT_CACHE_IDLE_TIMEOUT = 30 * time.Minute
+
+//cache metrics are published by expvar with the lock metrics
+var clientCacheMetrics = expvar.NewMap("sdk_client_cache")
+
+//all sdk clients share the transport so connections to the same endpoint are reused
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	MaxIdleConns:          200,
+	MaxIdleConnsPerHost:   32,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: 1 * time.Second,
+}
+
+type NewSdkClientFunc func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error)
+
+type clientCacheKey struct {
+	secretId string
+	region   string
+	service  string
+	endpoint string
+}
+
+type cachedClient struct {
+	client        interface{}
+	secretKeyHash string
+	lastUsedTime  time.Time
+}
+
+//ClientCache keeps sdk clients by (credential, region, service, endpoint).
+//when a SecretID comes with another SecretKey the credential is rotated and all its clients are dropped
+type ClientCache struct {
+	mutex         sync.Mutex
+	clients       map[clientCacheKey]*cachedClient
+	idleTimeout   time.Duration
+	lastSweepTime time.Time
+}
+
+var sdkClientCache = NewClientCache(CLIENT_CACHE_IDLE_TIMEOUT)
+
+func NewClientCache(idleTimeout time.Duration) *ClientCache {
+	return &ClientCache{
+		clients:       make(map[clientCacheKey]*cachedClient),
+		idleTimeout:   idleTimeout,
+		lastSweepTime: time.Now(),
+	}
+}
+
+//GetCachedClient returns the client of the default cache, newClient is called on cache miss
+func GetCachedClient(service, endpoint, region, secretId, secretKey string, newClient NewSdkClientFunc) (interface{}, error) {
+	return sdkClientCache.Get(service, endpoint, region, secretId, secretKey, newClient)
+}
+
+//InvalidateClients drops the cached clients of the SecretID
+func InvalidateClients(secretId string) {
+	sdkClientCache.Invalidate(secretId)
+}
+
+func (cache *ClientCache) Get(service, endpoint, region, secretId, secretKey string, newClient NewSdkClientFunc) (interface{}, error) {
+	key := clientCacheKey{secretId: secretId, region: region, service: service, endpoint: endpoint}
+	secretKeyHash := hashSecretKey(secretKey)
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.sweepLocked()
+	if cached, found := cache.clients[key]; found {
+		if cached.secretKeyHash == secretKeyHash {
+			cached.lastUsedTime = time.Now()
+			clientCacheMetrics.Add("hits", 1)
+			return cached.client, nil
+		}
+		logrus.Infof("credential of SecretID(%s) is rotated, drop its cached clients", secretId)
+		cache.invalidateLocked(secretId)
+	}
+	clientCacheMetrics.Add("misses", 1)
+
+	clientProfile := profile.NewClientProfile()
+	clientProfile.HttpProfile.Endpoint = endpoint
+	client, err := newClient(common.NewCredential(secretId, secretKey), region, clientProfile)
+	if err != nil {
+		return nil, err
+	}
+	if withTransport, ok := client.(interface {
+		WithHttpTransport(transport http.RoundTripper) *common.Client
+	}); ok {
+		withTransport.WithHttpTransport(sharedTransport)
+	}
+
+	cache.clients[key] = &cachedClient{
+		client:        client,
+		secretKeyHash: secretKeyHash,
+		lastUsedTime:  time.Now(),
+	}
+	return client, nil
+}
+
+func (cache *ClientCache) Invalidate(secretId string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.invalidateLocked(secretId)
+}
+
+func (cache *ClientCache) invalidateLocked(secretId string) {
+	for key := range cache.clients {
+		if key.secretId == secretId {
+			delete(cache.clients, key)
+			clientCacheMetrics.Add("invalidations", 1)
+		}
+	}
+}
+
+func (cache *ClientCache) sweepLocked() {
+	if time.Since(cache.lastSweepTime) < cache.idleTimeout {
+		return
+	}
+	cache.lastSweepTime = time.Now()
+	for key, cached := range cache.clients {
+		if time.Since(cached.lastUsedTime) >= cache.idleTimeout {
+			delete(cache.clients, key)
+		}
+	}
+}
+
+func hashSecretKey(secretKey string) string {
+	sum := sha256.Sum256([]byte(secretKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+)
+
+type fakeSdkClient struct {
+	secretKey string
+	endpoint  string
+}
+
+func newFakeSdkClientFunc(created *int) NewSdkClientFunc {
+	return func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		*created++
+		return &fakeSdkClient{secretKey: credential.SecretKey, endpoint: clientProfile.HttpProfile.Endpoint}, nil
+	}
+}
+
+func TestUnitClientCacheReusesClient(t *testing.T) {
+	cache := NewClientCache(time.Hour)
+	created := 0
+	newClient := newFakeSdkClientFunc(&created)
+
+	first, _ := cache.Get("cvm", "cvm.tencentcloudapi.com", "ap-guangzhou", "id", "key", newClient)
+	second, _ := cache.Get("cvm", "cvm.tencentcloudapi.com", "ap-guangzhou", "id", "key", newClient)
+	if first != second || created != 1 {
+		t.Errorf("client should be reused, created %d clients", created)
+	}
+	if first.(*fakeSdkClient).endpoint != "cvm.tencentcloudapi.com" {
+		t.Errorf("endpoint is not set, got %s", first.(*fakeSdkClient).endpoint)
+	}
+
+	cache.Get("cvm", "cvm.tencentcloudapi.com", "ap-shanghai", "id", "key", newClient)
+	cache.Get("vpc", "vpc.tencentcloudapi.com", "ap-guangzhou", "id", "key", newClient)
+	if created != 3 {
+		t.Errorf("region and service should have their own clients, created %d clients", created)
+	}
+}
+
+func TestUnitClientCacheInvalidatesRotatedCredential(t *testing.T) {
+	cache := NewClientCache(time.Hour)
+	created := 0
+	newClient := newFakeSdkClientFunc(&created)
+
+	cache.Get("cvm", "cvm.tencentcloudapi.com", "ap-guangzhou", "id", "old", newClient)
+	cache.Get("vpc", "vpc.tencentcloudapi.com", "ap-guangzhou", "id", "old", newClient)
+	cache.Get("vpc", "vpc.tencentcloudapi.com", "ap-guangzhou", "other", "key", newClient)
+
+	client, _ := cache.Get("cvm", "cvm.tencentcloudapi.com", "ap-guangzhou", "id", "new", newClient)
+	if client.(*fakeSdkClient).secretKey != "new" {
+		t.Errorf("client of the rotated credential should be recreated")
+	}
+	if len(cache.clients) != 2 {
+		t.Errorf("clients of the old credential should be dropped, %d clients left", len(cache.clients))
+	}
+
+	cache.Invalidate("other")
+	if len(cache.clients) != 1 {
+		t.Errorf("clients of invalidated SecretID should be dropped, %d clients left", len(cache.clients))
+	}
+}
+
+func TestUnitClientCacheSweepsIdleClients(t *testing.T) {
+	cache := NewClientCache(time.Millisecond)
+	created := 0
+	newClient := newFakeSdkClientFunc(&created)
+
+	cache.Get("cvm", "cvm.tencentcloudapi.com", "ap-guangzhou", "id", "key", newClient)
+	time.Sleep(5 * time.Millisecond)
+	cache.Get("cvm", "cvm.tencentcloudapi.com", "ap-guangzhou", "id", "key", newClient)
+	if created != 2 {
+		t.Errorf("idle client should be swept, created %d clients", created)
+	}
+}
@@ -1,7 +1,6 @@
 package plugins
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -88,7 +87,11 @@ func UnmarshalJson(source interface{}, target interface{}) error {
 		return fmt.Errorf("parse http request (%v) meet error (%v)", reader, err)
 	}
 
-	if err = json.Unmarshal(bodyBytes, target); err != nil {
+	_, strict := source.(*strictJsonReader)
+	if err = decodeJson(bodyBytes, target, strict); err != nil {
+		if _, ok := err.(ValidationErrors); ok {
+			return err
+		}
 		return fmt.Errorf("unmarshal http request (%v) meet error (%v)", reader, err)
 	}
 	return nil
@@ -105,3 +108,32 @@ func ExtractJsonFromStruct(s interface{}) map[string]string {
 	}
 	return fields
 }
+
+//splitAndTrim splits value by separator and drops the empty items
+func splitAndTrim(value string, separator string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+//stringValue returns the empty string for the nil pointers in sdk responses
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func stringValues(values []*string) []string {
+	result := []string{}
+	for _, value := range values {
+		if value != nil {
+			result = append(result, *value)
+		}
+	}
+	return result
+}
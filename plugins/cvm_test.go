@@ -1,3 +1,5 @@
+//go:build integration
+
 package plugins
 
 import (
@@ -7,7 +9,6 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
 )
 
 const ENV_SECRET_ID = "SECRET_ID"
@@ -23,10 +24,10 @@ func TestQueryCvmInstance1(t *testing.T) {
 	}
 	response, err := QueryCvmInstance(providerParams, filter)
 	if err != nil {
-		logrus.Errorf("TestQueryCvmInstance1 cvm DescribeInstances meet err=%v", err)
+		t.Fatalf("TestQueryCvmInstance1 cvm DescribeInstances meet err=%v", err)
 	}
-	fmt.Printf("TestQueryCvmInstance1 cvm DescribeInstances InstanceSet[0].InstanceId[%v]\n", *response.(*cvm.DescribeInstancesResponse).Response.InstanceSet[0].InstanceId)
-	fmt.Printf("TestQueryCvmInstance1 cvm DescribeInstances InstanceSet[0].PrivateIpAddresses[%v]\n", common.StringValues(response.(*cvm.DescribeInstancesResponse).Response.InstanceSet[0].PrivateIpAddresses))
+	fmt.Printf("TestQueryCvmInstance1 cvm DescribeInstances InstanceSet[0].InstanceId[%v]\n", *response[0].InstanceId)
+	fmt.Printf("TestQueryCvmInstance1 cvm DescribeInstances InstanceSet[0].PrivateIpAddresses[%v]\n", common.StringValues(response[0].PrivateIpAddresses))
 }
 
 func TestQueryCvmInstance2(t *testing.T) {
@@ -39,10 +40,10 @@ func TestQueryCvmInstance2(t *testing.T) {
 	}
 	response, err := QueryCvmInstance(providerParams, filter)
 	if err != nil {
-		logrus.Errorf("TestQueryCvmInstance2 cvm DescribeInstances meet err=%v", err)
+		t.Fatalf("TestQueryCvmInstance2 cvm DescribeInstances meet err=%v", err)
 	}
-	fmt.Printf("TestQueryCvmInstance2 cvm DescribeInstances InstanceSet[0].InstanceId[%v]\n", *response.(*cvm.DescribeInstancesResponse).Response.InstanceSet[0].InstanceId)
-	fmt.Printf("TestQueryCvmInstance2 cvm DescribeInstances InstanceSet[0].PrivateIpAddresses[%v]\n", common.StringValues(response.(*cvm.DescribeInstancesResponse).Response.InstanceSet[0].PrivateIpAddresses))
+	fmt.Printf("TestQueryCvmInstance2 cvm DescribeInstances InstanceSet[0].InstanceId[%v]\n", *response[0].InstanceId)
+	fmt.Printf("TestQueryCvmInstance2 cvm DescribeInstances InstanceSet[0].PrivateIpAddresses[%v]\n", common.StringValues(response[0].PrivateIpAddresses))
 }
 
 func TestBindCvmInstanceSecurityGroups(t *testing.T) {
@@ -67,9 +68,9 @@ func TestQueryCvmInstance3(t *testing.T) {
 	}
 	response, err := QueryCvmInstance(providerParams, filter)
 	if err != nil {
-		logrus.Errorf("TestQueryCvmInstance3 cvm DescribeInstances meet err=%v", err)
+		t.Fatalf("TestQueryCvmInstance3 cvm DescribeInstances meet err=%v", err)
 	}
-	fmt.Printf("TestQueryCvmInstance3 cvm DescribeInstances InstanceSet[0].InstanceId[%v]\n", *response.(*cvm.DescribeInstancesResponse).Response.InstanceSet[0].InstanceId)
-	fmt.Printf("TestQueryCvmInstance3 cvm DescribeInstances InstanceSet[0].PrivateIpAddresses[%v]\n", common.StringValues(response.(*cvm.DescribeInstancesResponse).Response.InstanceSet[0].PrivateIpAddresses))
-	fmt.Printf("TestQueryCvmInstance3 cvm DescribeInstances InstanceSet[0].SecurityGroupIds[%v]\n", common.StringValues(response.(*cvm.DescribeInstancesResponse).Response.InstanceSet[0].SecurityGroupIds))
+	fmt.Printf("TestQueryCvmInstance3 cvm DescribeInstances InstanceSet[0].InstanceId[%v]\n", *response[0].InstanceId)
+	fmt.Printf("TestQueryCvmInstance3 cvm DescribeInstances InstanceSet[0].PrivateIpAddresses[%v]\n", common.StringValues(response[0].PrivateIpAddresses))
+	fmt.Printf("TestQueryCvmInstance3 cvm DescribeInstances InstanceSet[0].SecurityGroupIds[%v]\n", common.StringValues(response[0].SecurityGroupIds))
 }
@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+//most Describe apis accept at most 100 ids in one request
+const DESCRIBE_BATCH_SIZE = 100
+
+//DescribeByIdsFunc describes the resources of ids with one request, the result is keyed by resource id
+type DescribeByIdsFunc func(paramsMap map[string]string, ids []string) (map[string]interface{}, error)
+
+type describeBatchKey struct {
+	region    string
+	secretId  string
+	secretKey string
+}
+
+type describeBatchGroup struct {
+	paramsMap map[string]string
+	ids       []string
+}
+
+//BatchDescribe groups the ids of the inputs by region and credential and describes every group in pages of DESCRIBE_BATCH_SIZE ids.
+//resources[i] is the resource of ids[i], it is nil when ids[i] is empty or the resource doesn't exist
+func BatchDescribe(providerParams []string, ids []string, describe DescribeByIdsFunc) ([]interface{}, error) {
+	if len(providerParams) != len(ids) {
+		return nil, fmt.Errorf("BatchDescribe got %d provider params for %d ids", len(providerParams), len(ids))
+	}
+
+	groups := make(map[describeBatchKey]*describeBatchGroup)
+	keys := make([]describeBatchKey, len(ids))
+	for i, id := range ids {
+		if id == "" {
+			continue
+		}
+		paramsMap, err := GetMapFromProviderParams(providerParams[i])
+		if err != nil {
+			return nil, err
+		}
+
+		key := describeBatchKey{region: paramsMap["Region"], secretId: paramsMap["SecretID"], secretKey: paramsMap["SecretKey"]}
+		keys[i] = key
+		group, found := groups[key]
+		if !found {
+			group = &describeBatchGroup{paramsMap: paramsMap}
+			groups[key] = group
+		}
+		group.ids = append(group.ids, id)
+	}
+
+	found := make(map[describeBatchKey]map[string]interface{})
+	for key, group := range groups {
+		ids := uniqueSortedIds(group.ids)
+		found[key] = make(map[string]interface{})
+		for start := 0; start < len(ids); start += DESCRIBE_BATCH_SIZE {
+			end := start + DESCRIBE_BATCH_SIZE
+			if end > len(ids) {
+				end = len(ids)
+			}
+
+			resources, err := describe(group.paramsMap, ids[start:end])
+			if err != nil {
+				logrus.Errorf("batch describe ids=%v in region(%s) meet error=%v", ids[start:end], key.region, err)
+				return nil, err
+			}
+			for id, resource := range resources {
+				found[key][id] = resource
+			}
+		}
+		logrus.Infof("batch describe %d ids in region(%s) with %d requests", len(ids), key.region, (len(ids)+DESCRIBE_BATCH_SIZE-1)/DESCRIBE_BATCH_SIZE)
+	}
+
+	resources := make([]interface{}, len(ids))
+	for i, id := range ids {
+		if id == "" {
+			continue
+		}
+		if resource, ok := found[keys[i]][id]; ok {
+			resources[i] = resource
+		}
+	}
+	return resources, nil
+}
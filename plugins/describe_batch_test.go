@@ -0,0 +1,70 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUnitBatchDescribeGroupsByRegionAndPages(t *testing.T) {
+	guangzhou := "Region=ap-guangzhou;AvailableZone=ap-guangzhou-3;SecretID=id;SecretKey=key"
+	shanghai := "Region=ap-shanghai;AvailableZone=ap-shanghai-2;SecretID=id;SecretKey=key"
+
+	providerParams := []string{}
+	ids := []string{}
+	for i := 0; i < 250; i++ {
+		providerParams = append(providerParams, guangzhou)
+		ids = append(ids, fmt.Sprintf("vpc-%03d", i))
+	}
+	providerParams = append(providerParams, shanghai, shanghai, guangzhou)
+	ids = append(ids, "vpc-sh", "", "vpc-000")
+
+	requests := map[string]int{}
+	describe := func(paramsMap map[string]string, ids []string) (map[string]interface{}, error) {
+		if len(ids) > DESCRIBE_BATCH_SIZE {
+			t.Errorf("describe %d ids in one request", len(ids))
+		}
+		requests[paramsMap["Region"]]++
+		found := make(map[string]interface{})
+		for _, id := range ids {
+			//odd ids don't exist
+			if id == "vpc-sh" || id[len(id)-1]%2 == 0 {
+				found[id] = paramsMap["Region"] + "/" + id
+			}
+		}
+		return found, nil
+	}
+
+	resources, err := BatchDescribe(providerParams, ids, describe)
+	if err != nil {
+		t.Fatalf("BatchDescribe meet error=%v", err)
+	}
+	if requests["ap-guangzhou"] != 3 || requests["ap-shanghai"] != 1 {
+		t.Errorf("unexpected requests %v", requests)
+	}
+
+	expects := map[int]interface{}{
+		0:   "ap-guangzhou/vpc-000",
+		1:   nil,
+		248: "ap-guangzhou/vpc-248",
+		250: "ap-shanghai/vpc-sh",
+		251: nil,
+		252: "ap-guangzhou/vpc-000",
+	}
+	for i, expect := range expects {
+		if resources[i] != expect {
+			t.Errorf("resources[%d] expect %v, got %v", i, expect, resources[i])
+		}
+	}
+}
+
+func TestUnitBatchDescribeReturnsError(t *testing.T) {
+	describe := func(paramsMap map[string]string, ids []string) (map[string]interface{}, error) {
+		return nil, fmt.Errorf("RequestLimitExceeded")
+	}
+	if _, err := BatchDescribe([]string{"Region=ap-guangzhou"}, []string{"disk-1"}, describe); err == nil {
+		t.Errorf("error of describe should be returned")
+	}
+	if _, err := BatchDescribe([]string{}, []string{"disk-1"}, describe); err == nil {
+		t.Errorf("mismatched provider params should be rejected")
+	}
+}
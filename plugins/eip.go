@@ -1,7 +1,6 @@
 package plugins
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -33,12 +32,13 @@ func newVpcClient(region, secretId, secretKey string) (*unversioned.Client, erro
 }
 
 func CreateEIPClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("vpc", "vpc.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return vpc.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*vpc.Client), nil
 }
 
 type EIPInputs struct {
@@ -47,13 +47,13 @@ type EIPInputs struct {
 
 type EIPInput struct {
 	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	AddressCount   string `json:"address_count,omitempty"`
-	InstanceId     string `json:"instance_id,omitempty"`
-	VpcId          string `json:"vpc_id,omitempty"`
-	NatId          string `json:"nat_id,omitempty"`
-	Eip            string `json:"eip,omitempty"`
-	Id             string `json:"id,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	AddressCount   string `json:"address_count,omitempty" validate:"range=1-100"`
+	InstanceId     string `json:"instance_id,omitempty" validate:"required=attach"`
+	VpcId          string `json:"vpc_id,omitempty" validate:"required=bindnat|unbindnat"`
+	NatId          string `json:"nat_id,omitempty" validate:"required=bindnat|unbindnat"`
+	Eip            string `json:"eip,omitempty" validate:"required=bindnat|unbindnat,ip"`
+	Id             string `json:"id,omitempty" validate:"required=attach|detach"`
 }
 
 type EIPOutputs struct {
@@ -97,12 +97,12 @@ func (action *EIPCreateAction) ReadParam(param interface{}) (interface{}, error)
 }
 
 func (action *EIPCreateAction) CheckParam(input interface{}) error {
-	_, ok := input.(EIPInputs)
+	eips, ok := input.(EIPInputs)
 	if !ok {
-		return fmt.Errorf("subnetCreateAtion:input type=%T not right", input)
+		return fmt.Errorf("EIPCreateAction:input type=%T not right", input)
 	}
 
-	return nil
+	return ValidateInputs(eips, "create")
 }
 
 func (action *EIPCreateAction) createEIP(eip *EIPInput) (*EIPOutput, error) {
@@ -196,12 +196,12 @@ func (action *EIPTerminateAction) ReadParam(param interface{}) (interface{}, err
 }
 
 func (action *EIPTerminateAction) CheckParam(input interface{}) error {
-	_, ok := input.(EIPInputs)
+	eips, ok := input.(EIPInputs)
 	if !ok {
 		return fmt.Errorf("EIPTerminateAction:input type=%T not right", input)
 	}
 
-	return nil
+	return ValidateInputs(eips, "terminate")
 }
 
 func (action *EIPTerminateAction) terminateEIP(eip *EIPInput) (*EIPOutput, error) {
@@ -284,19 +284,13 @@ func (action *EIPAttachAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("EIPAttachAction:input type=%T not right", input)
 	}
 
-	for _, eip := range eips.Inputs {
-		if eip.Id == "" {
-			return errors.New("EIPAttachAction param Id is empty")
-		}
-		if eip.InstanceId == "" {
-			return errors.New("EIPAttachAction param InstanceId is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(eips, "attach")
 }
 
 func (action *EIPAttachAction) attachEIP(eip *EIPInput) (*EIPOutput, error) {
+	unlock := LockResources("eip/attach", eip.Id, eip.InstanceId)
+	defer unlock()
+
 	paramsMap, err := GetMapFromProviderParams(eip.ProviderParams)
 	client, _ := CreateEIPClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
 
@@ -347,16 +341,14 @@ func (action *EIPDetachAction) CheckParam(input interface{}) error {
 	if !ok {
 		return fmt.Errorf("EIPDetachAction:input type=%T not right", input)
 	}
-	for _, eip := range eips.Inputs {
-		if eip.Id == "" {
-			return errors.New("EIPDetachAction param Id is empty")
-		}
-	}
 
-	return nil
+	return ValidateInputs(eips, "detach")
 }
 
 func (action *EIPDetachAction) detachEIP(eip *EIPInput) (*EIPOutput, error) {
+	unlock := LockResources("eip/detach", eip.Id)
+	defer unlock()
+
 	paramsMap, err := GetMapFromProviderParams(eip.ProviderParams)
 	client, _ := CreateEIPClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
 
@@ -405,19 +397,8 @@ func (action *EIPBindNatAction) CheckParam(input interface{}) error {
 	if !ok {
 		return fmt.Errorf("EIPBindNatAction:input type=%T not right", input)
 	}
-	for _, eip := range eips.Inputs {
-		if eip.Eip == "" {
-			return errors.New("EIPBindNatAction param Eip is empty")
-		}
-		if eip.NatId == "" {
-			return errors.New("EIPBindNatAction param NatId is empty")
-		}
-		if eip.VpcId == "" {
-			return errors.New("EIPBindNatAction param VpcId is empty")
-		}
-	}
 
-	return nil
+	return ValidateInputs(eips, "bindnat")
 }
 
 func (action *EIPBindNatAction) bindNatGateway(eip *EIPInput) (*EIPOutput, error) {
@@ -494,19 +475,7 @@ func (action *EIPUnBindNatAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("EIPUnBindNatAction:input type=%T not right", input)
 	}
 
-	for _, eip := range eips.Inputs {
-		if eip.Eip == "" {
-			return errors.New("EIPUnBindNatAction param Eip is empty")
-		}
-		if eip.NatId == "" {
-			return errors.New("EIPUnBindNatAction param NatId is empty")
-		}
-		if eip.VpcId == "" {
-			return errors.New("EIPUnBindNatAction param VpcId is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(eips, "unbindnat")
 }
 
 func (action *EIPUnBindNatAction) unbindNatGateway(eip *EIPInput) (*EIPOutput, error) {
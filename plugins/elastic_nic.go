@@ -1,7 +1,6 @@
 package plugins
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -20,12 +19,13 @@ func init() {
 }
 
 func CreateElasticNicClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("vpc", "vpc.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return vpc.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*vpc.Client), nil
 }
 
 type ElasticNicInputs struct {
@@ -34,15 +34,15 @@ type ElasticNicInputs struct {
 
 type ElasticNicInput struct {
 	Guid               string   `json:"guid,omitempty"`
-	ProviderParams     string   `json:"provider_params,omitempty"`
-	Name               string   `json:"name,omitempty"`
+	ProviderParams     string   `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Name               string   `json:"name,omitempty" validate:"required=create"`
 	Description        string   `json:"description,omitempty"`
 	SecurityGroupId    []string `json:"security_group_id,omitempty"`
-	PrivateIpAddresses []string `json:"private_ip_addr,omitempty"`
-	VpcId              string   `json:"vpc_id,omitempty"`
-	SubnetId           string   `json:"subnet_id,omitempty"`
-	InstanceId         string   `json:"instance_id,omitempty"`
-	Id                 string   `json:"id,omitempty"`
+	PrivateIpAddresses []string `json:"private_ip_addr,omitempty" validate:"ip"`
+	VpcId              string   `json:"vpc_id,omitempty" validate:"required=create"`
+	SubnetId           string   `json:"subnet_id,omitempty" validate:"required=create"`
+	InstanceId         string   `json:"instance_id,omitempty" validate:"required=attach|detach"`
+	Id                 string   `json:"id,omitempty" validate:"required=terminate|attach|detach"`
 }
 
 type ElasticNicOutputs struct {
@@ -88,36 +88,13 @@ func (action *ElasticNicCreateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("ElasticNicCreateAction:input type=%T not right", input)
 	}
 
-	for _, elasticNic := range elasticNics.Inputs {
-		if elasticNic.SubnetId == "" {
-			return errors.New("ElasticNicCreateAction input SubnetId is empty")
-		}
-		if elasticNic.VpcId == "" {
-			return errors.New("ElasticNicCreateAction input VpcId is empty")
-		}
-		if elasticNic.Name == "" {
-			return errors.New("ElasticNicCreateAction input Name is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(elasticNics, "create")
 }
 
 func (action *ElasticNicCreateAction) createElasticNic(ElasticNicInput *ElasticNicInput) (*ElasticNicOutput, error) {
 	paramsMap, err := GetMapFromProviderParams(ElasticNicInput.ProviderParams)
 	client, _ := CreateElasticNicClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
 
-	//check resource exist
-	if ElasticNicInput.Id != "" {
-		queryElasticNiResponse, flag, err := queryElasticNicInfo(client, ElasticNicInput)
-		if err != nil && flag == false {
-			return nil, err
-		}
-
-		if err == nil && flag == true {
-			return queryElasticNiResponse, nil
-		}
-	}
 	request := vpc.NewCreateNetworkInterfaceRequest()
 	request.VpcId = &ElasticNicInput.VpcId
 	request.SubnetId = &ElasticNicInput.SubnetId
@@ -151,7 +128,19 @@ func (action *ElasticNicCreateAction) createElasticNic(ElasticNicInput *ElasticN
 func (action *ElasticNicCreateAction) Do(input interface{}) (interface{}, error) {
 	elasticNics, _ := input.(ElasticNicInputs)
 	outputs := ElasticNicOutputs{}
-	for _, elasticNic := range elasticNics.Inputs {
+
+	//check resource exist
+	existElasticNics, err := queryElasticNicInfo(elasticNics.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, elasticNic := range elasticNics.Inputs {
+		if existElasticNics[i] != nil {
+			outputs.Outputs = append(outputs.Outputs, *existElasticNics[i])
+			continue
+		}
+
 		ElasticNicOutput, err := action.createElasticNic(&elasticNic)
 		if err != nil {
 			return nil, err
@@ -181,13 +170,8 @@ func (action *ElasticNicTerminateAction) CheckParam(input interface{}) error {
 	if !ok {
 		return fmt.Errorf("ElasticNicTerminateAction:input type=%T not right", input)
 	}
-	for _, elasticNic := range elasticNics.Inputs {
-		if elasticNic.Id == "" {
-			return errors.New("ElasticNicTerminateAction input Id is empty")
-		}
-	}
 
-	return nil
+	return ValidateInputs(elasticNics, "terminate")
 }
 
 func (action *ElasticNicTerminateAction) terminateElasticNic(ElasticNicInput *ElasticNicInput) (*ElasticNicOutput, error) {
@@ -227,40 +211,64 @@ func (action *ElasticNicTerminateAction) Do(input interface{}) (interface{}, err
 	return &outputs, nil
 }
 
-func queryElasticNicInfo(client *vpc.Client, input *ElasticNicInput) (*ElasticNicOutput, bool, error) {
-	output := ElasticNicOutput{}
+func describeElasticNicsByIds(paramsMap map[string]string, ids []string) (map[string]interface{}, error) {
+	client, err := CreateElasticNicClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
 
 	request := vpc.NewDescribeNetworkInterfacesRequest()
-	request.NetworkInterfaceIds = append(request.NetworkInterfaceIds, &input.Id)
+	for i := range ids {
+		request.NetworkInterfaceIds = append(request.NetworkInterfaceIds, &ids[i])
+	}
+	limit := uint64(DESCRIBE_BATCH_SIZE)
+	request.Limit = &limit
 	response, err := client.DescribeNetworkInterfaces(request)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	if len(response.Response.NetworkInterfaceSet) == 0 {
-		return nil, false, nil
+	elasticNics := make(map[string]interface{})
+	for _, networkInterface := range response.Response.NetworkInterfaceSet {
+		output := &ElasticNicOutput{
+			RequestId: *response.Response.RequestId,
+			Id:        *networkInterface.NetworkInterfaceId,
+		}
+		if len(networkInterface.PrivateIpAddressSet) > 0 {
+			output.PrivateIp = *networkInterface.PrivateIpAddressSet[0].PrivateIpAddress
+		}
+		for _, groupId := range networkInterface.GroupSet {
+			output.AttachGroupList = append(output.AttachGroupList, *groupId)
+		}
+		elasticNics[output.Id] = output
 	}
+	return elasticNics, nil
+}
 
-	if len(response.Response.NetworkInterfaceSet) > 1 {
-		logrus.Errorf("query elastic nic id=%s info find more than 1", input.Id)
-		return nil, false, fmt.Errorf("query elastic nic id=%s info find more than 1", input.Id)
+//queryElasticNicInfo returns the existing elastic nic of every input, nil when the input has no id or the nic doesn't exist
+func queryElasticNicInfo(inputs []ElasticNicInput) ([]*ElasticNicOutput, error) {
+	providerParams := []string{}
+	ids := []string{}
+	for _, input := range inputs {
+		providerParams = append(providerParams, input.ProviderParams)
+		ids = append(ids, input.Id)
 	}
 
-	output.Guid = input.Guid
-	output.Id = input.Id
-	output.RequestId = *response.Response.RequestId
-
-	if len(response.Response.NetworkInterfaceSet[0].PrivateIpAddressSet) > 0 {
-		output.PrivateIp = *response.Response.NetworkInterfaceSet[0].PrivateIpAddressSet[0].PrivateIpAddress
+	resources, err := BatchDescribe(providerParams, ids, describeElasticNicsByIds)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(response.Response.NetworkInterfaceSet[0].GroupSet) > 0 {
-		for i := 0; i < len(response.Response.NetworkInterfaceSet[0].GroupSet); i++ {
-			output.AttachGroupList = append(output.AttachGroupList, *response.Response.NetworkInterfaceSet[0].GroupSet[i])
+	outputs := make([]*ElasticNicOutput, len(inputs))
+	for i, resource := range resources {
+		if resource == nil {
+			continue
 		}
+		output := *resource.(*ElasticNicOutput)
+		output.Guid = inputs[i].Guid
+		outputs[i] = &output
 	}
-
-	return &output, true, nil
+	return outputs, nil
 }
 
 type ElasticNicAttachAction struct {
@@ -281,19 +289,13 @@ func (action *ElasticNicAttachAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("ElasticNicAttachAction:input type=%T not right", input)
 	}
 
-	for _, elasticNic := range elasticNics.Inputs {
-		if elasticNic.Id == "" {
-			return errors.New("ElasticNicAttachAction input Id is empty")
-		}
-		if elasticNic.InstanceId == "" {
-			return errors.New("ElasticNicAttachAction input InstanceId is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(elasticNics, "attach")
 }
 
 func (action *ElasticNicAttachAction) attachElasticNic(ElasticNicInput *ElasticNicInput) (*ElasticNicOutput, error) {
+	unlock := LockResources("elastic-nic/attach", ElasticNicInput.Id, ElasticNicInput.InstanceId)
+	defer unlock()
+
 	paramsMap, err := GetMapFromProviderParams(ElasticNicInput.ProviderParams)
 	client, _ := CreateElasticNicClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
 
@@ -348,19 +350,13 @@ func (action *ElasticNicDetachAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("ElasticNicDetachAction:input type=%T not right", input)
 	}
 
-	for _, elasticNic := range elasticNics.Inputs {
-		if elasticNic.Id == "" {
-			return errors.New("ElasticNicDetachAction input Id is empty")
-		}
-		if elasticNic.InstanceId == "" {
-			return errors.New("ElasticNicDetachAction input InstanceId is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(elasticNics, "detach")
 }
 
 func (action *ElasticNicDetachAction) detachElasticNic(ElasticNicInput *ElasticNicInput) (*ElasticNicOutput, error) {
+	unlock := LockResources("elastic-nic/detach", ElasticNicInput.Id, ElasticNicInput.InstanceId)
+	defer unlock()
+
 	paramsMap, err := GetMapFromProviderParams(ElasticNicInput.ProviderParams)
 	client, _ := CreateElasticNicClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
 
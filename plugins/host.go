@@ -0,0 +1,358 @@
+package plugins
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	HOST_SCRIPT_DIR             = "./scripts/host"
+	HOST_REMOTE_SCRIPT_PREFIX   = "/tmp/wecube_run_script_"
+	HOST_SCRIPT_DEFAULT_TIMEOUT = 300
+	HOST_DEFAULT_CONCURRENCY    = 10
+	//stdout and stderr longer than this are truncated from the head
+	MAX_HOST_SCRIPT_OUTPUT_SIZE = 64 * 1024
+	HOST_SCRIPT_NOT_RUN_EXIT    = -1
+	//exit code of the script killed by timeout -s KILL on the host
+	HOST_SCRIPT_KILLED_EXIT = 137
+	//the connection is closed when the host doesn't kill the script in this time after the timeout
+	HOST_SCRIPT_KILL_GRACE = 10 * time.Second
+)
+
+var hostScriptFileRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9]+)?$`)
+
+var hostActions = make(map[string]Action)
+
+func init() {
+	hostActions["run-script"] = new(HostRunScriptAction)
+}
+
+type HostPlugin struct {
+}
+
+func (plugin *HostPlugin) GetActionByName(actionName string) (Action, error) {
+	action, found := hostActions[actionName]
+	if !found {
+		return nil, fmt.Errorf("host plugin,action = %s not found", actionName)
+	}
+	return action, nil
+}
+
+//--------------run script--------------------//
+type HostRunScriptAction struct {
+}
+
+type HostRunScriptInputs struct {
+	Inputs []HostRunScriptInput `json:"inputs,omitempty"`
+}
+
+//HostRunScriptInput runs the script on all the instances and ips, they share the same encrypted password or private key
+type HostRunScriptInput struct {
+	Guid           string `json:"guid,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"provider_params"`
+	//instance ids and ips separated by comma, provider_params is required to get the private ips of the instances
+	InstanceIds string `json:"instance_ids,omitempty"`
+	Ips         string `json:"ips,omitempty"`
+
+	InstanceGuid       string `json:"instance_guid,omitempty" validate:"required"`
+	InstanceSeed       string `json:"seed,omitempty" validate:"required"`
+	InstancePassword   string `json:"password,omitempty"`
+	InstancePrivateKey string `json:"private_key,omitempty"`
+
+	//script is the inline script content, script_file is the name of the file in scripts/host,
+	//both are uploaded to the host and executed with script_args
+	Script     string `json:"script,omitempty"`
+	ScriptFile string `json:"script_file,omitempty"`
+	ScriptArgs string `json:"script_args,omitempty"`
+
+	//seconds to upload and run the script on each host, the script is killed on the host after it
+	Timeout     int `json:"timeout,omitempty" validate:"range=0-86400"`
+	Concurrency int `json:"concurrency,omitempty" validate:"range=0-100"`
+}
+
+type HostRunScriptOutputs struct {
+	Outputs []HostRunScriptOutput `json:"outputs,omitempty"`
+}
+
+type HostRunScriptOutput struct {
+	Guid    string             `json:"guid,omitempty"`
+	Results []HostScriptResult `json:"results"`
+}
+
+//HostScriptResult has exit code -1 and the error message when the script is not run or not finished in time
+type HostScriptResult struct {
+	Target       string `json:"target"`
+	Ip           string `json:"ip,omitempty"`
+	ExitCode     int    `json:"exit_code"`
+	Stdout       string `json:"stdout"`
+	Stderr       string `json:"stderr"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+func (action *HostRunScriptAction) ReadParam(param interface{}) (interface{}, error) {
+	var inputs HostRunScriptInputs
+	err := UnmarshalJson(param, &inputs)
+	if err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+func (action *HostRunScriptAction) CheckParam(input interface{}) error {
+	inputs, ok := input.(HostRunScriptInputs)
+	if !ok {
+		return fmt.Errorf("HostRunScriptAction:input type=%T not right", input)
+	}
+
+	for _, input := range inputs.Inputs {
+		if err := checkHostRunScriptInput(input); err != nil {
+			return fmt.Errorf("HostRunScriptAction:%v", err)
+		}
+	}
+	return ValidateInputs(inputs, "")
+}
+
+func checkHostRunScriptInput(input HostRunScriptInput) error {
+	if input.InstanceIds == "" && input.Ips == "" {
+		return fmt.Errorf("instance_ids or ips should be set")
+	}
+	if input.InstanceIds != "" && input.ProviderParams == "" {
+		return fmt.Errorf("provider_params should be set when instance_ids is set")
+	}
+	if input.InstancePassword == "" && input.InstancePrivateKey == "" {
+		return fmt.Errorf("password or private_key should be set")
+	}
+	if (input.Script == "") == (input.ScriptFile == "") {
+		return fmt.Errorf("one of script and script_file should be set")
+	}
+	if input.ScriptFile != "" {
+		if !hostScriptFileRegexp.MatchString(input.ScriptFile) {
+			return fmt.Errorf("script_file(%s) is invalid", input.ScriptFile)
+		}
+		if _, err := os.Stat(filepath.Join(HOST_SCRIPT_DIR, input.ScriptFile)); err != nil {
+			return fmt.Errorf("script_file(%s) not found", input.ScriptFile)
+		}
+	}
+	for _, ip := range splitAndTrim(input.Ips, ",") {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("ip(%s) is invalid", ip)
+		}
+	}
+	return nil
+}
+
+func (action *HostRunScriptAction) Do(input interface{}) (interface{}, error) {
+	inputs, _ := input.(HostRunScriptInputs)
+	outputs := HostRunScriptOutputs{}
+
+	for _, input := range inputs.Inputs {
+		output, err := runHostScript(input)
+		if err != nil {
+			return outputs, err
+		}
+		outputs.Outputs = append(outputs.Outputs, output)
+	}
+	return outputs, nil
+}
+
+func runHostScript(input HostRunScriptInput) (HostRunScriptOutput, error) {
+	output := HostRunScriptOutput{
+		Guid:    input.Guid,
+		Results: []HostScriptResult{},
+	}
+
+	password, err := decryptInstanceLoginSecret(input.InstanceGuid, input.InstanceSeed, input.InstancePassword, input.InstancePrivateKey)
+	if err != nil {
+		return output, err
+	}
+
+	targets := append(splitAndTrim(input.InstanceIds, ","), splitAndTrim(input.Ips, ",")...)
+	timeout := input.Timeout
+	if timeout <= 0 {
+		timeout = HOST_SCRIPT_DEFAULT_TIMEOUT
+	}
+	concurrency := input.Concurrency
+	if concurrency <= 0 {
+		concurrency = HOST_DEFAULT_CONCURRENCY
+	}
+
+	output.Results = runOnHostsConcurrently(targets, concurrency, func(target string) HostScriptResult {
+		result := HostScriptResult{Target: target, ExitCode: HOST_SCRIPT_NOT_RUN_EXIT}
+		ip, err := resolveHostIp(input.ProviderParams, target)
+		if err != nil {
+			result.ErrorMessage = err.Error()
+			return result
+		}
+		result.Ip = ip
+		runScriptOnHost(&result, password, input, time.Duration(timeout)*time.Second)
+		return result
+	})
+	return output, nil
+}
+
+//runOnHostsConcurrently runs on at most concurrency targets at the same time, the results are in the order of targets
+func runOnHostsConcurrently(targets []string, concurrency int, run func(target string) HostScriptResult) []HostScriptResult {
+	results := make([]HostScriptResult, len(targets))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, target string) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			results[i] = run(target)
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
+
+//resolveHostIp returns the private ip of the instance, the ips are returned as they are
+func resolveHostIp(providerParams string, target string) (string, error) {
+	if net.ParseIP(target) != nil {
+		return target, nil
+	}
+	return getInstancePrivateIp(providerParams, target)
+}
+
+func newRemoteScriptFile() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return HOST_REMOTE_SCRIPT_PREFIX + hex.EncodeToString(buf), nil
+}
+
+//buildRunScriptCommand runs the uploaded script and removes it, the script is killed with its children by timeout on the host
+//after timeoutSeconds, exec runs the script with sh when there is no shebang
+func buildRunScriptCommand(remoteFile string, args string, timeoutSeconds int) string {
+	file := shellQuote(remoteFile)
+	cmd := "chmod 700 " + file + " && timeout -s KILL " + strconv.Itoa(timeoutSeconds) + " " + file
+	if args != "" {
+		cmd += " " + args
+	}
+	return cmd + "; rc=$?; rm -f " + file + "; exit $rc"
+}
+
+func uploadHostScript(client *ssh.Client, input HostRunScriptInput, remoteFile string) error {
+	content := []byte(input.Script)
+	if input.ScriptFile != "" {
+		var err error
+		if content, err = ioutil.ReadFile(filepath.Join(HOST_SCRIPT_DIR, input.ScriptFile)); err != nil {
+			return err
+		}
+	}
+
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return err
+	}
+	defer sftpClient.Close()
+
+	file, err := sftpClient.Create(remoteFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(content)
+	return err
+}
+
+//runScriptOnHost uploads and runs the script in one connection, the timeout counts from connecting to the host
+func runScriptOnHost(result *HostScriptResult, password string, input HostRunScriptInput, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	remoteFile, err := newRemoteScriptFile()
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("create remote script file name meet error=%v", err)
+		return
+	}
+
+	client, err := createSshClient(result.Ip, password)
+	if err != nil {
+		logrus.Errorf("ssh to %s meet error=%v", result.Ip, err)
+		result.ErrorMessage = err.Error()
+		return
+	}
+	defer client.Close()
+	//closing the connection stops the upload or the script which is not killed by the host in time
+	timer := time.AfterFunc(time.Until(deadline)+HOST_SCRIPT_KILL_GRACE, func() {
+		client.Close()
+	})
+	defer timer.Stop()
+
+	if err = uploadHostScript(client, input, remoteFile); err != nil {
+		logrus.Errorf("upload script to %s meet error=%v", result.Ip, err)
+		result.ErrorMessage = fmt.Sprintf("upload script meet error=%v", err)
+		return
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		logrus.Errorf("upload script to %s timeout after %v", result.Ip, timeout)
+		result.ErrorMessage = fmt.Sprintf("timeout after %v", timeout)
+		return
+	}
+
+	cmd := buildRunScriptCommand(remoteFile, input.ScriptArgs, int(math.Ceil(remaining.Seconds())))
+	exitCode, stdout, stderr, err := runRemoteHostCommand(client, cmd)
+	result.ExitCode = exitCode
+	result.Stdout = truncateHostScriptOutput(stdout)
+	result.Stderr = truncateHostScriptOutput(stderr)
+	if !time.Now().Before(deadline) && (err != nil || exitCode == HOST_SCRIPT_KILLED_EXIT) {
+		logrus.Errorf("run script on %s timeout after %v", result.Ip, timeout)
+		result.ExitCode = HOST_SCRIPT_NOT_RUN_EXIT
+		result.ErrorMessage = fmt.Sprintf("timeout after %v", timeout)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("run script on %s meet error=%v", result.Ip, err)
+		result.ErrorMessage = err.Error()
+	}
+}
+
+//runRemoteHostCommand returns the exit code with stdout and stderr
+func runRemoteHostCommand(client *ssh.Client, cmd string) (int, string, string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return HOST_SCRIPT_NOT_RUN_EXIT, "", "", err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	err = session.Run(cmd)
+	if err == nil {
+		return 0, stdout.String(), stderr.String(), nil
+	}
+	if exitErr, ok := err.(*ssh.ExitError); ok {
+		return exitErr.ExitStatus(), stdout.String(), stderr.String(), nil
+	}
+	return HOST_SCRIPT_NOT_RUN_EXIT, stdout.String(), stderr.String(), err
+}
+
+func truncateHostScriptOutput(output string) string {
+	if len(output) <= MAX_HOST_SCRIPT_OUTPUT_SIZE {
+		return output
+	}
+	truncated := len(output) - MAX_HOST_SCRIPT_OUTPUT_SIZE
+	return "...(" + strconv.Itoa(truncated) + " bytes truncated)\n" + output[truncated:]
+}
@@ -0,0 +1,103 @@
+package plugins
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUnitCheckHostRunScriptInput(t *testing.T) {
+	valid := HostRunScriptInput{
+		Ips:              "10.0.0.1, 10.0.0.2",
+		InstanceGuid:     "0123_456789",
+		InstanceSeed:     "seed",
+		InstancePassword: "encrypted",
+		Script:           "echo hello",
+	}
+	if err := checkHostRunScriptInput(valid); err != nil {
+		t.Fatalf("valid input should pass, err=%v", err)
+	}
+
+	invalidInputs := map[string]func(input *HostRunScriptInput){
+		"no targets":                        func(input *HostRunScriptInput) { input.Ips = "" },
+		"instance ids without provider":     func(input *HostRunScriptInput) { input.InstanceIds = "ins-abcd1234" },
+		"invalid ip":                        func(input *HostRunScriptInput) { input.Ips = "10.0.0.1,host-1" },
+		"no password":                       func(input *HostRunScriptInput) { input.InstancePassword = "" },
+		"no script":                         func(input *HostRunScriptInput) { input.Script = "" },
+		"both script and script file":       func(input *HostRunScriptInput) { input.ScriptFile = "check-host.sh" },
+		"script file out of the script dir": func(input *HostRunScriptInput) { input.Script, input.ScriptFile = "", "../cbs.sh" },
+		"script file not found":             func(input *HostRunScriptInput) { input.Script, input.ScriptFile = "", "not-exist.sh" },
+	}
+	for name, modify := range invalidInputs {
+		input := valid
+		modify(&input)
+		if err := checkHostRunScriptInput(input); err == nil {
+			t.Errorf("%s should be invalid", name)
+		}
+	}
+}
+
+func TestUnitBuildRunScriptCommand(t *testing.T) {
+	expected := "chmod 700 '/tmp/wecube_run_script_01' && timeout -s KILL 300 '/tmp/wecube_run_script_01'; rc=$?; rm -f '/tmp/wecube_run_script_01'; exit $rc"
+	if got := buildRunScriptCommand("/tmp/wecube_run_script_01", "", 300); got != expected {
+		t.Errorf("command without args got %s, expected %s", got, expected)
+	}
+
+	expected = "chmod 700 '/tmp/wecube_run_script_01' && timeout -s KILL 60 '/tmp/wecube_run_script_01' /data '/var/log'; rc=$?; rm -f '/tmp/wecube_run_script_01'; exit $rc"
+	if got := buildRunScriptCommand("/tmp/wecube_run_script_01", "/data '/var/log'", 60); got != expected {
+		t.Errorf("command with args got %s, expected %s", got, expected)
+	}
+}
+
+func TestUnitRunOnHostsConcurrently(t *testing.T) {
+	targets := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	results := runOnHostsConcurrently(targets, 2, func(target string) HostScriptResult {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return HostScriptResult{Target: target, Ip: target}
+	})
+
+	if maxRunning > 2 {
+		t.Errorf("%d hosts run at the same time, more than 2", maxRunning)
+	}
+	if len(results) != len(targets) {
+		t.Fatalf("%d results for %d targets", len(results), len(targets))
+	}
+	for i, result := range results {
+		if result.Target != targets[i] {
+			t.Errorf("result %d is for %s, expected %s", i, result.Target, targets[i])
+		}
+	}
+}
+
+func TestUnitResolveHostIp(t *testing.T) {
+	ip, err := resolveHostIp("", "10.0.0.1")
+	if err != nil || ip != "10.0.0.1" {
+		t.Errorf("ip should be returned as it is, got %s, err=%v", ip, err)
+	}
+}
+
+func TestUnitTruncateHostScriptOutput(t *testing.T) {
+	if got := truncateHostScriptOutput("hello"); got != "hello" {
+		t.Errorf("short output should not be truncated, got %s", got)
+	}
+
+	output := strings.Repeat("a", 10) + strings.Repeat("b", MAX_HOST_SCRIPT_OUTPUT_SIZE)
+	got := truncateHostScriptOutput(output)
+	if !strings.HasPrefix(got, "...(10 bytes truncated)\n") || !strings.HasSuffix(got, strings.Repeat("b", MAX_HOST_SCRIPT_OUTPUT_SIZE)) {
+		t.Errorf("long output should keep the tail, got prefix %s", got[:30])
+	}
+}
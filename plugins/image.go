@@ -0,0 +1,655 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	IMAGE_STATE_NORMAL        = "NORMAL"
+	IMAGE_STATE_CREATE_FAILED = "CREATEFAILED"
+	IMAGE_STATE_IMPORT_FAILED = "IMPORTFAILED"
+
+	IMAGE_SHARE_PERMISSION_SHARE  = "SHARE"
+	IMAGE_SHARE_PERMISSION_CANCEL = "CANCEL"
+
+	IMAGE_ID_PREFIX = "img-"
+
+	//creating and copying images take minutes
+	IMAGE_WAIT_TIMEOUT       = 3600
+	IMAGE_WAIT_POLL_INTERVAL = 10
+)
+
+var (
+	IMAGE_WAIT_TIMEOUT_ERROR = errors.New("qcloud wait image timeout")
+	IMAGE_NOT_FOUND_ERROR    = errors.New("qcloud image not found")
+)
+
+var ImageActions = make(map[string]Action)
+
+func init() {
+	ImageActions["create"] = new(ImageCreateAction)
+	ImageActions["copy"] = new(ImageCopyAction)
+	ImageActions["share"] = new(ImageShareAction)
+	ImageActions["terminate"] = new(ImageTerminateAction)
+	ImageActions["query"] = new(ImageQueryAction)
+}
+
+type ImageInputs struct {
+	Inputs []ImageInput `json:"inputs,omitempty"`
+}
+
+type ImageInput struct {
+	Guid           string `json:"guid,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Id             string `json:"id,omitempty" validate:"required=copy|share|terminate"`
+	Name           string `json:"name,omitempty" validate:"required=create"`
+	Description    string `json:"description,omitempty"`
+
+	//use to create image from vm
+	InstanceId    string `json:"instance_id,omitempty" validate:"required=create"`
+	DataDiskIds   string `json:"data_disk_ids,omitempty"`
+	ForcePowerOff bool   `json:"force_power_off,omitempty"`
+
+	//regions separated by comma, use to copy
+	DestinationRegions string `json:"destination_regions,omitempty" validate:"required=copy"`
+
+	//account ids separated by comma, use to share
+	AccountIds string `json:"account_ids,omitempty" validate:"required=share"`
+	Permission string `json:"permission,omitempty" validate:"enum=SHARE|CANCEL,ignorecase"`
+
+	//use to query
+	ImageType string `json:"image_type,omitempty" validate:"enum=PRIVATE_IMAGE|PUBLIC_IMAGE|MARKET_IMAGE|SHARED_IMAGE"`
+}
+
+type ImageOutputs struct {
+	Outputs []ImageOutput `json:"outputs,omitempty"`
+}
+
+type ImageOutput struct {
+	Guid       string `json:"guid,omitempty"`
+	RequestId  string `json:"request_id,omitempty"`
+	Id         string `json:"id,omitempty"`
+	ImageState string `json:"image_state,omitempty"`
+	//ids of the copied images separated by comma, in the order of destination_regions
+	CopiedImageIds string        `json:"copied_image_ids,omitempty"`
+	Images         []ImageDetail `json:"images,omitempty"`
+}
+
+type ImageDetail struct {
+	Id           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+	ImageType    string `json:"image_type,omitempty"`
+	ImageState   string `json:"image_state,omitempty"`
+	OsName       string `json:"os_name,omitempty"`
+	Platform     string `json:"platform,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+	ImageSize    int64  `json:"image_size,omitempty"`
+	CreatedTime  string `json:"created_time,omitempty"`
+}
+
+type ImagePlugin struct {
+}
+
+func (plugin *ImagePlugin) GetActionByName(actionName string) (Action, error) {
+	action, found := ImageActions[actionName]
+	if !found {
+		return nil, fmt.Errorf("image plugin,action = %s not found", actionName)
+	}
+	return action, nil
+}
+
+type ImageAction struct {
+}
+
+func (action *ImageAction) ReadParam(param interface{}) (interface{}, error) {
+	var inputs ImageInputs
+	err := UnmarshalJson(param, &inputs)
+	if err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+func checkImageInputs(input interface{}, scene string) error {
+	images, ok := input.(ImageInputs)
+	if !ok {
+		return fmt.Errorf("Image%sAction:input type=%T not right", strings.Title(scene), input)
+	}
+
+	return ValidateInputs(images, scene)
+}
+
+func createImageClient(providerParams string) (*cvm.Client, error) {
+	paramsMap, err := GetMapFromProviderParams(providerParams)
+	if err != nil {
+		return nil, err
+	}
+	return createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+}
+
+func describeImages(client *cvm.Client, imageIds []string, filters []*cvm.Filter) ([]*cvm.Image, error) {
+	images := []*cvm.Image{}
+	err := PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := cvm.NewDescribeImagesRequest()
+		if len(imageIds) > 0 {
+			request.ImageIds = common.StringPtrs(imageIds)
+		}
+		request.Filters = filters
+		request.Offset = common.Uint64Ptr(uint64(offset))
+		request.Limit = common.Uint64Ptr(uint64(limit))
+
+		response, err := client.DescribeImages(request)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		images = append(images, response.Response.ImageSet...)
+		return len(response.Response.ImageSet), int(*response.Response.TotalCount), nil
+	})
+	if err != nil {
+		logrus.Errorf("cvm DescribeImages meet error=%v", err)
+		return nil, err
+	}
+	return images, nil
+}
+
+func getImageById(client *cvm.Client, imageId string) (*cvm.Image, error) {
+	images, err := describeImages(client, []string{imageId}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, IMAGE_NOT_FOUND_ERROR
+	}
+	return images[0], nil
+}
+
+//getImagesByName returns the images with exactly the name
+func getImagesByName(client *cvm.Client, name string) ([]*cvm.Image, error) {
+	filters := []*cvm.Filter{
+		{
+			Name:   common.StringPtr("image-name"),
+			Values: common.StringPtrs([]string{name}),
+		},
+	}
+	images, err := describeImages(client, nil, filters)
+	if err != nil {
+		return nil, err
+	}
+	//the name filter may match by prefix, so compare the name again
+	matchedImages := []*cvm.Image{}
+	for _, image := range images {
+		if stringValue(image.ImageName) == name {
+			matchedImages = append(matchedImages, image)
+		}
+	}
+	return matchedImages, nil
+}
+
+//getImageByName returns nil when there is no image with the name, image names are unique in one region
+func getImageByName(client *cvm.Client, name string) (*cvm.Image, error) {
+	images, err := getImagesByName(client, name)
+	if err != nil || len(images) == 0 {
+		return nil, err
+	}
+	return images[0], nil
+}
+
+//resolveImageId returns the id of the image, imageIdOrName can be the image id or the image name,
+//it's looked up as an id first since an image name may also start with img-
+func resolveImageId(client *cvm.Client, imageIdOrName string) (string, error) {
+	if strings.HasPrefix(imageIdOrName, IMAGE_ID_PREFIX) {
+		image, err := getImageById(client, imageIdOrName)
+		if err == nil {
+			return *image.ImageId, nil
+		}
+		logrus.Infof("image id(%s) not found, look it up as image name, error=%v", imageIdOrName, err)
+	}
+
+	image, err := getImageByName(client, imageIdOrName)
+	if err != nil {
+		return "", err
+	}
+	if image == nil {
+		return "", fmt.Errorf("image with id or name(%s) not found", imageIdOrName)
+	}
+	logrus.Infof("image name(%s) is resolved to image id(%s)", imageIdOrName, *image.ImageId)
+	return *image.ImageId, nil
+}
+
+//getImageIdSet returns the ids of the images as a set
+func getImageIdSet(images []*cvm.Image) map[string]bool {
+	imageIds := map[string]bool{}
+	for _, image := range images {
+		imageIds[stringValue(image.ImageId)] = true
+	}
+	return imageIds
+}
+
+//findNewImage returns the first image not in existingImageIds, nil when there is none
+func findNewImage(images []*cvm.Image, existingImageIds map[string]bool) *cvm.Image {
+	for _, image := range images {
+		if !existingImageIds[stringValue(image.ImageId)] {
+			return image
+		}
+	}
+	return nil
+}
+
+//waitImageNormal waits until the image is NORMAL, the image is looked up by name when imageId is empty,
+//an image looked up by name may be not visible yet right after it's created
+func waitImageNormal(client *cvm.Client, imageId string, imageName string, timeout int) (*cvm.Image, error) {
+	return waitImageInNormal(fmt.Sprintf("id=%s, name=%s", imageId, imageName), timeout, func() (*cvm.Image, error) {
+		if imageId != "" {
+			return getImageById(client, imageId)
+		}
+		return getImageByName(client, imageName)
+	})
+}
+
+//waitCopiedImageNormal waits until the image copied with the name is NORMAL,
+//the images with the same name in the region before copying are in existingImageIds and skipped
+func waitCopiedImageNormal(client *cvm.Client, imageName string, existingImageIds map[string]bool, timeout int) (*cvm.Image, error) {
+	return waitImageInNormal(fmt.Sprintf("copied, name=%s", imageName), timeout, func() (*cvm.Image, error) {
+		images, err := getImagesByName(client, imageName)
+		if err != nil {
+			return nil, err
+		}
+		return findNewImage(images, existingImageIds), nil
+	})
+}
+
+//waitImageInNormal polls the image returned by getImage, which returns nil when the image is not visible yet
+func waitImageInNormal(description string, timeout int, getImage func() (*cvm.Image, error)) (*cvm.Image, error) {
+	count := 0
+	for {
+		image, err := getImage()
+		if err != nil {
+			return nil, err
+		}
+
+		if image != nil {
+			state := stringValue(image.ImageState)
+			if state == IMAGE_STATE_NORMAL {
+				return image, nil
+			}
+			if state == IMAGE_STATE_CREATE_FAILED || state == IMAGE_STATE_IMPORT_FAILED {
+				return nil, fmt.Errorf("image(%s) is in state %s", stringValue(image.ImageId), state)
+			}
+		}
+
+		count++
+		if count*IMAGE_WAIT_POLL_INTERVAL > timeout {
+			logrus.Errorf("wait image(%s) normal timeout", description)
+			return nil, IMAGE_WAIT_TIMEOUT_ERROR
+		}
+		time.Sleep(IMAGE_WAIT_POLL_INTERVAL * time.Second)
+	}
+}
+
+func buildImageDetail(image *cvm.Image) ImageDetail {
+	detail := ImageDetail{
+		Id:           stringValue(image.ImageId),
+		Name:         stringValue(image.ImageName),
+		Description:  stringValue(image.ImageDescription),
+		ImageType:    stringValue(image.ImageType),
+		ImageState:   stringValue(image.ImageState),
+		OsName:       stringValue(image.OsName),
+		Platform:     stringValue(image.Platform),
+		Architecture: stringValue(image.Architecture),
+		CreatedTime:  stringValue(image.CreatedTime),
+	}
+	if image.ImageSize != nil {
+		detail.ImageSize = *image.ImageSize
+	}
+	return detail
+}
+
+//--------------create image--------------------//
+type ImageCreateAction struct {
+	ImageAction
+}
+
+func (action *ImageCreateAction) CheckParam(input interface{}) error {
+	return checkImageInputs(input, "create")
+}
+
+func (action *ImageCreateAction) createImage(input *ImageInput) (*ImageOutput, error) {
+	client, err := createImageClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	output := ImageOutput{Guid: input.Guid}
+
+	//check resource exist, the image name is unique so it's used to find the image created before
+	image, err := getImageByName(client, input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if image == nil {
+		unlock := LockResources("image/create", input.InstanceId)
+		defer unlock()
+
+		request := cvm.NewCreateImageRequest()
+		request.ImageName = &input.Name
+		request.InstanceId = &input.InstanceId
+		if input.Description != "" {
+			request.ImageDescription = &input.Description
+		}
+		if input.ForcePowerOff {
+			request.ForcePoweroff = common.StringPtr("TRUE")
+		}
+		if dataDiskIds := splitAndTrim(input.DataDiskIds, ","); len(dataDiskIds) > 0 {
+			request.DataDiskIds = common.StringPtrs(dataDiskIds)
+		}
+
+		response, err := client.CreateImage(request)
+		if err != nil {
+			logrus.Errorf("cvm CreateImage(instanceId=%s) meet error=%v", input.InstanceId, err)
+			return nil, err
+		}
+		output.RequestId = *response.Response.RequestId
+		logrus.Infof("Create image[%v] from instance[%v] has been submitted, RequestID is [%v]", input.Name, input.InstanceId, output.RequestId)
+	}
+
+	if image, err = waitImageNormal(client, "", input.Name, IMAGE_WAIT_TIMEOUT); err != nil {
+		return nil, err
+	}
+	output.Id = *image.ImageId
+	output.ImageState = *image.ImageState
+
+	return &output, nil
+}
+
+func (action *ImageCreateAction) Do(input interface{}) (interface{}, error) {
+	images, _ := input.(ImageInputs)
+	outputs := ImageOutputs{}
+	for _, image := range images.Inputs {
+		output, err := action.createImage(&image)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	logrus.Infof("all images = %v are created", images)
+	return &outputs, nil
+}
+
+//--------------copy image to other regions--------------------//
+type ImageCopyAction struct {
+	ImageAction
+}
+
+func (action *ImageCopyAction) CheckParam(input interface{}) error {
+	return checkImageInputs(input, "copy")
+}
+
+func (action *ImageCopyAction) copyImage(input *ImageInput) (*ImageOutput, error) {
+	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := LockResources("image/copy", input.Id)
+	defer unlock()
+
+	image, err := waitImageNormal(client, input.Id, "", IMAGE_WAIT_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+
+	//the copied images keep the name of the source image, so the images with the same name
+	//in the destination regions before copying are recorded to tell the copied ones apart
+	regions := splitAndTrim(input.DestinationRegions, ",")
+	regionClients := map[string]*cvm.Client{}
+	existingImageIds := map[string]map[string]bool{}
+	for _, region := range regions {
+		regionClient, err := createCvmClient(region, paramsMap["SecretID"], paramsMap["SecretKey"])
+		if err != nil {
+			return nil, err
+		}
+		existingImages, err := getImagesByName(regionClient, *image.ImageName)
+		if err != nil {
+			return nil, err
+		}
+		regionClients[region] = regionClient
+		existingImageIds[region] = getImageIdSet(existingImages)
+	}
+
+	request := cvm.NewSyncImagesRequest()
+	request.ImageIds = []*string{&input.Id}
+	request.DestinationRegions = common.StringPtrs(regions)
+	response, err := client.SyncImages(request)
+	if err != nil {
+		logrus.Errorf("cvm SyncImages(imageId=%s, regions=%v) meet error=%v", input.Id, regions, err)
+		return nil, err
+	}
+
+	copiedImageIds := []string{}
+	for _, region := range regions {
+		copiedImage, err := waitCopiedImageNormal(regionClients[region], *image.ImageName, existingImageIds[region], IMAGE_WAIT_TIMEOUT)
+		if err != nil {
+			return nil, fmt.Errorf("wait image copied to region(%s) meet error=%v", region, err)
+		}
+		copiedImageIds = append(copiedImageIds, *copiedImage.ImageId)
+	}
+
+	output := ImageOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.Id = input.Id
+	output.ImageState = IMAGE_STATE_NORMAL
+	output.CopiedImageIds = strings.Join(copiedImageIds, ",")
+
+	return &output, nil
+}
+
+func (action *ImageCopyAction) Do(input interface{}) (interface{}, error) {
+	images, _ := input.(ImageInputs)
+	outputs := ImageOutputs{}
+	for _, image := range images.Inputs {
+		output, err := action.copyImage(&image)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//--------------share image with other accounts--------------------//
+type ImageShareAction struct {
+	ImageAction
+}
+
+func (action *ImageShareAction) CheckParam(input interface{}) error {
+	return checkImageInputs(input, "share")
+}
+
+func (action *ImageShareAction) shareImage(input *ImageInput) (*ImageOutput, error) {
+	client, err := createImageClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := LockResources("image/share", input.Id)
+	defer unlock()
+
+	if _, err = waitImageNormal(client, input.Id, "", IMAGE_WAIT_TIMEOUT); err != nil {
+		return nil, err
+	}
+
+	permission := strings.ToUpper(input.Permission)
+	if permission == "" {
+		permission = IMAGE_SHARE_PERMISSION_SHARE
+	}
+
+	request := cvm.NewModifyImageSharePermissionRequest()
+	request.ImageId = &input.Id
+	request.AccountIds = common.StringPtrs(splitAndTrim(input.AccountIds, ","))
+	request.Permission = &permission
+	response, err := client.ModifyImageSharePermission(request)
+	if err != nil {
+		logrus.Errorf("cvm ModifyImageSharePermission(imageId=%s, permission=%s) meet error=%v", input.Id, permission, err)
+		return nil, err
+	}
+
+	output := ImageOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.Id = input.Id
+	output.ImageState = IMAGE_STATE_NORMAL
+
+	return &output, nil
+}
+
+func (action *ImageShareAction) Do(input interface{}) (interface{}, error) {
+	images, _ := input.(ImageInputs)
+	outputs := ImageOutputs{}
+	for _, image := range images.Inputs {
+		output, err := action.shareImage(&image)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//--------------terminate image--------------------//
+type ImageTerminateAction struct {
+	ImageAction
+}
+
+func (action *ImageTerminateAction) CheckParam(input interface{}) error {
+	return checkImageInputs(input, "terminate")
+}
+
+func (action *ImageTerminateAction) terminateImage(input *ImageInput) (*ImageOutput, error) {
+	client, err := createImageClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := LockResources("image/terminate", input.Id)
+	defer unlock()
+
+	output := ImageOutput{}
+	output.Guid = input.Guid
+	output.Id = input.Id
+
+	//images being created or copied can't be deleted
+	if _, err = waitImageNormal(client, input.Id, "", IMAGE_WAIT_TIMEOUT); err != nil {
+		if err == IMAGE_NOT_FOUND_ERROR {
+			logrus.Infof("image(%s) has been deleted", input.Id)
+			return &output, nil
+		}
+		return nil, err
+	}
+
+	request := cvm.NewDeleteImagesRequest()
+	request.ImageIds = []*string{&input.Id}
+	response, err := client.DeleteImages(request)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to DeleteImages(imageId=%v), error=%s", input.Id, err)
+	}
+	output.RequestId = *response.Response.RequestId
+
+	return &output, nil
+}
+
+func (action *ImageTerminateAction) Do(input interface{}) (interface{}, error) {
+	images, _ := input.(ImageInputs)
+	outputs := ImageOutputs{}
+	for _, image := range images.Inputs {
+		output, err := action.terminateImage(&image)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//--------------query images--------------------//
+type ImageQueryAction struct {
+	ImageAction
+}
+
+func (action *ImageQueryAction) CheckParam(input interface{}) error {
+	return checkImageInputs(input, "query")
+}
+
+//buildImageQueryFilters returns the filters of DescribeImages, id can't be used together with the other filters
+func buildImageQueryFilters(input *ImageInput) ([]string, []*cvm.Filter, error) {
+	imageIds := splitAndTrim(input.Id, ",")
+	filters := []*cvm.Filter{}
+	if input.Name != "" {
+		filters = append(filters, &cvm.Filter{Name: common.StringPtr("image-name"), Values: common.StringPtrs([]string{input.Name})})
+	}
+	if input.ImageType != "" {
+		filters = append(filters, &cvm.Filter{Name: common.StringPtr("image-type"), Values: common.StringPtrs([]string{input.ImageType})})
+	}
+	if len(imageIds) > 0 && len(filters) > 0 {
+		return nil, nil, fmt.Errorf("id can't be used together with name and image_type")
+	}
+	return imageIds, filters, nil
+}
+
+func (action *ImageQueryAction) queryImages(input *ImageInput) (*ImageOutput, error) {
+	imageIds, filters, err := buildImageQueryFilters(input)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := createImageClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := describeImages(client, imageIds, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	output := ImageOutput{}
+	output.Guid = input.Guid
+	output.Images = []ImageDetail{}
+	for _, image := range images {
+		output.Images = append(output.Images, buildImageDetail(image))
+	}
+
+	return &output, nil
+}
+
+func (action *ImageQueryAction) Do(input interface{}) (interface{}, error) {
+	images, _ := input.(ImageInputs)
+	outputs := ImageOutputs{}
+	for _, image := range images.Inputs {
+		output, err := action.queryImages(&image)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
@@ -0,0 +1,61 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func TestUnitFindNewImage(t *testing.T) {
+	images := []*cvm.Image{
+		{ImageId: common.StringPtr("img-1"), ImageName: common.StringPtr("golden")},
+		{ImageId: common.StringPtr("img-2"), ImageName: common.StringPtr("golden")},
+	}
+	existingImageIds := getImageIdSet(images[:1])
+	if image := findNewImage(images, existingImageIds); image == nil || *image.ImageId != "img-2" {
+		t.Errorf("new image=%v not right", image)
+	}
+	if image := findNewImage(images[:1], existingImageIds); image != nil {
+		t.Errorf("existing image(%s) should be skipped", *image.ImageId)
+	}
+}
+
+func TestUnitBuildImageQueryFilters(t *testing.T) {
+	imageIds, filters, err := buildImageQueryFilters(&ImageInput{Id: "img-1, img-2"})
+	if err != nil || len(imageIds) != 2 || len(filters) != 0 {
+		t.Errorf("image ids=%v, filters=%d, err=%v", imageIds, len(filters), err)
+	}
+
+	_, filters, err = buildImageQueryFilters(&ImageInput{Name: "golden", ImageType: "PRIVATE_IMAGE"})
+	if err != nil || len(filters) != 2 || *filters[0].Name != "image-name" || *filters[1].Name != "image-type" {
+		t.Errorf("filters=%v, err=%v", filters, err)
+	}
+
+	if _, _, err = buildImageQueryFilters(&ImageInput{Id: "img-1", Name: "golden"}); err == nil {
+		t.Errorf("id with name should be an error")
+	}
+}
+
+func TestUnitBuildImageDetail(t *testing.T) {
+	detail := buildImageDetail(&cvm.Image{
+		ImageId:    common.StringPtr("img-1"),
+		ImageName:  common.StringPtr("golden"),
+		ImageState: common.StringPtr(IMAGE_STATE_NORMAL),
+		ImageSize:  common.Int64Ptr(50),
+	})
+	if detail.Id != "img-1" || detail.Name != "golden" || detail.ImageState != IMAGE_STATE_NORMAL || detail.ImageSize != 50 || detail.OsName != "" {
+		t.Errorf("image detail=%+v not right", detail)
+	}
+}
+
+func TestUnitImageInputValidate(t *testing.T) {
+	inputs := ImageInputs{Inputs: []ImageInput{{ProviderParams: "Region=ap-guangzhou;AvailableZone=ap-guangzhou-4;SecretID=id;SecretKey=key", Id: "img-1"}}}
+	if err := ValidateInputs(inputs, "copy"); err == nil {
+		t.Errorf("copy without destination_regions should be an error")
+	}
+	inputs.Inputs[0].DestinationRegions = "ap-shanghai"
+	if err := ValidateInputs(inputs, "copy"); err != nil {
+		t.Errorf("copy input meet error=%v", err)
+	}
+}
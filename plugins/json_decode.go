@@ -0,0 +1,223 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+//top level fields of the request body consumed by the server itself rather than by actions
+var reservedRequestFields = map[string]bool{
+	"callback_url": true,
+	"strict":       true,
+}
+
+//strictJsonReader marks the parameters of a request decoded in strict mode
+type strictJsonReader struct {
+	io.Reader
+}
+
+//NewStrictJsonReader makes UnmarshalJson reject fields unknown to the target struct
+func NewStrictJsonReader(reader io.Reader) io.Reader {
+	return &strictJsonReader{Reader: reader}
+}
+
+//decodeJson unmarshals data into target, numbers and strings are converted to the type of the target field
+//so "10" fits an int64 field and 10 fits a string field. unknown fields are errors in strict mode
+func decodeJson(data []byte, target interface{}, strict bool) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	errs := ValidationErrors{}
+	raw = normalizeJsonValue(raw, reflect.TypeOf(target), "", strict, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(normalized, target); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			return ValidationErrors{{Path: typeErr.Field, Message: fmt.Sprintf("json %s can't be decoded into %v", typeErr.Value, typeErr.Type)}}
+		}
+		return err
+	}
+	return nil
+}
+
+func normalizeJsonValue(value interface{}, valueType reflect.Type, path string, strict bool, errs *ValidationErrors) interface{} {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if value == nil {
+		return nil
+	}
+
+	switch valueType.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		fields := getJsonFieldTypes(valueType)
+		for _, key := range sortedKeys(object) {
+			fieldPath := joinFieldPath(path, key)
+			fieldType, found := lookupJsonField(fields, key)
+			if !found {
+				if path == "" && reservedRequestFields[key] {
+					continue
+				}
+				if strict {
+					*errs = append(*errs, FieldError{Path: fieldPath, Message: "is an unknown field"})
+				} else {
+					logrus.Warnf("unknown field %s is ignored", fieldPath)
+				}
+				continue
+			}
+			object[key] = normalizeJsonValue(object[key], fieldType, fieldPath, strict, errs)
+		}
+		return object
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		for _, key := range sortedKeys(object) {
+			object[key] = normalizeJsonValue(object[key], valueType.Elem(), joinFieldPath(path, key), strict, errs)
+		}
+		return object
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		for i := range items {
+			items[i] = normalizeJsonValue(items[i], valueType.Elem(), fmt.Sprintf("%s[%d]", path, i), strict, errs)
+		}
+		return items
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return coerceJsonNumber(value, valueType, path, errs, func(s string) error {
+			_, err := strconv.ParseInt(s, 10, valueType.Bits())
+			return err
+		})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return coerceJsonNumber(value, valueType, path, errs, func(s string) error {
+			_, err := strconv.ParseUint(s, 10, valueType.Bits())
+			return err
+		})
+	case reflect.Float32, reflect.Float64:
+		return coerceJsonNumber(value, valueType, path, errs, func(s string) error {
+			_, err := strconv.ParseFloat(s, valueType.Bits())
+			return err
+		})
+	case reflect.Bool:
+		if str, ok := value.(string); ok {
+			str = strings.TrimSpace(str)
+			if str == "" {
+				return false
+			}
+			b, err := strconv.ParseBool(str)
+			if err != nil {
+				*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf("value(%q) is not a valid bool", str)})
+				return value
+			}
+			return b
+		}
+	case reflect.String:
+		switch v := value.(type) {
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	}
+	return value
+}
+
+//coerceJsonNumber accepts a json number or a numeric string, an empty string means zero
+func coerceJsonNumber(value interface{}, valueType reflect.Type, path string, errs *ValidationErrors, parse func(string) error) interface{} {
+	var str string
+	switch v := value.(type) {
+	case json.Number:
+		str = v.String()
+	case string:
+		str = strings.TrimSpace(v)
+		if str == "" {
+			return json.Number("0")
+		}
+	default:
+		return value
+	}
+
+	if err := parse(str); err != nil {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf("value(%s) is not a valid %v", str, valueType.Kind())})
+		return value
+	}
+	return json.Number(str)
+}
+
+//getJsonFieldTypes maps json names to field types, fields of embedded structs are promoted like encoding/json does
+func getJsonFieldTypes(structType reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && strings.Split(jsonTag, ",")[0] == "" && fieldType.Kind() == reflect.Struct {
+			for name, promotedType := range getJsonFieldTypes(fieldType) {
+				if _, found := fields[name]; !found {
+					fields[name] = promotedType
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		fields[getJsonFieldName(field)] = field.Type
+	}
+	return fields
+}
+
+//lookupJsonField prefers an exact match and falls back to the case-insensitive match of encoding/json
+func lookupJsonField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if fieldType, found := fields[key]; found {
+		return fieldType, true
+	}
+	for name, fieldType := range fields {
+		if strings.EqualFold(name, key) {
+			return fieldType, true
+		}
+	}
+	return nil, false
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
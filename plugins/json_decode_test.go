@@ -0,0 +1,30 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnitUnmarshalJsonCoercesNumbers(t *testing.T) {
+	body := `{"callback_url":"http://127.0.0.1/cb","inputs":[{"disk_size":"50","disk_charge_period":12,"instance_chargetype":"PREPAID"}]}`
+	var inputs StorageInputs
+	if err := UnmarshalJson(strings.NewReader(body), &inputs); err != nil {
+		t.Fatalf("non strict decoding meet err=%v", err)
+	}
+	if inputs.Inputs[0].DiskSize != 50 || inputs.Inputs[0].DiskChargePeriod != "12" {
+		t.Errorf("numbers are not coerced, inputs=%+v", inputs)
+	}
+}
+
+func TestUnitUnmarshalJsonStrict(t *testing.T) {
+	body := `{"callback_url":"http://127.0.0.1/cb","strict":true,"inputs":[{"disk_size":"50"},{"disk_size":"-1","instance_chargetype":"PREPAID"}]}`
+	var inputs StorageInputs
+	err := UnmarshalJson(NewStrictJsonReader(strings.NewReader(body)), &inputs)
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("strict decoding should report 2 errors, err=%v", err)
+	}
+	if errs[0].Path != "inputs[1].disk_size" || errs[1].Path != "inputs[1].instance_chargetype" {
+		t.Errorf("unexpected error paths, err=%v", err)
+	}
+}
@@ -0,0 +1,371 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+var KeyPairActions = make(map[string]Action)
+
+func init() {
+	KeyPairActions["create"] = new(KeyPairCreateAction)
+	KeyPairActions["import"] = new(KeyPairImportAction)
+	KeyPairActions["terminate"] = new(KeyPairTerminateAction)
+	KeyPairActions["bind"] = new(KeyPairBindAction)
+	KeyPairActions["unbind"] = new(KeyPairUnbindAction)
+}
+
+type KeyPairInputs struct {
+	Inputs []KeyPairInput `json:"inputs,omitempty"`
+}
+
+type KeyPairInput struct {
+	Guid           string `json:"guid,omitempty"`
+	Seed           string `json:"seed,omitempty" validate:"required=create"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Id             string `json:"id,omitempty" validate:"required=terminate|bind|unbind"`
+	Name           string `json:"name,omitempty" validate:"required=create|import"`
+	ProjectId      int64  `json:"project_id,omitempty"`
+	PublicKey      string `json:"public_key,omitempty" validate:"required=import"`
+	//instance ids separated by comma
+	InstanceIds string `json:"instance_ids,omitempty" validate:"required=bind|unbind"`
+	//running instances are stopped by force when binding or unbinding keys
+	ForceStop bool `json:"force_stop,omitempty"`
+}
+
+type KeyPairOutputs struct {
+	Outputs []KeyPairOutput `json:"outputs,omitempty"`
+}
+
+type KeyPairOutput struct {
+	Guid      string `json:"guid,omitempty"`
+	RequestId string `json:"request_id,omitempty"`
+	Id        string `json:"id,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+	//private key is only returned by create, it's encrypted with guid and seed like the vm password
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+type KeyPairPlugin struct {
+}
+
+func (plugin *KeyPairPlugin) GetActionByName(actionName string) (Action, error) {
+	action, found := KeyPairActions[actionName]
+	if !found {
+		return nil, fmt.Errorf("key pair plugin,action = %s not found", actionName)
+	}
+	return action, nil
+}
+
+type KeyPairAction struct {
+}
+
+func (action *KeyPairAction) ReadParam(param interface{}) (interface{}, error) {
+	var inputs KeyPairInputs
+	err := UnmarshalJson(param, &inputs)
+	if err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+func checkKeyPairInputs(input interface{}, scene string) error {
+	keyPairs, ok := input.(KeyPairInputs)
+	if !ok {
+		return fmt.Errorf("KeyPair%sAction:input type=%T not right", strings.Title(scene), input)
+	}
+
+	return ValidateInputs(keyPairs, scene)
+}
+
+func createKeyPairClient(providerParams string) (*cvm.Client, error) {
+	paramsMap, err := GetMapFromProviderParams(providerParams)
+	if err != nil {
+		return nil, err
+	}
+	return createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+}
+
+func queryKeyPairInfo(client *cvm.Client, input *KeyPairInput) (*KeyPairOutput, bool, error) {
+	request := cvm.NewDescribeKeyPairsRequest()
+	request.KeyIds = []*string{&input.Id}
+	response, err := client.DescribeKeyPairs(request)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(response.Response.KeyPairSet) == 0 {
+		return nil, false, nil
+	}
+
+	output := KeyPairOutput{}
+	output.Guid = input.Guid
+	output.Id = input.Id
+	output.RequestId = *response.Response.RequestId
+	if response.Response.KeyPairSet[0].PublicKey != nil {
+		output.PublicKey = *response.Response.KeyPairSet[0].PublicKey
+	}
+
+	return &output, true, nil
+}
+
+//--------------create key pair--------------------//
+type KeyPairCreateAction struct {
+	KeyPairAction
+}
+
+func (action *KeyPairCreateAction) CheckParam(input interface{}) error {
+	return checkKeyPairInputs(input, "create")
+}
+
+func (action *KeyPairCreateAction) createKeyPair(input *KeyPairInput) (*KeyPairOutput, error) {
+	client, err := createKeyPairClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	//check resource exist
+	if input.Id != "" {
+		queryKeyPairResponse, flag, err := queryKeyPairInfo(client, input)
+		if err != nil && flag == false {
+			return nil, err
+		}
+
+		if err == nil && flag == true {
+			return queryKeyPairResponse, nil
+		}
+	}
+
+	request := cvm.NewCreateKeyPairRequest()
+	request.KeyName = &input.Name
+	request.ProjectId = &input.ProjectId
+	response, err := client.CreateKeyPair(request)
+	if err != nil {
+		logrus.Errorf("cvm CreateKeyPair meet error=%v", err)
+		return nil, err
+	}
+
+	output := KeyPairOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.Id = *response.Response.KeyPair.KeyId
+	output.PublicKey = *response.Response.KeyPair.PublicKey
+	if output.PrivateKey, err = encryptSecret(input.Guid, input.Seed, *response.Response.KeyPair.PrivateKey); err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+func (action *KeyPairCreateAction) Do(input interface{}) (interface{}, error) {
+	keyPairs, _ := input.(KeyPairInputs)
+	outputs := KeyPairOutputs{}
+	for _, keyPair := range keyPairs.Inputs {
+		output, err := action.createKeyPair(&keyPair)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	logrus.Infof("all key pairs = %v are created", keyPairs)
+	return &outputs, nil
+}
+
+//--------------import key pair--------------------//
+type KeyPairImportAction struct {
+	KeyPairAction
+}
+
+func (action *KeyPairImportAction) CheckParam(input interface{}) error {
+	return checkKeyPairInputs(input, "import")
+}
+
+func (action *KeyPairImportAction) importKeyPair(input *KeyPairInput) (*KeyPairOutput, error) {
+	client, err := createKeyPairClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	//check resource exist
+	if input.Id != "" {
+		queryKeyPairResponse, flag, err := queryKeyPairInfo(client, input)
+		if err != nil && flag == false {
+			return nil, err
+		}
+
+		if err == nil && flag == true {
+			return queryKeyPairResponse, nil
+		}
+	}
+
+	request := cvm.NewImportKeyPairRequest()
+	request.KeyName = &input.Name
+	request.ProjectId = &input.ProjectId
+	request.PublicKey = &input.PublicKey
+	response, err := client.ImportKeyPair(request)
+	if err != nil {
+		logrus.Errorf("cvm ImportKeyPair meet error=%v", err)
+		return nil, err
+	}
+
+	output := KeyPairOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.Id = *response.Response.KeyId
+	output.PublicKey = input.PublicKey
+
+	return &output, nil
+}
+
+func (action *KeyPairImportAction) Do(input interface{}) (interface{}, error) {
+	keyPairs, _ := input.(KeyPairInputs)
+	outputs := KeyPairOutputs{}
+	for _, keyPair := range keyPairs.Inputs {
+		output, err := action.importKeyPair(&keyPair)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	logrus.Infof("all key pairs = %v are imported", keyPairs)
+	return &outputs, nil
+}
+
+//--------------terminate key pair--------------------//
+type KeyPairTerminateAction struct {
+	KeyPairAction
+}
+
+func (action *KeyPairTerminateAction) CheckParam(input interface{}) error {
+	return checkKeyPairInputs(input, "terminate")
+}
+
+func (action *KeyPairTerminateAction) terminateKeyPair(input *KeyPairInput) (*KeyPairOutput, error) {
+	client, err := createKeyPairClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	request := cvm.NewDeleteKeyPairsRequest()
+	request.KeyIds = []*string{&input.Id}
+	response, err := client.DeleteKeyPairs(request)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to DeleteKeyPairs(keyId=%v), error=%s", input.Id, err)
+	}
+
+	output := KeyPairOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.Id = input.Id
+
+	return &output, nil
+}
+
+func (action *KeyPairTerminateAction) Do(input interface{}) (interface{}, error) {
+	keyPairs, _ := input.(KeyPairInputs)
+	outputs := KeyPairOutputs{}
+	for _, keyPair := range keyPairs.Inputs {
+		output, err := action.terminateKeyPair(&keyPair)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//--------------bind key pair to instances--------------------//
+type KeyPairBindAction struct {
+	KeyPairAction
+}
+
+func (action *KeyPairBindAction) CheckParam(input interface{}) error {
+	return checkKeyPairInputs(input, "bind")
+}
+
+func (action *KeyPairBindAction) Do(input interface{}) (interface{}, error) {
+	keyPairs, _ := input.(KeyPairInputs)
+	outputs := KeyPairOutputs{}
+	for _, keyPair := range keyPairs.Inputs {
+		output, err := bindOrUnbindKeyPair(&keyPair, true)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//--------------unbind key pair from instances--------------------//
+type KeyPairUnbindAction struct {
+	KeyPairAction
+}
+
+func (action *KeyPairUnbindAction) CheckParam(input interface{}) error {
+	return checkKeyPairInputs(input, "unbind")
+}
+
+func (action *KeyPairUnbindAction) Do(input interface{}) (interface{}, error) {
+	keyPairs, _ := input.(KeyPairInputs)
+	outputs := KeyPairOutputs{}
+	for _, keyPair := range keyPairs.Inputs {
+		output, err := bindOrUnbindKeyPair(&keyPair, false)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+func bindOrUnbindKeyPair(input *KeyPairInput, bind bool) (*KeyPairOutput, error) {
+	instanceIds := splitAndTrim(input.InstanceIds, ",")
+	unlock := LockResources("key-pair/bind", instanceIds...)
+	defer unlock()
+
+	client, err := createKeyPairClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	requestId := ""
+	if bind {
+		request := cvm.NewAssociateInstancesKeyPairsRequest()
+		request.InstanceIds = common.StringPtrs(instanceIds)
+		request.KeyIds = []*string{&input.Id}
+		request.ForceStop = &input.ForceStop
+		response, err := client.AssociateInstancesKeyPairs(request)
+		if err != nil {
+			logrus.Errorf("cvm AssociateInstancesKeyPairs(keyId=%s, instanceIds=%v) meet error=%v", input.Id, instanceIds, err)
+			return nil, err
+		}
+		requestId = *response.Response.RequestId
+	} else {
+		request := cvm.NewDisassociateInstancesKeyPairsRequest()
+		request.InstanceIds = common.StringPtrs(instanceIds)
+		request.KeyIds = []*string{&input.Id}
+		request.ForceStop = &input.ForceStop
+		response, err := client.DisassociateInstancesKeyPairs(request)
+		if err != nil {
+			logrus.Errorf("cvm DisassociateInstancesKeyPairs(keyId=%s, instanceIds=%v) meet error=%v", input.Id, instanceIds, err)
+			return nil, err
+		}
+		requestId = *response.Response.RequestId
+	}
+
+	output := KeyPairOutput{}
+	output.Guid = input.Guid
+	output.RequestId = requestId
+	output.Id = input.Id
+
+	return &output, nil
+}
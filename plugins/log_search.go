@@ -3,7 +3,6 @@ package plugins
 import (
 	"bufio"
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
 	"os/exec"
@@ -48,7 +47,7 @@ type SearchInputs struct {
 //SearchInput .
 type SearchInput struct {
 	Guid       string `json:"guid,omitempty"`
-	KeyWord    string `json:"key_word,omitempty"`
+	KeyWord    string `json:"key_word,omitempty" validate:"required"`
 	LineNumber int    `json:"line_number,omitempty"`
 }
 
@@ -81,13 +80,7 @@ func (action *LogSearchAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("LogSearchAction:input type=%T not right", input)
 	}
 
-	for _, log := range logs.Inputs {
-		if log.KeyWord == "" {
-			return errors.New("LogSearchAction input KeyWord can not be empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(logs, "")
 }
 
 //Do .
@@ -212,8 +205,8 @@ type SearchDetailInputs struct {
 
 //SearchDetailInput .
 type SearchDetailInput struct {
-	FileName        string `json:"file_name,omitempty"`
-	LineNumber      string `json:"line_number,omitempty"`
+	FileName        string `json:"file_name,omitempty" validate:"required"`
+	LineNumber      string `json:"line_number,omitempty" validate:"required,range=1-2147483647"`
 	RelateLineCount int    `json:"relate_line_count,omitempty"`
 }
 
@@ -246,16 +239,7 @@ func (action *LogSearchDetailAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("LogSearchDetailAction:input type=%T not right", input)
 	}
 
-	for _, log := range logs.Inputs {
-		if log.FileName == "" {
-			return errors.New("LogSearchDetailAction input finename can not be empty")
-		}
-		if log.LineNumber == "" {
-			return errors.New("LogSearchDetailAction input LineNumber can not be empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(logs, "")
 }
 
 //Do .
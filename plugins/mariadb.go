@@ -42,24 +42,33 @@ type MariadbInputs struct {
 }
 
 type MariadbInput struct {
-	Guid           string `json:"guid,omitempty"`
-	Seed           string `json:"seed,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
+	Guid           string `json:"guid,omitempty" validate:"required"`
+	Seed           string `json:"seed,omitempty" validate:"required"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
 	UserName       string `json:"user_name,omitempty"`
 
 	Id           string `json:"id,omitempty"`
-	Zones        string `json:"zones,omitempty"` //split by ,
+	Zones        string `json:"zones,omitempty" validate:"required"` //split by ,
 	NodeCount    int64  `json:"node_count,omitempty"`
-	MemorySize   int64  `json:"memory_size,omitempty"`
-	StorageSize  int64  `json:"storage_size,omitempty"`
-	VpcId        string `json:"vpc_id,omitempty"`
-	SubnetId     string `json:"subnet_id,omitempty"`
+	MemorySize   int64  `json:"memory_size,omitempty" validate:"required"`
+	StorageSize  int64  `json:"storage_size,omitempty" validate:"required"`
+	VpcId        string `json:"vpc_id,omitempty" validate:"required"`
+	SubnetId     string `json:"subnet_id,omitempty" validate:"required"`
 	ChargePeriod int64  `json:"charge_period,omitempty"`
 	DbVersion    string `json:"db_version,omitempty"`
 
 	//初始化时使用
 	CharacterSet        string `json:"character_set,omitempty"`
 	LowerCaseTableNames string `json:"lower_case_table_names,omitempty"`
+
+	//id of the task running the request, it's set by Process for RecordTaskResources
+	TaskId string `json:"-"`
+}
+
+func (inputs MariadbInputs) setTaskId(taskId string) {
+	for i := range inputs.Inputs {
+		inputs.Inputs[i].TaskId = taskId
+	}
 }
 
 type MariadbOutputs struct {
@@ -107,40 +116,7 @@ func (action *MariadbCreateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("MariadbCreateAction:input type=%T not right", input)
 	}
 
-	for _, input := range req.Inputs {
-		if input.Guid == "" {
-			return errors.New("guid is empty")
-		}
-
-		if input.Seed == "" {
-			return errors.New("seed is empty")
-		}
-
-		if input.ProviderParams == "" {
-			return errors.New("providerParams is empty")
-		}
-
-		if input.MemorySize == 0 {
-			return errors.New("memory size is empty")
-		}
-
-		if input.StorageSize == 0 {
-			return errors.New("storage size is empty")
-		}
-
-		if input.VpcId == "" {
-			return errors.New("vpcId is empty")
-		}
-
-		if input.SubnetId == "" {
-			return errors.New("subnetId is empty")
-		}
-		if input.Zones == "" {
-			return errors.New("zones is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(req, "")
 }
 
 func (action *MariadbCreateAction) Do(input interface{}) (interface{}, error) {
@@ -179,12 +155,13 @@ func isValidMariadbVersion(version string) error {
 }
 
 func CreateMariadbClient(region, secretId, secretKey string) (client *mariadb.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "mariadb.tencentcloudapi.com"
-
-	return mariadb.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("mariadb", "mariadb.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return mariadb.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*mariadb.Client), nil
 }
 
 func getInstanceIdByDealName(client *mariadb.Client, dealName string) (string, error) {
@@ -241,6 +218,7 @@ func createMariadbInstance(client *mariadb.Client, input *MariadbInput) (string,
 		logrus.Errorf("getInstanceIdByDealName(%s) meet error(%v)", *resp.Response.DealName, err)
 		return "", "", err
 	}
+	RecordTaskResources(input.TaskId, input.Guid, instanceId)
 
 	return *resp.Response.RequestId, instanceId, nil
 }
@@ -476,7 +454,6 @@ func (action *MariadbCreateAction) createAndInitMariadb(input *MariadbInput) (Ma
 func QueryMariadbInstance(providerParams string, filter Filter) ([]*mariadb.DBInstance, error) {
 	validFilterNames := []string{"instanceId", "vip"}
 	filterValues := common.StringPtrs(filter.Values)
-	var offset, limit int64 = 0, int64(len(filterValues))
 	logrus.Infof("QueryMariadbInstance providerParams:%v, filter:%++v", providerParams, filter)
 	paramsMap, err := GetMapFromProviderParams(providerParams)
 	if err != nil {
@@ -491,25 +468,34 @@ func QueryMariadbInstance(providerParams string, filter Filter) ([]*mariadb.DBIn
 		return nil, err
 	}
 
-	request := mariadb.NewDescribeDBInstancesRequest()
-	request.Limit = &limit
-	request.Offset = &offset
-	if filter.Name == "instanceId" {
-		request.InstanceIds = filterValues
-	}
-	if filter.Name == "vip" {
-		request.SearchName = &filter.Name
-		searchKey := strings.Join(filter.Values, "\n")
-		request.SearchKey = &searchKey
-	}
+	instances := []*mariadb.DBInstance{}
+	err = PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := mariadb.NewDescribeDBInstancesRequest()
+		request.Offset = common.Int64Ptr(int64(offset))
+		request.Limit = common.Int64Ptr(int64(limit))
+		if filter.Name == "instanceId" {
+			request.InstanceIds = filterValues
+		}
+		if filter.Name == "vip" {
+			request.SearchName = &filter.Name
+			searchKey := strings.Join(filter.Values, "\n")
+			request.SearchKey = &searchKey
+		}
 
-	response, err := client.DescribeDBInstances(request)
+		response, err := client.DescribeDBInstances(request)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		instances = append(instances, response.Response.Instances...)
+		return len(response.Response.Instances), int(*response.Response.TotalCount), nil
+	})
 	if err != nil {
 		logrus.Errorf("mariadb DescribeDBInstances meet err=%v", err)
 		return nil, err
 	}
 
-	return response.Response.Instances, nil
+	return instances, nil
 }
 
 func QueryMariadbInstanceSecurityGroups(providerParams string, instanceId string) ([]string, error) {
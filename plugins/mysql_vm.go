@@ -25,16 +25,14 @@ func init() {
 }
 
 func CreateMysqlVmClient(region, secretId, secretKey string) (client *cdb.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "cdb.tencentcloudapi.com"
-	client, err = cdb.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("cdb", "cdb.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return cdb.NewClient(credential, region, clientProfile)
+	})
 	if err != nil {
 		logrus.Errorf("CreateMysqlVmClient meet error=%v", err)
+		return nil, err
 	}
-
-	return client, err
+	return cachedClient.(*cdb.Client), nil
 }
 
 type MysqlVmInputs struct {
@@ -44,16 +42,16 @@ type MysqlVmInputs struct {
 type MysqlVmInput struct {
 	Guid           string `json:"guid,omitempty"`
 	Seed           string `json:"seed,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
 	EngineVersion  string `json:"engine_version,omitempty"`
 	Memory         int64  `json:"memory,omitempty"`
 	Volume         int64  `json:"volume,omitempty"`
 	VpcId          string `json:"vpc_id,omitempty"`
 	SubnetId       string `json:"subnet_id,omitempty"`
 	Name           string `json:"name,omitempty"`
-	Id             string `json:"id,omitempty"`
+	Id             string `json:"id,omitempty" validate:"required=terminate|restart"`
 	Count          int64  `json:"count,omitempty"`
-	ChargeType     string `json:"charge_type,omitempty"`
+	ChargeType     string `json:"charge_type,omitempty" validate:"enum=PREPAID|POSTPAID"`
 	ChargePeriod   int64  `json:"charge_period,omitempty"`
 
 	//初始化时使用
@@ -103,12 +101,12 @@ func (action *MysqlVmCreateAction) ReadParam(param interface{}) (interface{}, er
 }
 
 func (action *MysqlVmCreateAction) CheckParam(input interface{}) error {
-	_, ok := input.(MysqlVmInputs)
+	mysqlVms, ok := input.(MysqlVmInputs)
 	if !ok {
 		return fmt.Errorf("mysqlVmCreateAtion:input type=%T not right", input)
 	}
 
-	return nil
+	return ValidateInputs(mysqlVms, "create")
 }
 
 func (action *MysqlVmCreateAction) createMysqlVmWithPrepaid(client *cdb.Client, mysqlVmInput *MysqlVmInput) (string, string, error) {
@@ -372,12 +370,7 @@ func (action *MysqlVmTerminateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("mysqlVmTerminateAtion:input type=%T not right", input)
 	}
 
-	for _, mysqlVm := range mysqlVms.Inputs {
-		if mysqlVm.Id == "" {
-			return errors.New("mysqlVmTerminateAtion input mysqlVmId is empty")
-		}
-	}
-	return nil
+	return ValidateInputs(mysqlVms, "terminate")
 }
 
 func (action *MysqlVmTerminateAction) terminateMysqlVm(mysqlVmInput *MysqlVmInput) (*MysqlVmOutput, error) {
@@ -463,12 +456,7 @@ func (action *MysqlVmRestartAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("mysqlVmRestartAtion:input type=%T not right", input)
 	}
 
-	for _, mysqlVm := range mysqlVms.Inputs {
-		if mysqlVm.Id == "" {
-			return errors.New("mysqlVmRestartAtion input mysqlVmId is empty")
-		}
-	}
-	return nil
+	return ValidateInputs(mysqlVms, "restart")
 }
 
 func (action *MysqlVmRestartAction) restartMysqlVm(mysqlVmInput MysqlVmInput) error {
@@ -563,7 +551,6 @@ func QueryMysqlInstance(providerParams string, filter Filter) ([]*cdb.InstanceIn
 	validFilterNames := []string{"instanceId", "vip"}
 	filterValues := common.StringPtrs(filter.Values)
 	emptyInstances := []*cdb.InstanceInfo{}
-	var offset, limit uint64 = 0, uint64(len(filterValues))
 
 	paramsMap, err := GetMapFromProviderParams(providerParams)
 	client, err := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
@@ -574,23 +561,32 @@ func QueryMysqlInstance(providerParams string, filter Filter) ([]*cdb.InstanceIn
 		return emptyInstances, err
 	}
 
-	request := cdb.NewDescribeDBInstancesRequest()
-	request.Limit = &limit
-	request.Offset = &offset
-	if filter.Name == "instanceId" {
-		request.InstanceIds = filterValues
-	}
-	if filter.Name == "vip" {
-		request.Vips = filterValues
-	}
+	instances := []*cdb.InstanceInfo{}
+	err = PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := cdb.NewDescribeDBInstancesRequest()
+		request.Offset = common.Uint64Ptr(uint64(offset))
+		request.Limit = common.Uint64Ptr(uint64(limit))
+		if filter.Name == "instanceId" {
+			request.InstanceIds = filterValues
+		}
+		if filter.Name == "vip" {
+			request.Vips = filterValues
+		}
 
-	response, err := client.DescribeDBInstances(request)
+		response, err := client.DescribeDBInstances(request)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		instances = append(instances, response.Response.Items...)
+		return len(response.Response.Items), int(*response.Response.TotalCount), nil
+	})
 	if err != nil {
 		logrus.Errorf("cdb DescribeDBInstances meet err=%v", err)
 		return emptyInstances, err
 	}
 
-	return response.Response.Items, nil
+	return instances, nil
 }
 
 //-------------query security group by instanceId-----------//
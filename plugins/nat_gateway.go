@@ -1,7 +1,6 @@
 package plugins
 
 import (
-	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
@@ -37,14 +36,14 @@ type NatGatewayInputs struct {
 
 type NatGatewayInput struct {
 	Guid            string `json:"guid,omitempty"`
-	ProviderParams  string `json:"provider_params,omitempty"`
-	Name            string `json:"name,omitempty"`
-	VpcId           string `json:"vpc_id,omitempty"`
+	ProviderParams  string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Name            string `json:"name,omitempty" validate:"required=create"`
+	VpcId           string `json:"vpc_id,omitempty" validate:"required=create"`
 	MaxConcurrent   int    `json:"max_concurrent,omitempty"`
 	BandWidth       int    `json:"bandwidth,omitempty"`
 	AssignedEipSet  string `json:"assigned_eip_set,omitempty"`
 	AutoAllocEipNum int    `json:"auto_alloc_eip_num,omitempty"`
-	Id              string `json:"id,omitempty"`
+	Id              string `json:"id,omitempty" validate:"required=terminate"`
 	Eip             string `json:"eip,omitempty"`
 	EipId           string `json:"eip_id,omitempty"`
 }
@@ -76,16 +75,7 @@ func (action *NatGatewayCreateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("natGatewayCreateAction:input type=%T not right", input)
 	}
 
-	for _, natGateway := range natGateways.Inputs {
-		if natGateway.VpcId == "" {
-			return errors.New("natGatewayCreateAction input vpcId is empty")
-		}
-		if natGateway.Name == "" {
-			return errors.New("natGatewayCreateAction input name is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(natGateways, "create")
 }
 
 func (action *NatGatewayCreateAction) createNatGateway(natGateway *NatGatewayInput) (*NatGatewayOutput, error) {
@@ -195,13 +185,7 @@ func (action *NatGatewayTerminateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("natGatewayTerminateAction:input type=%T not right", input)
 	}
 
-	for _, natGateway := range natGateways.Inputs {
-		if natGateway.Id == "" {
-			return errors.New("natGatewayTerminateAction input natGateway is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(natGateways, "terminate")
 }
 
 func (action *NatGatewayTerminateAction) terminateNatGateway(natGateway *NatGatewayInput) (*NatGatewayOutput, error) {
@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//most Describe apis return at most 100 items in one page
+	DEFAULT_PAGE_SIZE = 100
+	//stop paginating instead of loading a whole account into memory
+	MAX_PAGINATION_ITEMS = 10000
+)
+
+//OffsetPageFunc describes the page at offset, it returns the item count of the page and the total count of all pages.
+//totalCount is negative when the api doesn't return it
+type OffsetPageFunc func(offset int, limit int) (pageItems int, totalCount int, err error)
+
+//Paginator calls the describe function page by page until all the items are fetched,
+//more than MaxItems items is an error rather than a silently truncated result
+type Paginator struct {
+	PageSize int
+	MaxItems int
+}
+
+func NewPaginator() *Paginator {
+	return &Paginator{PageSize: DEFAULT_PAGE_SIZE, MaxItems: MAX_PAGINATION_ITEMS}
+}
+
+//PaginateByOffset paginates the apis with Offset and Limit parameters by the default paginator
+func PaginateByOffset(describePage OffsetPageFunc) error {
+	return NewPaginator().ByOffset(describePage)
+}
+
+func (paginator *Paginator) ByOffset(describePage OffsetPageFunc) error {
+	offset := 0
+	for {
+		pageItems, totalCount, err := describePage(offset, paginator.PageSize)
+		if err != nil {
+			return err
+		}
+		offset += pageItems
+		if err = paginator.checkItems(offset); err != nil {
+			return err
+		}
+
+		if pageItems == 0 || (totalCount >= 0 && offset >= totalCount) || (totalCount < 0 && pageItems < paginator.PageSize) {
+			return nil
+		}
+	}
+}
+
+func (paginator *Paginator) checkItems(items int) error {
+	if paginator.MaxItems > 0 && items > paginator.MaxItems {
+		logrus.Errorf("paginate more than %d items", paginator.MaxItems)
+		return fmt.Errorf("paginate more than %d items, please narrow the query", paginator.MaxItems)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"testing"
+)
+
+func TestUnitPaginateByOffsetFetchesAllPages(t *testing.T) {
+	total := 250
+	items := []int{}
+	requests := 0
+	err := PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		requests++
+		count := 0
+		for i := offset; i < total && count < limit; i++ {
+			items = append(items, i)
+			count++
+		}
+		return count, total, nil
+	})
+	if err != nil {
+		t.Fatalf("PaginateByOffset meet error=%v", err)
+	}
+	if len(items) != total || requests != 3 {
+		t.Errorf("expect %d items in 3 requests, got %d items in %d requests", total, len(items), requests)
+	}
+}
+
+func TestUnitPaginateByOffsetWithoutTotalCount(t *testing.T) {
+	paginator := &Paginator{PageSize: 10, MaxItems: 100}
+	requests := 0
+	err := paginator.ByOffset(func(offset int, limit int) (int, int, error) {
+		requests++
+		if offset >= 25 {
+			return 0, -1, nil
+		}
+		if offset+limit > 25 {
+			return 25 - offset, -1, nil
+		}
+		return limit, -1, nil
+	})
+	if err != nil || requests != 3 {
+		t.Errorf("short page should be the last one, requests=%d err=%v", requests, err)
+	}
+}
+
+func TestUnitPaginateByOffsetCap(t *testing.T) {
+	paginator := &Paginator{PageSize: 100, MaxItems: 150}
+	err := paginator.ByOffset(func(offset int, limit int) (int, int, error) {
+		return limit, 1000, nil
+	})
+	if err == nil {
+		t.Errorf("items more than MaxItems should be an error")
+	}
+}
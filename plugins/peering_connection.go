@@ -33,14 +33,14 @@ type PeeringConnectionInputs struct {
 
 type PeeringConnectionInput struct {
 	Guid               string `json:"guid,omitempty"`
-	ProviderParams     string `json:"provider_params,omitempty"`
-	Name               string `json:"name,omitempty"`
-	PeerProviderParams string `json:"peer_provider_params,omitempty"`
-	VpcId              string `json:"vpc_id,omitempty"`
+	ProviderParams     string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Name               string `json:"name,omitempty" validate:"required=create"`
+	PeerProviderParams string `json:"peer_provider_params,omitempty" validate:"required=terminate,provider_params"`
+	VpcId              string `json:"vpc_id,omitempty" validate:"required=create"`
 	PeerVpcId          string `json:"peer_vpc_id,omitempty"`
 	PeerUin            string `json:"peer_uin,omitempty"`
-	Bandwidth          string `json:"bandwidth,omitempty"`
-	Id                 string `json:"id,omitempty"`
+	Bandwidth          string `json:"bandwidth,omitempty" validate:"range=1-10000"`
+	Id                 string `json:"id,omitempty" validate:"required=terminate"`
 }
 
 type PeeringConnectionOutputs struct {
@@ -80,16 +80,7 @@ func (action *PeeringConnectionCreateAction) CheckParam(input interface{}) error
 		return fmt.Errorf("peeringConnectionCreateAction:input type=%T not right", input)
 	}
 
-	for _, peeringConnection := range peeringConnections.Inputs {
-		if peeringConnection.VpcId == "" {
-			return errors.New("peeringConnectionCreateAction input vpcId is empty")
-		}
-		if peeringConnection.Name == "" {
-			return errors.New("peeringConnectionCreateAction input name is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(peeringConnections, "create")
 }
 
 func (action *PeeringConnectionCreateAction) createPeeringConnectionAtSameRegion(client *vpcExtend.Client, peeringConnection PeeringConnectionInput, paramsMap map[string]string) (string, error) {
@@ -208,19 +199,7 @@ func (action *PeeringConnectionTerminateAction) CheckParam(input interface{}) er
 		return fmt.Errorf("peeringConnectionTerminateAction:input type=%T not right", input)
 	}
 
-	for _, peeringConnection := range peeringConnections.Inputs {
-		if peeringConnection.Id == "" {
-			return errors.New("peeringConnectionTerminateAction input peeringConnection is empty")
-		}
-		if peeringConnection.PeerProviderParams == "" {
-			return errors.New("peeringConnectionTerminateAction input peeringConnection.PeerProviderParams is empty")
-		}
-		if peeringConnection.ProviderParams == "" {
-			return errors.New("peeringConnectionTerminateAction input peeringConnection.ProviderParams is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(peeringConnections, "terminate")
 }
 
 func (action *PeeringConnectionTerminateAction) deletePeeringConnectionAtSameRegion(client *vpcExtend.Client, peeringConnection PeeringConnectionInput) error {
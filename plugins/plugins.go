@@ -2,14 +2,21 @@ package plugins
 
 import (
 	"fmt"
+	"io"
 	"sync"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	DEFAULT_PROVIDER_NAME = "qcloud"
+	DEFAULT_API_VERSION   = "v1"
+)
+
 var (
 	pluginsMutex sync.Mutex
-	plugins      = make(map[string]Plugin)
+	//provider name -> api version -> plugin name -> plugin
+	plugins = make(map[string]map[string]map[string]Plugin)
 )
 
 type Plugin interface {
@@ -22,23 +29,51 @@ type Action interface {
 	Do(param interface{}) (interface{}, error)
 }
 
+//RegisterPlugin registers a plugin of the default provider and api version
 func RegisterPlugin(name string, plugin Plugin) {
+	RegisterProviderPlugin(DEFAULT_PROVIDER_NAME, DEFAULT_API_VERSION, name, plugin)
+}
+
+func RegisterProviderPlugin(providerName string, version string, name string, plugin Plugin) {
 	pluginsMutex.Lock()
 	defer pluginsMutex.Unlock()
 
-	if _, found := plugins[name]; found {
-		logrus.Fatalf("cloud provider %q was registered twice", name)
+	versions, found := plugins[providerName]
+	if !found {
+		versions = make(map[string]map[string]Plugin)
+		plugins[providerName] = versions
+	}
+
+	namedPlugins, found := versions[version]
+	if !found {
+		namedPlugins = make(map[string]Plugin)
+		versions[version] = namedPlugins
+	}
+
+	if _, found := namedPlugins[name]; found {
+		logrus.Fatalf("plugin %q of provider %q version %q was registered twice", name, providerName, version)
 	}
 
-	plugins[name] = plugin
+	namedPlugins[name] = plugin
 }
 
-func getPluginByName(name string) (Plugin, error) {
+func getPlugin(providerName string, version string, name string) (Plugin, error) {
 	pluginsMutex.Lock()
 	defer pluginsMutex.Unlock()
-	plugin, found := plugins[name]
+
+	versions, found := plugins[providerName]
+	if !found {
+		return nil, fmt.Errorf("provider[%s] not supported", providerName)
+	}
+
+	namedPlugins, found := versions[version]
+	if !found {
+		return nil, fmt.Errorf("provider[%s] api version[%s] not supported", providerName, version)
+	}
+
+	plugin, found := namedPlugins[name]
 	if !found {
-		return nil, fmt.Errorf("plugin[%s] not found", name)
+		return nil, fmt.Errorf("plugin[%s] not found in provider[%s] api version[%s]", name, providerName, version)
 	}
 	return plugin, nil
 }
@@ -61,15 +96,22 @@ func init() {
 	RegisterPlugin("route-policy", new(RoutePolicyPlugin))
 	RegisterPlugin("clb", new(ClbPlugin))
 	RegisterPlugin("cbs", new(CbsPlugin))
+	RegisterPlugin("key-pair", new(KeyPairPlugin))
+	RegisterPlugin("image", new(ImagePlugin))
+	RegisterPlugin("snapshot", new(SnapshotPlugin))
+	RegisterPlugin("host", new(HostPlugin))
 
 }
 
 type PluginRequest struct {
+	TaskId       string
 	Version      string
 	ProviderName string
 	Name         string
 	Action       string
 	Parameters   interface{}
+	CallbackUrl  string
+	StrictJson   bool
 }
 
 type PluginResponse struct {
@@ -83,7 +125,7 @@ func Process(pluginRequest *PluginRequest) (*PluginResponse, error) {
 	var err error
 	defer func() {
 		if err != nil {
-			logrus.Errorf("plguin[%v]-action[%v] meet error = %v", pluginRequest.Name, pluginRequest.Action, err)
+			logrus.Errorf("provider[%v]-version[%v]-plguin[%v]-action[%v] meet error = %v", pluginRequest.ProviderName, pluginRequest.Version, pluginRequest.Name, pluginRequest.Action, err)
 			pluginResponse.ResultCode = "1"
 			pluginResponse.ResultMsg = fmt.Sprint(err)
 		} else {
@@ -95,7 +137,7 @@ func Process(pluginRequest *PluginRequest) (*PluginResponse, error) {
 
 	logrus.Infof("plguin[%v]-action[%v] start...", pluginRequest.Name, pluginRequest.Action)
 
-	plugin, err := getPluginByName(pluginRequest.Name)
+	plugin, err := getPlugin(pluginRequest.ProviderName, pluginRequest.Version, pluginRequest.Name)
 	if err != nil {
 		return &pluginResponse, err
 	}
@@ -105,11 +147,19 @@ func Process(pluginRequest *PluginRequest) (*PluginResponse, error) {
 		return &pluginResponse, err
 	}
 
-	logrus.Infof("read parameters from http request = %v", pluginRequest.Parameters)
-	actionParam, err := action.ReadParam(pluginRequest.Parameters)
+	parameters := pluginRequest.Parameters
+	if reader, ok := parameters.(io.Reader); ok && pluginRequest.StrictJson {
+		parameters = NewStrictJsonReader(reader)
+	}
+
+	logrus.Infof("read parameters from http request = %v, strict = %v", pluginRequest.Parameters, pluginRequest.StrictJson)
+	actionParam, err := action.ReadParam(parameters)
 	if err != nil {
 		return &pluginResponse, err
 	}
+	if inputs, ok := actionParam.(taskIdSetter); ok {
+		inputs.setTaskId(pluginRequest.TaskId)
+	}
 
 	logrus.Infof("check parameters = %v", actionParam)
 	if err = action.CheckParam(actionParam); err != nil {
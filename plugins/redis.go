@@ -25,12 +25,13 @@ func init() {
 }
 
 func CreateRedisClient(region, secretId, secretKey string) (client *redis.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "redis.tencentcloudapi.com"
-
-	return redis.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("redis", "redis.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return redis.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*redis.Client), nil
 }
 
 type RedisInputs struct {
@@ -39,13 +40,13 @@ type RedisInputs struct {
 
 type RedisInput struct {
 	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
 	TypeID         uint64 `json:"type_id,omitempty"`
 	MemSize        uint64 `json:"mem_size,omitempty"`
-	GoodsNum       uint64 `json:"goods_num,omitempty"`
+	GoodsNum       uint64 `json:"goods_num,omitempty" validate:"required"`
 	Period         uint64 `json:"period,omitempty"`
-	Password       string `json:"password,omitempty"`
-	BillingMode    int64  `json:"billing_mode,omitempty"`
+	Password       string `json:"password,omitempty" validate:"required"`
+	BillingMode    int64  `json:"billing_mode,omitempty" validate:"enum=0|1"`
 	VpcID          string `json:"vpc_id,omitempty"`
 	SubnetID       string `json:"subnet_id,omitempty"`
 	ID             string `json:"id,omitempty"`
@@ -94,19 +95,7 @@ func (action *RedisCreateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("RedisCreateAction:input type=%T not right", input)
 	}
 
-	for _, redis := range rediss.Inputs {
-		if redis.GoodsNum == 0 {
-			return errors.New("RedisCreateAction input goodsnum is invalid")
-		}
-		if redis.Password == "" {
-			return errors.New("RedisCreateAction input password is empty")
-		}
-		if redis.BillingMode != 0 && redis.BillingMode != 1 {
-			return errors.New("RedisCreateAction input password is invalid")
-		}
-	}
-
-	return nil
+	return ValidateInputs(rediss, "")
 }
 
 func (action *RedisCreateAction) createRedis(redisInput *RedisInput) (*RedisOutput, error) {
@@ -239,12 +228,13 @@ func (action *RedisCreateAction) waitForRedisInstancesCreationToFinish(client *r
 }
 
 func CreateDescribeZonesClient(region, secretId, secretKey string) (client *cvm.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "cvm.tencentcloudapi.com"
-
-	return cvm.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("cvm", "cvm.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return cvm.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*cvm.Client), nil
 }
 
 func GetAvaliableZoneInfo(region, secretid, secretkey string) (map[string]int, error) {
@@ -0,0 +1,301 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FSTAB_FILE     = "/etc/fstab"
+	FSTAB_TMP_FILE = "/etc/fstab.wecube.tmp"
+	LSBLK_COLUMNS  = "NAME,TYPE,FSTYPE,UUID,MOUNTPOINT"
+	DISK_TYPE_DISK = "disk"
+)
+
+var (
+	mkfsCommands = map[string]string{
+		"ext3": "mkfs.ext3 -F",
+		"ext4": "mkfs.ext4 -F",
+		"xfs":  "mkfs.xfs -n ftype=1 -f",
+	}
+
+	//nofail keeps the instance booting when the disk is detached or renamed
+	fstabMountOptions = map[string]string{
+		"ext3": "noatime,acl,user_xattr,nofail",
+		"ext4": "noatime,acl,user_xattr,nofail",
+		"xfs":  "defaults,nofail",
+	}
+)
+
+//LsblkOutput is the output of lsblk -J, the empty columns are null
+type LsblkOutput struct {
+	BlockDevices []BlockDevice `json:"blockdevices"`
+}
+
+type BlockDevice struct {
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	FsType     string        `json:"fstype"`
+	Uuid       string        `json:"uuid"`
+	MountPoint string        `json:"mountpoint"`
+	Children   []BlockDevice `json:"children,omitempty"`
+}
+
+type FstabEntry struct {
+	Source         string
+	MountDir       string
+	FileSystemType string
+	Options        string
+	Dump           int
+	Pass           int
+}
+
+func (entry FstabEntry) String() string {
+	return fmt.Sprintf("%s %s %s %s %d %d", entry.Source, entry.MountDir, entry.FileSystemType, entry.Options, entry.Dump, entry.Pass)
+}
+
+//shellQuote quotes the value as one argument of the remote shell
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func parseLsblkOutput(output string) ([]BlockDevice, error) {
+	lsblkOutput := LsblkOutput{}
+	if err := json.Unmarshal([]byte(output), &lsblkOutput); err != nil {
+		logrus.Errorf("parse lsblk output(%s) meet error=%v", output, err)
+		return nil, err
+	}
+	return lsblkOutput.BlockDevices, nil
+}
+
+//isUnformatedVolume returns true for the disks or logical volumes without file system, partitions and mount point
+func isUnformatedVolume(device BlockDevice) bool {
+	return device.FsType == "" && device.MountPoint == "" && len(device.Children) == 0
+}
+
+func isUnformatedDisk(device BlockDevice) bool {
+	return device.Type == DISK_TYPE_DISK && isUnformatedVolume(device)
+}
+
+//listRemoteBlockDevices lists all the block devices of the host when volumeName is empty
+func listRemoteBlockDevices(ip, password, volumeName string) ([]BlockDevice, error) {
+	cmd := "lsblk -J -p -o " + LSBLK_COLUMNS
+	if volumeName != "" {
+		cmd += " " + shellQuote(volumeName)
+	}
+	output, err := runRemoteHostScript(ip, password, cmd)
+	if err != nil {
+		logrus.Errorf("run lsblk on %s meet error=%v", ip, err)
+		return nil, err
+	}
+	return parseLsblkOutput(output)
+}
+
+func getRemoteBlockDevice(ip, password, volumeName string) (*BlockDevice, error) {
+	devices, err := listRemoteBlockDevices(ip, password, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("block device(%s) not found on %s", volumeName, ip)
+	}
+	return &devices[0], nil
+}
+
+func getUnformatDisks(privateIp string, password string) ([]string, error) {
+	devices, err := listRemoteBlockDevices(privateIp, password, "")
+	if err != nil {
+		return []string{}, err
+	}
+
+	unformatedDisks := []string{}
+	for _, device := range devices {
+		if isUnformatedDisk(device) {
+			unformatedDisks = append(unformatedDisks, device.Name)
+		}
+	}
+	return unformatedDisks, nil
+}
+
+//getRemoteDiskUuid probes the disk directly, the udev database read by lsblk may lag behind mkfs
+func getRemoteDiskUuid(ip, password, volumeName string) (string, error) {
+	output, err := runRemoteHostScript(ip, password, "blkid -p -s UUID -o value "+shellQuote(volumeName))
+	if err != nil {
+		return "", err
+	}
+	uuid := strings.TrimSpace(output)
+	if uuid == "" {
+		return "", fmt.Errorf("block device(%s) has no uuid", volumeName)
+	}
+	return uuid, nil
+}
+
+//parseFstabEntry returns nil for the comments and blank lines
+func parseFstabEntry(line string) *FstabEntry {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+		return nil
+	}
+	entry := FstabEntry{Source: fields[0], MountDir: fields[1]}
+	if len(fields) > 2 {
+		entry.FileSystemType = fields[2]
+	}
+	if len(fields) > 3 {
+		entry.Options = fields[3]
+	}
+	if len(fields) > 4 {
+		entry.Dump, _ = strconv.Atoi(fields[4])
+	}
+	if len(fields) > 5 {
+		entry.Pass, _ = strconv.Atoi(fields[5])
+	}
+	return &entry
+}
+
+//removeFstabEntries removes the entries of mountDir, only the entries of the sources are removed when sources are given
+func removeFstabEntries(content string, mountDir string, sources ...string) string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		entry := parseFstabEntry(line)
+		if entry != nil && strings.TrimRight(entry.MountDir, "/") == strings.TrimRight(mountDir, "/") {
+			matched := len(sources) == 0
+			for _, source := range sources {
+				if source != "" && entry.Source == source {
+					matched = true
+				}
+			}
+			if matched {
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+//addFstabEntry replaces the existing entries of the same mount dir with the new entry
+func addFstabEntry(content string, entry FstabEntry) string {
+	return removeFstabEntries(content, entry.MountDir) + entry.String() + "\n"
+}
+
+func readRemoteFstab(ip, password string) (string, error) {
+	return runRemoteHostScript(ip, password, "cat "+FSTAB_FILE)
+}
+
+//writeRemoteFstab writes a temporary file and renames it, so fstab is never left half written
+func writeRemoteFstab(ip, password, content string) error {
+	client, err := createSftpClient(ip, password)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	file, err := client.Create(FSTAB_TMP_FILE)
+	if err != nil {
+		logrus.Errorf("create %s on %s meet error=%v", FSTAB_TMP_FILE, ip, err)
+		return err
+	}
+	if _, err = file.Write([]byte(content)); err != nil {
+		file.Close()
+		logrus.Errorf("write %s on %s meet error=%v", FSTAB_TMP_FILE, ip, err)
+		return err
+	}
+	file.Close()
+
+	if err = client.PosixRename(FSTAB_TMP_FILE, FSTAB_FILE); err != nil {
+		logrus.Errorf("rename %s to %s on %s meet error=%v", FSTAB_TMP_FILE, FSTAB_FILE, ip, err)
+		return err
+	}
+	return nil
+}
+
+//verifyFstabMount umounts and mounts the dir again by the fstab entry only,
+//so a broken entry is found now rather than at the next reboot
+func verifyFstabMount(ip, password, mountDir, uuid string) error {
+	dir := shellQuote(mountDir)
+	cmd := "umount " + dir + " && mount " + dir + " && findmnt -n -o UUID --mountpoint " + dir
+	output, err := runRemoteHostScript(ip, password, cmd)
+	if err != nil {
+		logrus.Errorf("remount %s on %s meet error=%v", mountDir, ip, err)
+		return err
+	}
+	if mountedUuid := strings.TrimSpace(output); mountedUuid != uuid {
+		return fmt.Errorf("%s is mounted from uuid(%s) rather than uuid(%s) after remount", mountDir, mountedUuid, uuid)
+	}
+	return nil
+}
+
+func formatAndMountDisk(ip, password, volumeName, fileSystemType, mountDir string) error {
+	mkfsCommand, ok := mkfsCommands[fileSystemType]
+	if !ok {
+		return fmt.Errorf("invalid fileSystemType(%s)", fileSystemType)
+	}
+
+	device, err := getRemoteBlockDevice(ip, password, volumeName)
+	if err != nil {
+		return err
+	}
+	if !isUnformatedVolume(*device) {
+		return fmt.Errorf("disk(%s) has been formated", volumeName)
+	}
+
+	cmd := mkfsCommand + " " + shellQuote(volumeName) + " && mkdir -p " + shellQuote(mountDir)
+	if _, err = runRemoteHostScript(ip, password, cmd); err != nil {
+		logrus.Errorf("format disk(%s) on %s meet error=%v", volumeName, ip, err)
+		return err
+	}
+
+	uuid, err := getRemoteDiskUuid(ip, password, volumeName)
+	if err != nil {
+		return err
+	}
+
+	fstab, err := readRemoteFstab(ip, password)
+	if err != nil {
+		return err
+	}
+	entry := FstabEntry{
+		Source:         "UUID=" + uuid,
+		MountDir:       mountDir,
+		FileSystemType: fileSystemType,
+		Options:        fstabMountOptions[fileSystemType],
+		Pass:           2,
+	}
+	if err = writeRemoteFstab(ip, password, addFstabEntry(fstab, entry)); err != nil {
+		return err
+	}
+
+	if _, err = runRemoteHostScript(ip, password, "mount "+shellQuote(mountDir)); err != nil {
+		logrus.Errorf("mount %s on %s meet error=%v", mountDir, ip, err)
+		return err
+	}
+	return verifyFstabMount(ip, password, mountDir, uuid)
+}
+
+//umountDisk removes the fstab entries written by device name before and by uuid now
+func umountDisk(ip, password, volumeName, mountDir string) error {
+	sources := []string{volumeName}
+	if device, err := getRemoteBlockDevice(ip, password, volumeName); err == nil && device.Uuid != "" {
+		sources = append(sources, "UUID="+device.Uuid)
+	}
+
+	dir := shellQuote(mountDir)
+	if _, err := runRemoteHostScript(ip, password, "if mountpoint -q "+dir+"; then umount "+dir+"; fi"); err != nil {
+		logrus.Errorf("umount %s on %s meet error=%v", mountDir, ip, err)
+		return err
+	}
+
+	fstab, err := readRemoteFstab(ip, password)
+	if err != nil {
+		return err
+	}
+	newFstab := removeFstabEntries(fstab, mountDir, sources...)
+	if newFstab == fstab {
+		return nil
+	}
+	return writeRemoteFstab(ip, password, newFstab)
+}
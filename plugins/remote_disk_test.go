@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"testing"
+)
+
+func TestUnitParseLsblkOutput(t *testing.T) {
+	output := `{
+   "blockdevices": [
+      {"name": "/dev/vda", "type": "disk", "fstype": null, "uuid": null, "mountpoint": null,
+         "children": [
+            {"name": "/dev/vda1", "type": "part", "fstype": "ext4", "uuid": "4b499d76-769a-40a0-93dc-4a31a59add28", "mountpoint": "/"}
+         ]
+      },
+      {"name": "/dev/vdb", "type": "disk", "fstype": "xfs", "uuid": "0a1b2c3d-1111-2222-3333-444455556666", "mountpoint": "/data"},
+      {"name": "/dev/vdc", "type": "disk", "fstype": null, "uuid": null, "mountpoint": null},
+      {"name": "/dev/sr0", "type": "rom", "fstype": null, "uuid": null, "mountpoint": null}
+   ]
+}`
+	devices, err := parseLsblkOutput(output)
+	if err != nil {
+		t.Fatalf("parseLsblkOutput meet error=%v", err)
+	}
+	if len(devices) != 4 || len(devices[0].Children) != 1 || devices[1].Uuid != "0a1b2c3d-1111-2222-3333-444455556666" {
+		t.Fatalf("unexpected devices %+v", devices)
+	}
+
+	unformatedDisks := []string{}
+	for _, device := range devices {
+		if isUnformatedDisk(device) {
+			unformatedDisks = append(unformatedDisks, device.Name)
+		}
+	}
+	if len(unformatedDisks) != 1 || unformatedDisks[0] != "/dev/vdc" {
+		t.Errorf("unexpected unformated disks %v", unformatedDisks)
+	}
+
+	if _, err := parseLsblkOutput("lsblk: unknown column"); err == nil {
+		t.Errorf("invalid output should be an error")
+	}
+}
+
+func TestUnitShellQuote(t *testing.T) {
+	cases := map[string]string{
+		"/data":        "'/data'",
+		"/data dir":    "'/data dir'",
+		"/data'; rm -": `'/data'\''; rm -'`,
+	}
+	for value, expected := range cases {
+		if got := shellQuote(value); got != expected {
+			t.Errorf("shellQuote(%s)=%s, expected %s", value, got, expected)
+		}
+	}
+}
+
+func TestUnitAddFstabEntry(t *testing.T) {
+	fstab := "#\n# /etc/fstab\n#\nUUID=4b499d76 / ext4 defaults 1 1\n/dev/vdb        /data        ext4            noatime,acl,user_xattr 1 1\n"
+	entry := FstabEntry{
+		Source:         "UUID=0a1b2c3d",
+		MountDir:       "/data/",
+		FileSystemType: "xfs",
+		Options:        fstabMountOptions["xfs"],
+		Pass:           2,
+	}
+	expected := "#\n# /etc/fstab\n#\nUUID=4b499d76 / ext4 defaults 1 1\nUUID=0a1b2c3d /data/ xfs defaults,nofail 0 2\n"
+	if got := addFstabEntry(fstab, entry); got != expected {
+		t.Errorf("addFstabEntry got\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+func TestUnitRemoveFstabEntries(t *testing.T) {
+	fstab := "UUID=4b499d76 / ext4 defaults 1 1\n/dev/vdb /data ext4 noatime 1 1\nUUID=0a1b2c3d /data xfs defaults,nofail 0 2\nUUID=99999999 /data xfs defaults,nofail 0 2\n"
+
+	expected := "UUID=4b499d76 / ext4 defaults 1 1\nUUID=99999999 /data xfs defaults,nofail 0 2\n"
+	if got := removeFstabEntries(fstab, "/data", "/dev/vdb", "UUID=0a1b2c3d"); got != expected {
+		t.Errorf("removeFstabEntries by sources got\n%s\nexpected\n%s", got, expected)
+	}
+
+	expected = "UUID=4b499d76 / ext4 defaults 1 1\n"
+	if got := removeFstabEntries(fstab, "/data"); got != expected {
+		t.Errorf("removeFstabEntries by mount dir got\n%s\nexpected\n%s", got, expected)
+	}
+
+	if got := removeFstabEntries(fstab, "/backup", "/dev/vdb"); got != fstab {
+		t.Errorf("fstab without the mount dir should not be changed, got\n%s", got)
+	}
+}
@@ -0,0 +1,153 @@
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	VOLUME_LAYOUT_SINGLE      = "single"
+	VOLUME_LAYOUT_LVM_LINEAR  = "lvm-linear"
+	VOLUME_LAYOUT_LVM_STRIPED = "lvm-striped"
+
+	DISK_TYPE_LVM = "lvm"
+	//cvm accepts at most 20 data disks
+	MAX_CBS_DISK_COUNT = 20
+)
+
+var lvmNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+func isLvmVolumeLayout(layout string) bool {
+	return layout == VOLUME_LAYOUT_LVM_LINEAR || layout == VOLUME_LAYOUT_LVM_STRIPED
+}
+
+func checkVolumeLayout(diskCount int64, layout string) error {
+	if diskCount > 1 && !isLvmVolumeLayout(layout) {
+		return fmt.Errorf("volume_layout should be %s or %s when disk_count is %d", VOLUME_LAYOUT_LVM_LINEAR, VOLUME_LAYOUT_LVM_STRIPED, diskCount)
+	}
+	if layout == VOLUME_LAYOUT_LVM_STRIPED && diskCount < 2 {
+		return fmt.Errorf("volume_layout %s needs at least 2 disks", VOLUME_LAYOUT_LVM_STRIPED)
+	}
+	return nil
+}
+
+//getLvmNames names the volume group and logical volume after the mount dir, the volume group has the first disk id
+//since /data/mysql and /data_mysql get the same name, /data/mysql on disk-1 gets vg_data_mysql_disk_1 and lv_data_mysql
+func getLvmNames(mountDir string, diskId string) (string, string) {
+	name := strings.Trim(lvmNameInvalidChars.ReplaceAllString(mountDir, "_"), "_")
+	if name == "" {
+		name = "root"
+	}
+	volumeGroup := "vg_" + name
+	if suffix := strings.Trim(lvmNameInvalidChars.ReplaceAllString(diskId, "_"), "_"); suffix != "" {
+		volumeGroup += "_" + suffix
+	}
+	return volumeGroup, "lv_" + name
+}
+
+func buildLvmCreateCommand(volumes []string, layout, volumeGroup, logicalVolume string) string {
+	quotedVolumes := []string{}
+	for _, volume := range volumes {
+		quotedVolumes = append(quotedVolumes, shellQuote(volume))
+	}
+	pvs := strings.Join(quotedVolumes, " ")
+
+	lvcreate := "lvcreate -y -n " + shellQuote(logicalVolume) + " -l 100%FREE"
+	if layout == VOLUME_LAYOUT_LVM_STRIPED {
+		lvcreate += " -i " + strconv.Itoa(len(volumes))
+	}
+	lvcreate += " " + shellQuote(volumeGroup)
+
+	return "pvcreate -y " + pvs + " && vgcreate " + shellQuote(volumeGroup) + " " + pvs + " && " + lvcreate
+}
+
+//createLvmVolume builds a logical volume on all the volumes and returns the path of it
+func createLvmVolume(ip, password string, volumes []string, layout, mountDir, diskId string) (string, error) {
+	if _, err := runRemoteHostScript(ip, password, "command -v lvcreate"); err != nil {
+		return "", fmt.Errorf("lvm2 is not installed on %s", ip)
+	}
+
+	volumeGroup, logicalVolume := getLvmNames(mountDir, diskId)
+	if _, err := runRemoteHostScript(ip, password, buildLvmCreateCommand(volumes, layout, volumeGroup, logicalVolume)); err != nil {
+		logrus.Errorf("create logical volume %s/%s on %s meet error=%v", volumeGroup, logicalVolume, ip, err)
+		return "", err
+	}
+	return "/dev/" + volumeGroup + "/" + logicalVolume, nil
+}
+
+//parseLvmReport parses the output of lvm reports with --noheadings --separator "|"
+func parseLvmReport(output string) [][]string {
+	rows := [][]string{}
+	for _, line := range splitAndTrim(output, "\n") {
+		fields := strings.Split(line, "|")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		rows = append(rows, fields)
+	}
+	return rows
+}
+
+func getPhysicalVolumesOfGroup(pvsOutput string, volumeGroup string) []string {
+	physicalVolumes := []string{}
+	for _, row := range parseLvmReport(pvsOutput) {
+		if len(row) == 2 && row[1] == volumeGroup {
+			physicalVolumes = append(physicalVolumes, row[0])
+		}
+	}
+	return physicalVolumes
+}
+
+//getLvmPhysicalVolumes returns the volume group of the logical volume and the physical volumes of the group
+func getLvmPhysicalVolumes(ip, password, logicalVolumePath string) (string, []string, error) {
+	output, err := runRemoteHostScript(ip, password, "lvs --noheadings --separator '|' -o vg_name "+shellQuote(logicalVolumePath))
+	if err != nil {
+		logrus.Errorf("lvs %s on %s meet error=%v", logicalVolumePath, ip, err)
+		return "", nil, err
+	}
+	volumeGroup := strings.TrimSpace(output)
+	if volumeGroup == "" {
+		return "", nil, fmt.Errorf("volume group of %s not found", logicalVolumePath)
+	}
+
+	if output, err = runRemoteHostScript(ip, password, "pvs --noheadings --separator '|' -o pv_name,vg_name"); err != nil {
+		logrus.Errorf("pvs on %s meet error=%v", ip, err)
+		return "", nil, err
+	}
+	return volumeGroup, getPhysicalVolumesOfGroup(output, volumeGroup), nil
+}
+
+//buildLvmGrowCommand grows the physical volumes to the size of their disks and the logical volume to the free space of the group,
+//lvextend is skipped when there is no free space left, which is the case when the expand is retried
+func buildLvmGrowCommand(physicalVolumes []string, volumeGroup, logicalVolume string) string {
+	quotedVolumes := []string{}
+	for _, volume := range physicalVolumes {
+		quotedVolumes = append(quotedVolumes, shellQuote(volume))
+	}
+
+	freeCount := "$(vgs --noheadings -o vg_free_count " + shellQuote(volumeGroup) + " | tr -d ' ')"
+	return "pvresize " + strings.Join(quotedVolumes, " ") + " >/dev/null" +
+		" && if [ \"" + freeCount + "\" != 0 ]; then lvextend -l +100%FREE " + shellQuote(logicalVolume) + " >/dev/null; fi"
+}
+
+//removeLvmVolume removes the logical volume with its volume group and physical volumes, the volume should be umounted
+func removeLvmVolume(ip, password, logicalVolumePath string) error {
+	volumeGroup, physicalVolumes, err := getLvmPhysicalVolumes(ip, password, logicalVolumePath)
+	if err != nil {
+		return err
+	}
+
+	cmd := "lvremove -y " + shellQuote(logicalVolumePath) + " && vgremove -y " + shellQuote(volumeGroup)
+	for _, physicalVolume := range physicalVolumes {
+		cmd += " && pvremove -y " + shellQuote(physicalVolume)
+	}
+	if _, err = runRemoteHostScript(ip, password, cmd); err != nil {
+		logrus.Errorf("remove logical volume %s on %s meet error=%v", logicalVolumePath, ip, err)
+		return err
+	}
+	return nil
+}
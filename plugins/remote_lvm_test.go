@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"testing"
+)
+
+func TestUnitCheckVolumeLayout(t *testing.T) {
+	cases := []struct {
+		diskCount int64
+		layout    string
+		valid     bool
+	}{
+		{1, "", true},
+		{1, VOLUME_LAYOUT_SINGLE, true},
+		{1, VOLUME_LAYOUT_LVM_LINEAR, true},
+		{3, VOLUME_LAYOUT_LVM_LINEAR, true},
+		{2, VOLUME_LAYOUT_LVM_STRIPED, true},
+		{2, "", false},
+		{2, VOLUME_LAYOUT_SINGLE, false},
+		{1, VOLUME_LAYOUT_LVM_STRIPED, false},
+	}
+	for _, c := range cases {
+		err := checkVolumeLayout(c.diskCount, c.layout)
+		if c.valid && err != nil {
+			t.Errorf("%d disks in layout(%s) should be valid, err=%v", c.diskCount, c.layout, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%d disks in layout(%s) should be invalid", c.diskCount, c.layout)
+		}
+	}
+}
+
+func TestUnitGetLvmNames(t *testing.T) {
+	cases := map[string][2]string{
+		"/data":          {"vg_data_disk_1", "lv_data"},
+		"/data/mysql/":   {"vg_data_mysql_disk_1", "lv_data_mysql"},
+		"/opt/my-app.db": {"vg_opt_my_app_db_disk_1", "lv_opt_my_app_db"},
+		"/":              {"vg_root_disk_1", "lv_root"},
+	}
+	for mountDir, expected := range cases {
+		volumeGroup, logicalVolume := getLvmNames(mountDir, "disk-1")
+		if volumeGroup != expected[0] || logicalVolume != expected[1] {
+			t.Errorf("getLvmNames(%s)=%s,%s, expected %v", mountDir, volumeGroup, logicalVolume, expected)
+		}
+	}
+
+	//mount dirs with the same name on different disks don't share the volume group
+	first, _ := getLvmNames("/data/mysql", "disk-1")
+	second, _ := getLvmNames("/data_mysql", "disk-2")
+	if first == second {
+		t.Errorf("volume groups of /data/mysql and /data_mysql should be different, both are %s", first)
+	}
+}
+
+func TestUnitBuildLvmCreateCommand(t *testing.T) {
+	volumes := []string{"/dev/vdb", "/dev/vdc"}
+
+	expected := "pvcreate -y '/dev/vdb' '/dev/vdc' && vgcreate 'vg_data' '/dev/vdb' '/dev/vdc' && lvcreate -y -n 'lv_data' -l 100%FREE 'vg_data'"
+	if got := buildLvmCreateCommand(volumes, VOLUME_LAYOUT_LVM_LINEAR, "vg_data", "lv_data"); got != expected {
+		t.Errorf("linear command got %s, expected %s", got, expected)
+	}
+
+	expected = "pvcreate -y '/dev/vdb' '/dev/vdc' && vgcreate 'vg_data' '/dev/vdb' '/dev/vdc' && lvcreate -y -n 'lv_data' -l 100%FREE -i 2 'vg_data'"
+	if got := buildLvmCreateCommand(volumes, VOLUME_LAYOUT_LVM_STRIPED, "vg_data", "lv_data"); got != expected {
+		t.Errorf("striped command got %s, expected %s", got, expected)
+	}
+}
+
+func TestUnitBuildLvmGrowCommand(t *testing.T) {
+	expected := "pvresize '/dev/vdb' '/dev/vdc' >/dev/null" +
+		" && if [ \"$(vgs --noheadings -o vg_free_count 'vg_data' | tr -d ' ')\" != 0 ]; then lvextend -l +100%FREE '/dev/vg_data/lv_data' >/dev/null; fi"
+	if got := buildLvmGrowCommand([]string{"/dev/vdb", "/dev/vdc"}, "vg_data", "/dev/vg_data/lv_data"); got != expected {
+		t.Errorf("grow command got %s, expected %s", got, expected)
+	}
+}
+
+func TestUnitGetPhysicalVolumesOfGroup(t *testing.T) {
+	output := "  /dev/vda2|centos\n  /dev/vdb|vg_data\n  /dev/vdc|vg_data\n  /dev/vdd|\n"
+	physicalVolumes := getPhysicalVolumesOfGroup(output, "vg_data")
+	if len(physicalVolumes) != 2 || physicalVolumes[0] != "/dev/vdb" || physicalVolumes[1] != "/dev/vdc" {
+		t.Errorf("unexpected physical volumes %v", physicalVolumes)
+	}
+	if physicalVolumes = getPhysicalVolumesOfGroup(output, "vg_backup"); len(physicalVolumes) != 0 {
+		t.Errorf("unexpected physical volumes %v", physicalVolumes)
+	}
+}
@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//lock metrics are published by expvar, main serves them on metrics_addr only
+var resourceLockMetrics = expvar.NewMap("resource_locks")
+
+//ResourceLocker serializes the operations which mutate the same cloud resource
+type ResourceLocker struct {
+	mutex sync.Mutex
+	locks map[string]*resourceLock
+}
+
+type resourceLock struct {
+	ch    chan struct{}
+	refs  int
+	owner string
+}
+
+var resourceLocker = NewResourceLocker()
+
+func NewResourceLocker() *ResourceLocker {
+	return &ResourceLocker{locks: make(map[string]*resourceLock)}
+}
+
+//LockResources locks the resources in the default locker for owner, see ResourceLocker.Lock
+func LockResources(owner string, resourceIds ...string) func() {
+	return resourceLocker.Lock(owner, resourceIds...)
+}
+
+//Lock blocks until all the resources are locked and returns the function to unlock them,
+//resources are locked in sorted order so two owners locking the same resources can't deadlock
+func (locker *ResourceLocker) Lock(owner string, resourceIds ...string) func() {
+	ids := uniqueSortedIds(resourceIds)
+	for _, id := range ids {
+		locker.lockOne(owner, id)
+	}
+
+	return func() {
+		for i := len(ids) - 1; i >= 0; i-- {
+			locker.unlockOne(ids[i])
+		}
+	}
+}
+
+func (locker *ResourceLocker) lockOne(owner string, id string) {
+	locker.mutex.Lock()
+	lock, found := locker.locks[id]
+	if !found {
+		lock = &resourceLock{ch: make(chan struct{}, 1)}
+		locker.locks[id] = lock
+	}
+	lock.refs++
+	holder := lock.owner
+	locker.mutex.Unlock()
+
+	startTime := time.Now()
+	select {
+	case lock.ch <- struct{}{}:
+	default:
+		logrus.Infof("%s waits for resource(%s) locked by %s", owner, id, holder)
+		resourceLockMetrics.Add("waits", 1)
+		lock.ch <- struct{}{}
+
+		waitTime := time.Since(startTime)
+		logrus.Infof("%s locked resource(%s) after waiting %v", owner, id, waitTime)
+		resourceLockMetrics.AddFloat("wait_seconds_total", waitTime.Seconds())
+	}
+	resourceLockMetrics.Add("acquisitions", 1)
+	resourceLockMetrics.Add("held", 1)
+
+	locker.mutex.Lock()
+	lock.owner = owner
+	locker.mutex.Unlock()
+}
+
+func (locker *ResourceLocker) unlockOne(id string) {
+	locker.mutex.Lock()
+	defer locker.mutex.Unlock()
+
+	lock, found := locker.locks[id]
+	if !found {
+		logrus.Errorf("unlock resource(%s) which is not locked", id)
+		return
+	}
+	lock.owner = ""
+	<-lock.ch
+	resourceLockMetrics.Add("held", -1)
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(locker.locks, id)
+	}
+}
+
+func uniqueSortedIds(resourceIds []string) []string {
+	ids := []string{}
+	seen := make(map[string]bool)
+	for _, id := range resourceIds {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
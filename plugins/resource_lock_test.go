@@ -0,0 +1,48 @@
+package plugins
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUnitResourceLockerSerializes(t *testing.T) {
+	locker := NewResourceLocker()
+	running := 0
+	maxRunning := 0
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			//owners lock the same resources in different orders
+			ids := []string{"ins-1", "disk-1"}
+			if i%2 == 0 {
+				ids = []string{"disk-1", "ins-1", "ins-1"}
+			}
+			unlock := locker.Lock("test", ids...)
+			defer unlock()
+
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	if maxRunning != 1 {
+		t.Errorf("%d owners held the same resources at once", maxRunning)
+	}
+	if len(locker.locks) != 0 {
+		t.Errorf("locks should be released, locks=%v", locker.locks)
+	}
+}
@@ -1,7 +1,6 @@
 package plugins
 
 import (
-	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
@@ -35,12 +34,12 @@ type CreateRoutePolicyInputs struct {
 
 type CreateRoutePolicyInput struct {
 	Guid            string `json:"guid,omitempty"`
-	Id              string `json:"id,omitempty"`
-	ProviderParams  string `json:"provider_params,omitempty"`
-	RouteTableId    string `json:"route_table_id,omitempty"`
-	DestinationCidr string `json:"dest_cidr,omitempty"`
-	GatewayType     string `json:"gateway_type,omitempty"`
-	GatewayId       string `json:"gateway_id,omitempty"`
+	Id              string `json:"id,omitempty" validate:"required=terminate"`
+	ProviderParams  string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	RouteTableId    string `json:"route_table_id,omitempty" validate:"required"`
+	DestinationCidr string `json:"dest_cidr,omitempty" validate:"required=create,cidr"`
+	GatewayType     string `json:"gateway_type,omitempty" validate:"required=create,enum=CVM|VPN|DIRECTCONNECT|PEERCONNECTION|SSLVPN|NAT|NORMAL_CVM|EIP|CCN,ignorecase"`
+	GatewayId       string `json:"gateway_id,omitempty" validate:"required=create"`
 	Description     string `json:"desc,omitempty"`
 }
 
@@ -66,22 +65,6 @@ func (action *CreateRoutePolicyAction) ReadParam(param interface{}) (interface{}
 	return inputs, nil
 }
 
-func isValidGatewayType(gatewayType string) error {
-	upperGatewayType := strings.ToUpper(gatewayType)
-	validGatewayTypes := []string{
-		"CVM", "VPN", "DIRECTCONNECT", "PEERCONNECTION", "SSLVPN",
-		"NAT", "NORMAL_CVM", "EIP", "CCN",
-	}
-
-	for _, validGatewayType := range validGatewayTypes {
-		if upperGatewayType == validGatewayType {
-			return nil
-		}
-	}
-
-	return fmt.Errorf("invalid gatewayType %s", gatewayType)
-}
-
 func isRouteConflicts(input CreateRoutePolicyInput) error {
 	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
 	client, err := CreateRouteTableClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
@@ -116,29 +99,14 @@ func isRouteConflicts(input CreateRoutePolicyInput) error {
 
 func (action *CreateRoutePolicyAction) CheckParam(input interface{}) error {
 	inputs, _ := input.(CreateRoutePolicyInputs)
+	if err := ValidateInputs(inputs, "create"); err != nil {
+		return err
+	}
+
 	for _, input := range inputs.Inputs {
-		if input.ProviderParams == "" {
-			return errors.New("CreateRoutePolicyAction input ProviderParams is empty")
-		}
-		if input.GatewayType == "" {
-			return errors.New("CreateRoutePolicyAction input GatewayType is empty")
-		}
-		if err := isValidGatewayType(input.GatewayType); err != nil {
-			return err
-		}
 		if err := isRouteConflicts(input); err != nil {
 			return err
 		}
-
-		if input.RouteTableId == "" {
-			return errors.New("CreateRoutePolicyAction input RouteTableId is empty")
-		}
-		if input.DestinationCidr == "" {
-			return errors.New("CreateRoutePolicyAction input DestinationCidr is empty")
-		}
-		if input.GatewayId == "" {
-			return errors.New("CreateRoutePolicyAction input GatewayId is empty")
-		}
 	}
 
 	return nil
@@ -224,20 +192,7 @@ func (action *DeleteRoutePolicyAction) ReadParam(param interface{}) (interface{}
 
 func (action *DeleteRoutePolicyAction) CheckParam(input interface{}) error {
 	inputs, _ := input.(DeleteRoutePolicyInputs)
-	for _, input := range inputs.Inputs {
-		if input.Id == "" {
-			return errors.New("DeleteRoutePolicyAction input Id is empty")
-		}
-
-		if input.ProviderParams == "" {
-			return errors.New("DeleteRoutePolicyAction input ProviderParams is empty")
-		}
-
-		if input.RouteTableId == "" {
-			return errors.New("DeleteRoutePolicyAction input RouteTableId is empty")
-		}
-	}
-	return nil
+	return ValidateInputs(inputs, "terminate")
 }
 
 func (action *DeleteRoutePolicyAction) Do(input interface{}) (interface{}, error) {
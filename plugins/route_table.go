@@ -1,7 +1,6 @@
 package plugins
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -31,12 +30,13 @@ func (plugin *RouteTablePlugin) GetActionByName(actionName string) (Action, erro
 }
 
 func CreateRouteTableClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("vpc", "vpc.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return vpc.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*vpc.Client), nil
 }
 
 type RouteTableInputs struct {
@@ -45,10 +45,10 @@ type RouteTableInputs struct {
 
 type RouteTableInput struct {
 	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	Name           string `json:"name,omitempty"`
-	VpcId          string `json:"vpc_id,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Id             string `json:"id,omitempty" validate:"required=terminate"`
+	Name           string `json:"name,omitempty" validate:"required=create"`
+	VpcId          string `json:"vpc_id,omitempty" validate:"required=create"`
 }
 
 type RouteTableOutputs struct {
@@ -80,16 +80,7 @@ func (action *RouteTableCreateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("routeTableCreateAtion:input type=%T not right", input)
 	}
 
-	for _, routeTable := range routeTables.Inputs {
-		if routeTable.VpcId == "" {
-			return errors.New("routeTableCreateAtion input vpcId is empty")
-		}
-		if routeTable.Name == "" {
-			return errors.New("routeTableCreateAtion input name is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(routeTables, "create")
 }
 
 func (action *RouteTableCreateAction) createRouteTable(input *RouteTableInput) (*RouteTableOutput, error) {
@@ -164,10 +155,11 @@ func (action *RouteTableTerminateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("routeTableTerminateAtion:input type=%T not right", input)
 	}
 
+	if err := ValidateInputs(routeTables, "terminate"); err != nil {
+		return err
+	}
+
 	for _, routeTable := range routeTables.Inputs {
-		if routeTable.Id == "" {
-			return errors.New("routeTableTerminateAtion param routeTableId is empty")
-		}
 		if err := makeSureRouteTableHasNoPolicy(routeTable); err != nil {
 			return err
 		}
@@ -260,9 +252,9 @@ type AssociateRouteTableInputs struct {
 
 type AssociateRouteTableInput struct {
 	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	SubnetId       string `json:"subnet_id,omitempty"`
-	RouteTableId   string `json:"route_table_id,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	SubnetId       string `json:"subnet_id,omitempty" validate:"required"`
+	RouteTableId   string `json:"route_table_id,omitempty" validate:"required"`
 }
 
 type AssociateRouteTableOutputs struct {
@@ -288,18 +280,7 @@ func (action *RouteTableAssociateSubnetAction) ReadParam(param interface{}) (int
 
 func (action *RouteTableAssociateSubnetAction) CheckParam(input interface{}) error {
 	inputs, _ := input.(AssociateRouteTableInputs)
-	for _, input := range inputs.Inputs {
-		if input.ProviderParams == "" {
-			return errors.New("RouteTableAssociatSubnetAction input ProviderParams is empty")
-		}
-		if input.SubnetId == "" {
-			return errors.New("RouteTableAssociatSubnetAction input SubnetId is empty")
-		}
-		if input.RouteTableId == "" {
-			return errors.New("RouteTableAssociatSubnetAction input RouteTableId is empty")
-		}
-	}
-	return nil
+	return ValidateInputs(inputs, "")
 }
 
 func associateSubnetWithRouteTable(providerParams string, subnetId string, routeTableId string) error {
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
@@ -33,16 +34,14 @@ func (plugin *SecurityGroupPlugin) GetActionByName(actionName string) (Action, e
 }
 
 func createVpcClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = QCLOUD_ENDPOINT_VPC
-
-	client, err = vpc.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("vpc", QCLOUD_ENDPOINT_VPC, region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return vpc.NewClient(credential, region, clientProfile)
+	})
 	if err != nil {
 		logrus.Errorf("Create Qcloud vm client failed,err=%v", err)
+		return nil, err
 	}
-	return
+	return cachedClient.(*vpc.Client), nil
 }
 
 type SecurityGroupInputs struct {
@@ -51,9 +50,9 @@ type SecurityGroupInputs struct {
 
 type SecurityGroupInput struct {
 	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Name           string `json:"name,omitempty"`
-	Id             string `json:"id,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Name           string `json:"name,omitempty" validate:"required=create"`
+	Id             string `json:"id,omitempty" validate:"required=terminate"`
 	Description    string `json:"description,omitempty"`
 }
 
@@ -73,15 +72,15 @@ type SecurityGroupPolicyInputs struct {
 
 type SecurityGroupPolicyInput struct {
 	Guid              string `json:"guid,omitempty"`
-	ProviderParams    string `json:"provider_params,omitempty"`
+	ProviderParams    string `json:"provider_params,omitempty" validate:"required,provider_params"`
 	Name              string `json:"name,omitempty"`
-	Id                string `json:"id,omitempty"`
+	Id                string `json:"id,omitempty" validate:"required"`
 	Description       string `json:"description,omitempty"`
-	PolicyType        string `json:"policy_type,omitempty"`
-	PolicyCidrBlock   string `json:"policy_cidr_block,omitempty"`
-	PolicyProtocol    string `json:"policy_protocol,omitempty"`
-	PolicyPort        string `json:"policy_port,omitempty"`
-	PolicyAction      string `json:"policy_action,omitempty"`
+	PolicyType        string `json:"policy_type,omitempty" validate:"required,enum=Ingress|Egress"`
+	PolicyCidrBlock   string `json:"policy_cidr_block,omitempty" validate:"required"`
+	PolicyProtocol    string `json:"policy_protocol,omitempty" validate:"enum=TCP|UDP|ICMP|ICMPv6|ALL,ignorecase"`
+	PolicyPort        string `json:"policy_port,omitempty" validate:"port"`
+	PolicyAction      string `json:"policy_action,omitempty" validate:"required,enum=ACCEPT|DROP,ignorecase"`
 	PolicyDescription string `json:"policy_description,omitempty"`
 }
 
@@ -116,12 +115,12 @@ func (action *SecurityGroupCreation) ReadParam(param interface{}) (interface{},
 }
 
 func (action *SecurityGroupCreation) CheckParam(input interface{}) error {
-	_, ok := input.(SecurityGroupInputs)
+	securityGroups, ok := input.(SecurityGroupInputs)
 	if !ok {
 		return INVALID_PARAMETERS
 	}
 
-	return nil
+	return ValidateInputs(securityGroups, "create")
 }
 
 func (action *SecurityGroupCreation) Do(input interface{}) (interface{}, error) {
@@ -227,12 +226,12 @@ func (action *SecurityGroupTermination) ReadParam(param interface{}) (interface{
 }
 
 func (action *SecurityGroupTermination) CheckParam(input interface{}) error {
-	_, ok := input.(SecurityGroupInputs)
+	securityGroups, ok := input.(SecurityGroupInputs)
 	if !ok {
 		return INVALID_PARAMETERS
 	}
 
-	return nil
+	return ValidateInputs(securityGroups, "terminate")
 }
 
 func (action *SecurityGroupTermination) Do(input interface{}) (interface{}, error) {
@@ -296,12 +295,12 @@ func (action *SecurityGroupCreatePolicies) ReadParam(param interface{}) (interfa
 }
 
 func (action *SecurityGroupCreatePolicies) CheckParam(input interface{}) error {
-	_, ok := input.(SecurityGroupPolicyInputs)
+	securityGroupPolicies, ok := input.(SecurityGroupPolicyInputs)
 	if !ok {
 		return INVALID_PARAMETERS
 	}
 
-	return nil
+	return ValidateInputs(securityGroupPolicies, "")
 }
 
 func (action *SecurityGroupCreatePolicies) Do(input interface{}) (interface{}, error) {
@@ -319,7 +318,9 @@ func (action *SecurityGroupCreatePolicies) Do(input interface{}) (interface{}, e
 			return outputs, err
 		}
 
+		unlock := LockResources("security-group/create-policies", securityGroup.SecurityGroupId)
 		output, err := createSecurityGroupPolicies(client, &securityGroup)
+		unlock()
 		if err != nil {
 			return outputs, err
 		}
@@ -476,12 +477,12 @@ func (action *SecurityGroupDeletePolicies) ReadParam(param interface{}) (interfa
 }
 
 func (action *SecurityGroupDeletePolicies) CheckParam(input interface{}) error {
-	_, ok := input.(SecurityGroupPolicyInputs)
+	securityGroupPolicies, ok := input.(SecurityGroupPolicyInputs)
 	if !ok {
 		return INVALID_PARAMETERS
 	}
 
-	return nil
+	return ValidateInputs(securityGroupPolicies, "")
 }
 
 func (action *SecurityGroupDeletePolicies) Do(input interface{}) (interface{}, error) {
@@ -498,7 +499,9 @@ func (action *SecurityGroupDeletePolicies) Do(input interface{}) (interface{}, e
 		if err != nil {
 			return outputs, err
 		}
+		unlock := LockResources("security-group/delete-policies", securityGroup.SecurityGroupId)
 		output, err := deleteSecurityGroupPolicies(client, &securityGroup)
+		unlock()
 		if err != nil {
 			return outputs, err
 		}
@@ -593,14 +596,24 @@ func QuerySecurityGroups(providerParam string, securityGroupIds []string) ([]*vp
 		return securityGroups, err
 	}
 
-	req := vpc.NewDescribeSecurityGroupsRequest()
-	req.SecurityGroupIds = common.StringPtrs(securityGroupIds)
-	resp, err := client.DescribeSecurityGroups(req)
+	err = PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		req := vpc.NewDescribeSecurityGroupsRequest()
+		req.SecurityGroupIds = common.StringPtrs(securityGroupIds)
+		req.Offset = common.StringPtr(strconv.Itoa(offset))
+		req.Limit = common.StringPtr(strconv.Itoa(limit))
+		resp, err := client.DescribeSecurityGroups(req)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		securityGroups = append(securityGroups, resp.Response.SecurityGroupSet...)
+		return len(resp.Response.SecurityGroupSet), int(*resp.Response.TotalCount), nil
+	})
 	if err != nil {
-		return securityGroups, err
+		return []*vpc.SecurityGroup{}, err
 	}
 
-	return resp.Response.SecurityGroupSet, nil
+	return securityGroups, nil
 }
 
 func QuerySecurityGroupPolicies(providerParam string, securityGroupId string) (vpc.SecurityGroupPolicySet, error) {
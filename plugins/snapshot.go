@@ -0,0 +1,764 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	SNAPSHOT_STATE_NORMAL = "NORMAL"
+
+	DISK_STATE_ATTACHED   = "ATTACHED"
+	DISK_STATE_UNATTACHED = "UNATTACHED"
+
+	CHARGE_TYPE_POSTPAID_BY_HOUR = "POSTPAID_BY_HOUR"
+
+	SNAPSHOT_WAIT_TIMEOUT = 3600
+	DISK_WAIT_TIMEOUT     = 600
+)
+
+var SnapshotActions = make(map[string]Action)
+
+func init() {
+	SnapshotActions["create"] = new(SnapshotCreateAction)
+	SnapshotActions["terminate"] = new(SnapshotTerminateAction)
+	SnapshotActions["query"] = new(SnapshotQueryAction)
+	SnapshotActions["rollback"] = new(SnapshotRollbackAction)
+	SnapshotActions["create-disk"] = new(SnapshotCreateDiskAction)
+	SnapshotActions["create-policy"] = new(SnapshotCreatePolicyAction)
+	SnapshotActions["terminate-policy"] = new(SnapshotTerminatePolicyAction)
+	SnapshotActions["bind-policy"] = new(SnapshotBindPolicyAction)
+	SnapshotActions["unbind-policy"] = new(SnapshotUnbindPolicyAction)
+}
+
+type SnapshotInputs struct {
+	Inputs []SnapshotInput `json:"inputs,omitempty"`
+}
+
+type SnapshotInput struct {
+	Guid           string `json:"guid,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Id             string `json:"id,omitempty" validate:"required=terminate|rollback|create-disk"`
+	Name           string `json:"name,omitempty"`
+	DiskId         string `json:"disk_id,omitempty" validate:"required=create|rollback"`
+	//the instance of the attached disk is stopped when rolling back and started after that
+	StopInstance bool `json:"stop_instance,omitempty"`
+
+	//use to create disk from snapshot, disk_size is the snapshot's disk size when it's not set
+	DiskType         string `json:"disk_type,omitempty" validate:"required=create-disk,enum=CLOUD_BASIC|CLOUD_PREMIUM|CLOUD_SSD"`
+	DiskSize         uint64 `json:"disk_size,omitempty"`
+	DiskName         string `json:"disk_name,omitempty"`
+	DiskChargeType   string `json:"disk_charge_type,omitempty" validate:"enum=PREPAID|POSTPAID_BY_HOUR"`
+	DiskChargePeriod string `json:"disk_charge_period,omitempty" validate:"range=1-36"`
+
+	//use to manage auto snapshot policies, days_of_week(0-6) and hours(0-23) are separated by comma
+	PolicyId   string `json:"policy_id,omitempty" validate:"required=terminate-policy|bind-policy|unbind-policy"`
+	PolicyName string `json:"policy_name,omitempty" validate:"required=create-policy"`
+	DaysOfWeek string `json:"days_of_week,omitempty" validate:"required=create-policy"`
+	Hours      string `json:"hours,omitempty" validate:"required=create-policy"`
+	//snapshots are kept permanently when retention_days is 0
+	RetentionDays uint64 `json:"retention_days,omitempty" validate:"range=0-65535"`
+	//disk ids separated by comma
+	DiskIds string `json:"disk_ids,omitempty" validate:"required=bind-policy|unbind-policy"`
+}
+
+type SnapshotOutputs struct {
+	Outputs []SnapshotOutput `json:"outputs,omitempty"`
+}
+
+type SnapshotOutput struct {
+	Guid          string           `json:"guid,omitempty"`
+	RequestId     string           `json:"request_id,omitempty"`
+	Id            string           `json:"id,omitempty"`
+	SnapshotState string           `json:"snapshot_state,omitempty"`
+	DiskId        string           `json:"disk_id,omitempty"`
+	PolicyId      string           `json:"policy_id,omitempty"`
+	Snapshots     []SnapshotDetail `json:"snapshots,omitempty"`
+}
+
+type SnapshotDetail struct {
+	Id            string `json:"id,omitempty"`
+	Name          string `json:"name,omitempty"`
+	DiskId        string `json:"disk_id,omitempty"`
+	DiskUsage     string `json:"disk_usage,omitempty"`
+	DiskSize      uint64 `json:"disk_size,omitempty"`
+	SnapshotState string `json:"snapshot_state,omitempty"`
+	Zone          string `json:"zone,omitempty"`
+	CreateTime    string `json:"create_time,omitempty"`
+	DeadlineTime  string `json:"deadline_time,omitempty"`
+}
+
+type SnapshotPlugin struct {
+}
+
+func (plugin *SnapshotPlugin) GetActionByName(actionName string) (Action, error) {
+	action, found := SnapshotActions[actionName]
+	if !found {
+		return nil, fmt.Errorf("snapshot plugin,action = %s not found", actionName)
+	}
+	return action, nil
+}
+
+type SnapshotAction struct {
+}
+
+func (action *SnapshotAction) ReadParam(param interface{}) (interface{}, error) {
+	var inputs SnapshotInputs
+	err := UnmarshalJson(param, &inputs)
+	if err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+func checkSnapshotInputs(input interface{}, scene string) error {
+	snapshots, ok := input.(SnapshotInputs)
+	if !ok {
+		return fmt.Errorf("Snapshot%sAction:input type=%T not right", strings.Title(scene), input)
+	}
+
+	return ValidateInputs(snapshots, scene)
+}
+
+func createSnapshotClient(providerParams string) (*cbs.Client, error) {
+	paramsMap, err := GetMapFromProviderParams(providerParams)
+	if err != nil {
+		return nil, err
+	}
+	return CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+}
+
+func describeSnapshots(client *cbs.Client, snapshotIds []string, filters []*cbs.Filter) ([]*cbs.Snapshot, error) {
+	snapshots := []*cbs.Snapshot{}
+	err := PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := cbs.NewDescribeSnapshotsRequest()
+		if len(snapshotIds) > 0 {
+			request.SnapshotIds = common.StringPtrs(snapshotIds)
+		}
+		request.Filters = filters
+		request.Offset = common.Uint64Ptr(uint64(offset))
+		request.Limit = common.Uint64Ptr(uint64(limit))
+
+		response, err := client.DescribeSnapshots(request)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		snapshots = append(snapshots, response.Response.SnapshotSet...)
+		return len(response.Response.SnapshotSet), int(*response.Response.TotalCount), nil
+	})
+	if err != nil {
+		logrus.Errorf("cbs DescribeSnapshots meet error=%v", err)
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func waitSnapshotNormal(client *cbs.Client, snapshotId string, timeout int) (*cbs.Snapshot, error) {
+	count := 0
+	for {
+		snapshots, err := describeSnapshots(client, []string{snapshotId}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(snapshots) == 0 {
+			return nil, fmt.Errorf("snapshot(%s) not found", snapshotId)
+		}
+		if *snapshots[0].SnapshotState == SNAPSHOT_STATE_NORMAL {
+			return snapshots[0], nil
+		}
+
+		count++
+		if count*5 > timeout {
+			logrus.Errorf("wait snapshot(%s) normal timeout, current state is %s", snapshotId, *snapshots[0].SnapshotState)
+			return nil, fmt.Errorf("wait snapshot(%s) normal timeout", snapshotId)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+//createSnapshotAndWait creates the snapshot of the disk and waits until it's NORMAL
+func createSnapshotAndWait(client *cbs.Client, diskId string, name string) (string, string, error) {
+	request := cbs.NewCreateSnapshotRequest()
+	request.DiskId = &diskId
+	if name != "" {
+		request.SnapshotName = &name
+	}
+	response, err := client.CreateSnapshot(request)
+	if err != nil {
+		logrus.Errorf("cbs CreateSnapshot(diskId=%s) meet error=%v", diskId, err)
+		return "", "", err
+	}
+	snapshotId := *response.Response.SnapshotId
+	logrus.Infof("Create snapshot[%v] of disk[%v] has been submitted, RequestID is [%v]", snapshotId, diskId, *response.Response.RequestId)
+
+	if _, err = waitSnapshotNormal(client, snapshotId, SNAPSHOT_WAIT_TIMEOUT); err != nil {
+		return "", "", err
+	}
+	return snapshotId, *response.Response.RequestId, nil
+}
+
+//parseUintList parses the numbers separated by comma, every number should be in [min,max]
+func parseUintList(value string, min uint64, max uint64) ([]uint64, error) {
+	numbers := []uint64{}
+	for _, item := range splitAndTrim(value, ",") {
+		number, err := strconv.ParseUint(item, 10, 64)
+		if err != nil || number < min || number > max {
+			return nil, fmt.Errorf("%s is not a number in [%d,%d]", item, min, max)
+		}
+		numbers = append(numbers, number)
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("no number is found in %s", value)
+	}
+	return numbers, nil
+}
+
+func uint64Ptrs(values []uint64) []*uint64 {
+	ptrs := []*uint64{}
+	for i := range values {
+		ptrs = append(ptrs, &values[i])
+	}
+	return ptrs
+}
+
+func buildSnapshotDetail(snapshot *cbs.Snapshot) SnapshotDetail {
+	detail := SnapshotDetail{
+		Id:            stringValue(snapshot.SnapshotId),
+		Name:          stringValue(snapshot.SnapshotName),
+		DiskId:        stringValue(snapshot.DiskId),
+		DiskUsage:     stringValue(snapshot.DiskUsage),
+		SnapshotState: stringValue(snapshot.SnapshotState),
+		CreateTime:    stringValue(snapshot.CreateTime),
+		DeadlineTime:  stringValue(snapshot.DeadlineTime),
+	}
+	if snapshot.DiskSize != nil {
+		detail.DiskSize = *snapshot.DiskSize
+	}
+	if snapshot.Placement != nil {
+		detail.Zone = stringValue(snapshot.Placement.Zone)
+	}
+	return detail
+}
+
+func doSnapshotInputs(input interface{}, do func(input *SnapshotInput) (*SnapshotOutput, error)) (interface{}, error) {
+	snapshots, _ := input.(SnapshotInputs)
+	outputs := SnapshotOutputs{}
+	for _, snapshot := range snapshots.Inputs {
+		output, err := do(&snapshot)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//--------------create snapshot--------------------//
+type SnapshotCreateAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotCreateAction) CheckParam(input interface{}) error {
+	return checkSnapshotInputs(input, "create")
+}
+
+func (action *SnapshotCreateAction) createSnapshot(input *SnapshotInput) (*SnapshotOutput, error) {
+	client, err := createSnapshotClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	output := SnapshotOutput{}
+	output.Guid = input.Guid
+	output.DiskId = input.DiskId
+
+	//check resource exist
+	if input.Id != "" {
+		snapshots, err := describeSnapshots(client, []string{input.Id}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(snapshots) == 1 {
+			output.Id = input.Id
+			output.SnapshotState = *snapshots[0].SnapshotState
+			return &output, nil
+		}
+	}
+
+	unlock := LockResources("snapshot/create", input.DiskId)
+	defer unlock()
+
+	if output.Id, output.RequestId, err = createSnapshotAndWait(client, input.DiskId, input.Name); err != nil {
+		return nil, err
+	}
+	output.SnapshotState = SNAPSHOT_STATE_NORMAL
+
+	return &output, nil
+}
+
+func (action *SnapshotCreateAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, action.createSnapshot)
+}
+
+//--------------terminate snapshot--------------------//
+type SnapshotTerminateAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotTerminateAction) CheckParam(input interface{}) error {
+	return checkSnapshotInputs(input, "terminate")
+}
+
+func (action *SnapshotTerminateAction) terminateSnapshot(input *SnapshotInput) (*SnapshotOutput, error) {
+	client, err := createSnapshotClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	request := cbs.NewDeleteSnapshotsRequest()
+	request.SnapshotIds = []*string{&input.Id}
+	response, err := client.DeleteSnapshots(request)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to DeleteSnapshots(snapshotId=%v), error=%s", input.Id, err)
+	}
+
+	output := SnapshotOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.Id = input.Id
+
+	return &output, nil
+}
+
+func (action *SnapshotTerminateAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, action.terminateSnapshot)
+}
+
+//--------------query snapshots--------------------//
+type SnapshotQueryAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotQueryAction) CheckParam(input interface{}) error {
+	return checkSnapshotInputs(input, "query")
+}
+
+//buildSnapshotQueryFilters returns the filters of DescribeSnapshots, id can't be used together with the other filters
+func buildSnapshotQueryFilters(input *SnapshotInput) ([]string, []*cbs.Filter, error) {
+	snapshotIds := splitAndTrim(input.Id, ",")
+	filters := []*cbs.Filter{}
+	if diskIds := splitAndTrim(input.DiskId, ","); len(diskIds) > 0 {
+		filters = append(filters, &cbs.Filter{Name: common.StringPtr("disk-id"), Values: common.StringPtrs(diskIds)})
+	}
+	if input.Name != "" {
+		filters = append(filters, &cbs.Filter{Name: common.StringPtr("snapshot-name"), Values: common.StringPtrs([]string{input.Name})})
+	}
+	if len(snapshotIds) > 0 && len(filters) > 0 {
+		return nil, nil, fmt.Errorf("id can't be used together with disk_id and name")
+	}
+	return snapshotIds, filters, nil
+}
+
+func (action *SnapshotQueryAction) querySnapshots(input *SnapshotInput) (*SnapshotOutput, error) {
+	snapshotIds, filters, err := buildSnapshotQueryFilters(input)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := createSnapshotClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := describeSnapshots(client, snapshotIds, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	output := SnapshotOutput{}
+	output.Guid = input.Guid
+	output.Snapshots = []SnapshotDetail{}
+	for _, snapshot := range snapshots {
+		output.Snapshots = append(output.Snapshots, buildSnapshotDetail(snapshot))
+	}
+
+	return &output, nil
+}
+
+func (action *SnapshotQueryAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, action.querySnapshots)
+}
+
+//--------------rollback disk from snapshot--------------------//
+type SnapshotRollbackAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotRollbackAction) CheckParam(input interface{}) error {
+	return checkSnapshotInputs(input, "rollback")
+}
+
+//rollbackDisk applies the snapshot to the disk, the attached disk can only be rolled back when its instance is stopped
+func (action *SnapshotRollbackAction) rollbackDisk(input *SnapshotInput) (*SnapshotOutput, error) {
+	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+	client, err := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	disk, err := getCbsDisk(client, input.DiskId)
+	if err != nil {
+		return nil, err
+	}
+	instanceId := stringValue(disk.InstanceId)
+
+	unlock := LockResources("snapshot/rollback", input.DiskId, instanceId)
+	defer unlock()
+
+	if _, err = waitSnapshotNormal(client, input.Id, SNAPSHOT_WAIT_TIMEOUT); err != nil {
+		return nil, err
+	}
+
+	//the instance stopped for rolling back is started again when the rollback fails
+	var cvmClient *cvm.Client
+	stopped := false
+	defer func() {
+		if stopped {
+			restartStoppedInstance(cvmClient, instanceId)
+		}
+	}()
+	if instanceId != "" {
+		if cvmClient, err = createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"]); err != nil {
+			return nil, err
+		}
+		instance, err := getInstanceByInstanceId(cvmClient, instanceId)
+		if err != nil {
+			return nil, err
+		}
+		if *instance.InstanceState != INSTANCE_STATE_STOPPED {
+			if !input.StopInstance {
+				return nil, fmt.Errorf("instance(%s) of disk(%s) should be stopped before rolling back, or set stop_instance", instanceId, input.DiskId)
+			}
+			if err = stopInstanceAndWait(cvmClient, instanceId); err != nil {
+				return nil, err
+			}
+			stopped = true
+		}
+	}
+
+	request := cbs.NewApplySnapshotRequest()
+	request.SnapshotId = &input.Id
+	request.DiskId = &input.DiskId
+	response, err := client.ApplySnapshot(request)
+	if err != nil {
+		logrus.Errorf("cbs ApplySnapshot(snapshotId=%s, diskId=%s) meet error=%v", input.Id, input.DiskId, err)
+		return nil, err
+	}
+
+	if _, err = waitCbsDiskReady(client, input.DiskId, *disk.DiskState, SNAPSHOT_WAIT_TIMEOUT); err != nil {
+		return nil, err
+	}
+	if stopped {
+		stopped = false
+		if err = startInstanceAndWait(cvmClient, instanceId); err != nil {
+			return nil, err
+		}
+	}
+
+	output := SnapshotOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.Id = input.Id
+	output.DiskId = input.DiskId
+	output.SnapshotState = SNAPSHOT_STATE_NORMAL
+
+	return &output, nil
+}
+
+func (action *SnapshotRollbackAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, action.rollbackDisk)
+}
+
+//--------------create disk from snapshot--------------------//
+type SnapshotCreateDiskAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotCreateDiskAction) CheckParam(input interface{}) error {
+	return checkSnapshotInputs(input, "create-disk")
+}
+
+func (action *SnapshotCreateDiskAction) createDisk(input *SnapshotInput) (*SnapshotOutput, error) {
+	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+	client, err := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := waitSnapshotNormal(client, input.Id, SNAPSHOT_WAIT_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+
+	diskSize := input.DiskSize
+	if diskSize == 0 {
+		diskSize = *snapshot.DiskSize
+	}
+	if diskSize < *snapshot.DiskSize {
+		return nil, fmt.Errorf("disk_size(%d) should not be less than the snapshot's disk size(%d)", diskSize, *snapshot.DiskSize)
+	}
+	chargeType := input.DiskChargeType
+	if chargeType == "" {
+		chargeType = CHARGE_TYPE_POSTPAID_BY_HOUR
+	}
+	availableZone := paramsMap["AvailableZone"]
+
+	request := cbs.NewCreateDisksRequest()
+	request.SnapshotId = &input.Id
+	request.DiskType = &input.DiskType
+	request.DiskSize = &diskSize
+	request.DiskChargeType = &chargeType
+	request.Placement = &cbs.Placement{Zone: &availableZone}
+	if input.DiskName != "" {
+		request.DiskName = &input.DiskName
+	}
+	if chargeType == CHARGE_TYPE_PREPAID {
+		period, _ := strconv.ParseUint(input.DiskChargePeriod, 0, 64)
+		renewFlag := "NOTIFY_AND_AUTO_RENEW"
+		request.DiskChargePrepaid = &cbs.DiskChargePrepaid{
+			Period:    &period,
+			RenewFlag: &renewFlag,
+		}
+	}
+
+	response, err := client.CreateDisks(request)
+	if err != nil {
+		logrus.Errorf("cbs CreateDisks(snapshotId=%s) meet error=%v", input.Id, err)
+		return nil, err
+	}
+	if len(response.Response.DiskIdSet) == 0 {
+		return nil, fmt.Errorf("no storage is created from snapshot(%s)", input.Id)
+	}
+
+	output := SnapshotOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.Id = input.Id
+	output.DiskId = *response.Response.DiskIdSet[0]
+
+	if _, err = waitCbsDiskReady(client, output.DiskId, DISK_STATE_UNATTACHED, DISK_WAIT_TIMEOUT); err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+func (action *SnapshotCreateDiskAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, action.createDisk)
+}
+
+//--------------create auto snapshot policy--------------------//
+type SnapshotCreatePolicyAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotCreatePolicyAction) CheckParam(input interface{}) error {
+	if err := checkSnapshotInputs(input, "create-policy"); err != nil {
+		return err
+	}
+
+	snapshots, _ := input.(SnapshotInputs)
+	for _, snapshot := range snapshots.Inputs {
+		if _, err := buildAutoSnapshotPolicy(&snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buildAutoSnapshotPolicy(input *SnapshotInput) (*cbs.Policy, error) {
+	daysOfWeek, err := parseUintList(input.DaysOfWeek, 0, 6)
+	if err != nil {
+		return nil, fmt.Errorf("days_of_week is invalid, %v", err)
+	}
+	hours, err := parseUintList(input.Hours, 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("hours is invalid, %v", err)
+	}
+	return &cbs.Policy{
+		DayOfWeek: uint64Ptrs(daysOfWeek),
+		Hour:      uint64Ptrs(hours),
+	}, nil
+}
+
+func (action *SnapshotCreatePolicyAction) createPolicy(input *SnapshotInput) (*SnapshotOutput, error) {
+	client, err := createSnapshotClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	output := SnapshotOutput{}
+	output.Guid = input.Guid
+
+	//check resource exist
+	if input.PolicyId != "" {
+		request := cbs.NewDescribeAutoSnapshotPoliciesRequest()
+		request.AutoSnapshotPolicyIds = []*string{&input.PolicyId}
+		response, err := client.DescribeAutoSnapshotPolicies(request)
+		if err != nil {
+			return nil, err
+		}
+		if len(response.Response.AutoSnapshotPolicySet) == 1 {
+			output.RequestId = *response.Response.RequestId
+			output.PolicyId = input.PolicyId
+			return &output, nil
+		}
+	}
+
+	policy, err := buildAutoSnapshotPolicy(input)
+	if err != nil {
+		return nil, err
+	}
+
+	request := cbs.NewCreateAutoSnapshotPolicyRequest()
+	request.Policy = []*cbs.Policy{policy}
+	request.AutoSnapshotPolicyName = &input.PolicyName
+	request.IsActivated = common.BoolPtr(true)
+	if input.RetentionDays == 0 {
+		request.IsPermanent = common.BoolPtr(true)
+	} else {
+		request.IsPermanent = common.BoolPtr(false)
+		request.RetentionDays = &input.RetentionDays
+	}
+
+	response, err := client.CreateAutoSnapshotPolicy(request)
+	if err != nil {
+		logrus.Errorf("cbs CreateAutoSnapshotPolicy meet error=%v", err)
+		return nil, err
+	}
+	output.RequestId = *response.Response.RequestId
+	output.PolicyId = *response.Response.AutoSnapshotPolicyId
+
+	return &output, nil
+}
+
+func (action *SnapshotCreatePolicyAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, action.createPolicy)
+}
+
+//--------------terminate auto snapshot policy--------------------//
+type SnapshotTerminatePolicyAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotTerminatePolicyAction) CheckParam(input interface{}) error {
+	return checkSnapshotInputs(input, "terminate-policy")
+}
+
+func (action *SnapshotTerminatePolicyAction) terminatePolicy(input *SnapshotInput) (*SnapshotOutput, error) {
+	client, err := createSnapshotClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	request := cbs.NewDeleteAutoSnapshotPoliciesRequest()
+	request.AutoSnapshotPolicyIds = []*string{&input.PolicyId}
+	response, err := client.DeleteAutoSnapshotPolicies(request)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to DeleteAutoSnapshotPolicies(policyId=%v), error=%s", input.PolicyId, err)
+	}
+
+	output := SnapshotOutput{}
+	output.Guid = input.Guid
+	output.RequestId = *response.Response.RequestId
+	output.PolicyId = input.PolicyId
+
+	return &output, nil
+}
+
+func (action *SnapshotTerminatePolicyAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, action.terminatePolicy)
+}
+
+//--------------bind auto snapshot policy to disks--------------------//
+type SnapshotBindPolicyAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotBindPolicyAction) CheckParam(input interface{}) error {
+	return checkSnapshotInputs(input, "bind-policy")
+}
+
+func (action *SnapshotBindPolicyAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, func(input *SnapshotInput) (*SnapshotOutput, error) {
+		return bindOrUnbindAutoSnapshotPolicy(input, true)
+	})
+}
+
+//--------------unbind auto snapshot policy from disks--------------------//
+type SnapshotUnbindPolicyAction struct {
+	SnapshotAction
+}
+
+func (action *SnapshotUnbindPolicyAction) CheckParam(input interface{}) error {
+	return checkSnapshotInputs(input, "unbind-policy")
+}
+
+func (action *SnapshotUnbindPolicyAction) Do(input interface{}) (interface{}, error) {
+	return doSnapshotInputs(input, func(input *SnapshotInput) (*SnapshotOutput, error) {
+		return bindOrUnbindAutoSnapshotPolicy(input, false)
+	})
+}
+
+func bindOrUnbindAutoSnapshotPolicy(input *SnapshotInput, bind bool) (*SnapshotOutput, error) {
+	client, err := createSnapshotClient(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	diskIds := splitAndTrim(input.DiskIds, ",")
+	requestId := ""
+	if bind {
+		request := cbs.NewBindAutoSnapshotPolicyRequest()
+		request.AutoSnapshotPolicyId = &input.PolicyId
+		request.DiskIds = common.StringPtrs(diskIds)
+		response, err := client.BindAutoSnapshotPolicy(request)
+		if err != nil {
+			logrus.Errorf("cbs BindAutoSnapshotPolicy(policyId=%s, diskIds=%v) meet error=%v", input.PolicyId, diskIds, err)
+			return nil, err
+		}
+		requestId = *response.Response.RequestId
+	} else {
+		request := cbs.NewUnbindAutoSnapshotPolicyRequest()
+		request.AutoSnapshotPolicyId = &input.PolicyId
+		request.DiskIds = common.StringPtrs(diskIds)
+		response, err := client.UnbindAutoSnapshotPolicy(request)
+		if err != nil {
+			logrus.Errorf("cbs UnbindAutoSnapshotPolicy(policyId=%s, diskIds=%v) meet error=%v", input.PolicyId, diskIds, err)
+			return nil, err
+		}
+		requestId = *response.Response.RequestId
+	}
+
+	output := SnapshotOutput{}
+	output.Guid = input.Guid
+	output.RequestId = requestId
+	output.PolicyId = input.PolicyId
+
+	return &output, nil
+}
@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"testing"
+)
+
+func TestUnitParseUintList(t *testing.T) {
+	numbers, err := parseUintList("1, 3,5", 0, 6)
+	if err != nil || len(numbers) != 3 || numbers[2] != 5 {
+		t.Errorf("numbers=%v, err=%v", numbers, err)
+	}
+
+	for _, value := range []string{"7", "a", "", "-1"} {
+		if _, err = parseUintList(value, 0, 6); err == nil {
+			t.Errorf("%s should be an error", value)
+		}
+	}
+}
+
+func TestUnitBuildAutoSnapshotPolicy(t *testing.T) {
+	policy, err := buildAutoSnapshotPolicy(&SnapshotInput{DaysOfWeek: "0,6", Hours: "2"})
+	if err != nil || len(policy.DayOfWeek) != 2 || *policy.DayOfWeek[1] != 6 || *policy.Hour[0] != 2 {
+		t.Errorf("policy=%v, err=%v", policy, err)
+	}
+
+	if _, err = buildAutoSnapshotPolicy(&SnapshotInput{DaysOfWeek: "1", Hours: "24"}); err == nil {
+		t.Errorf("hour 24 should be an error")
+	}
+}
+
+func TestUnitBuildSnapshotQueryFilters(t *testing.T) {
+	snapshotIds, filters, err := buildSnapshotQueryFilters(&SnapshotInput{DiskId: "disk-1,disk-2"})
+	if err != nil || len(snapshotIds) != 0 || len(filters) != 1 || len(filters[0].Values) != 2 {
+		t.Errorf("snapshot ids=%v, filters=%v, err=%v", snapshotIds, filters, err)
+	}
+
+	if _, _, err = buildSnapshotQueryFilters(&SnapshotInput{Id: "snap-1", DiskId: "disk-1"}); err == nil {
+		t.Errorf("id with disk_id should be an error")
+	}
+}
+
+func TestUnitSnapshotInputValidate(t *testing.T) {
+	providerParams := "Region=ap-guangzhou;AvailableZone=ap-guangzhou-4;SecretID=id;SecretKey=key"
+	inputs := SnapshotInputs{Inputs: []SnapshotInput{{ProviderParams: providerParams, PolicyId: "asp-1"}}}
+	if err := ValidateInputs(inputs, "bind-policy"); err == nil {
+		t.Errorf("bind-policy without disk_ids should be an error")
+	}
+	if err := ValidateInputs(inputs, "terminate-policy"); err != nil {
+		t.Errorf("terminate-policy input meet error=%v", err)
+	}
+}
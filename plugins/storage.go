@@ -19,12 +19,13 @@ func init() {
 }
 
 func CreateCbsClient(region, secretId, secretKey string) (client *cbs.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "cbs.tencentcloudapi.com"
-
-	return cbs.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("cbs", "cbs.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return cbs.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*cbs.Client), nil
 }
 
 type StorageInputs struct {
@@ -33,14 +34,14 @@ type StorageInputs struct {
 
 type StorageInput struct {
 	Guid             string `json:"guid,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	DiskType         string `json:"disk_type,omitempty"`
-	DiskSize         uint64 `json:"disk_size,omitempty"`
+	ProviderParams   string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	DiskType         string `json:"disk_type,omitempty" validate:"required=create,enum=CLOUD_BASIC|CLOUD_PREMIUM|CLOUD_SSD"`
+	DiskSize         uint64 `json:"disk_size,omitempty" validate:"required=create"`
 	DiskName         string `json:"disk_name,omitempty"`
-	Id               string `json:"id,omitempty"`
-	DiskChargeType   string `json:"disk_charge_type,omitempty"`
-	DiskChargePeriod string `json:"disk_charge_period,omitempty"`
-	InstanceId       string `json:"instance_id,omitempty"`
+	Id               string `json:"id,omitempty" validate:"required=terminate"`
+	DiskChargeType   string `json:"disk_charge_type,omitempty" validate:"required=create,enum=PREPAID|POSTPAID_BY_HOUR"`
+	DiskChargePeriod string `json:"disk_charge_period,omitempty" validate:"range=1-36"`
+	InstanceId       string `json:"instance_id,omitempty" validate:"required=create"`
 }
 
 type StorageOutputs struct {
@@ -79,27 +80,28 @@ func (action *StorageCreateAction) ReadParam(param interface{}) (interface{}, er
 }
 
 func (action *StorageCreateAction) CheckParam(input interface{}) error {
-	_, ok := input.(StorageInputs)
+	storages, ok := input.(StorageInputs)
 	if !ok {
 		return fmt.Errorf("storageCreateAtion:input type=%T not right", input)
 	}
 
-	return nil
+	return ValidateInputs(storages, "create")
 }
 
 func (action *StorageCreateAction) Do(input interface{}) (interface{}, error) {
 	storages, _ := input.(StorageInputs)
 	outputs := StorageOutputs{}
 
-	for _, storage := range storages.Inputs {
-		output, err := action.createStorage(&storage)
-		if err != nil {
-			return nil, err
-		}
-
-		storage.Id = output.Id
+	//check resource exist
+	existStorages, err := queryStorageInfo(storages.Inputs)
+	if err != nil {
+		return nil, err
+	}
 
-		err = action.attachStorage(&storage)
+	for i, storage := range storages.Inputs {
+		unlock := LockResources("storage/create", storage.InstanceId)
+		output, err := action.attachOrCreateStorage(&storage, existStorages[i])
+		unlock()
 		if err != nil {
 			return nil, err
 		}
@@ -111,6 +113,24 @@ func (action *StorageCreateAction) Do(input interface{}) (interface{}, error) {
 	return &outputs, nil
 }
 
+//attachOrCreateStorage creates the storage when existStorage is nil, then attaches it to storage.InstanceId,
+//the caller should hold the lock of storage.InstanceId
+func (action *StorageCreateAction) attachOrCreateStorage(storage *StorageInput, existStorage *StorageOutput) (*StorageOutput, error) {
+	output := existStorage
+	if output == nil {
+		var err error
+		if output, err = action.createStorage(storage); err != nil {
+			return nil, err
+		}
+	}
+
+	storage.Id = output.Id
+	if err := action.attachStorage(storage); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
 func (action *StorageCreateAction) attachStorage(storage *StorageInput) error {
 	paramsMap, _ := GetMapFromProviderParams(storage.ProviderParams)
 	client, _ := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
@@ -145,18 +165,6 @@ func (action *StorageCreateAction) createStorage(storage *StorageInput) (*Storag
 	paramsMap, err := GetMapFromProviderParams(storage.ProviderParams)
 	client, _ := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
 
-	//check resource exist
-	if storage.Id != "" {
-		queryStorageResponse, flag, err := queryStorageInfo(client, storage)
-		if err != nil && flag == false {
-			return nil, err
-		}
-
-		if err == nil && flag == true {
-			return queryStorageResponse, nil
-		}
-	}
-
 	request := cbs.NewCreateDisksRequest()
 	request.DiskName = &storage.DiskName
 	request.DiskType = &storage.DiskType
@@ -211,13 +219,7 @@ func (action *StorageTerminateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("storageTerminationAtion:input type=%T not right", input)
 	}
 
-	for _, storage := range storages.Inputs {
-		if storage.Id == "" {
-			return fmt.Errorf("storageTerminateAction storage_id is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(storages, "terminate")
 }
 
 func (action *StorageTerminateAction) Do(input interface{}) (interface{}, error) {
@@ -225,12 +227,15 @@ func (action *StorageTerminateAction) Do(input interface{}) (interface{}, error)
 	outputs := StorageOutputs{}
 
 	for _, storage := range storages.Inputs {
+		unlock := LockResources("storage/terminate", storage.Id)
 		err := action.detachStorage(&storage)
 		if err != nil {
+			unlock()
 			return nil, err
 		}
 
 		output, err := action.terminateStorage(&storage)
+		unlock()
 		if err != nil {
 			return nil, err
 		}
@@ -291,28 +296,90 @@ func (action *StorageTerminateAction) terminateStorage(storage *StorageInput) (*
 	return &output, nil
 }
 
-func queryStorageInfo(client *cbs.Client, input *StorageInput) (*StorageOutput, bool, error) {
-	output := StorageOutput{}
+func describeStoragesByIds(paramsMap map[string]string, ids []string) (map[string]interface{}, error) {
+	client, err := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
 
 	request := cbs.NewDescribeDisksRequest()
-	request.DiskIds = append(request.DiskIds, &input.Id)
+	for i := range ids {
+		request.DiskIds = append(request.DiskIds, &ids[i])
+	}
+	limit := uint64(DESCRIBE_BATCH_SIZE)
+	request.Limit = &limit
 	response, err := client.DescribeDisks(request)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	if len(response.Response.DiskSet) == 0 {
-		return nil, false, nil
+	storages := make(map[string]interface{})
+	for _, disk := range response.Response.DiskSet {
+		storages[*disk.DiskId] = &StorageOutput{
+			RequestId: *response.Response.RequestId,
+			Id:        *disk.DiskId,
+		}
 	}
+	return storages, nil
+}
 
-	if len(response.Response.DiskSet) > 1 {
-		logrus.Errorf("query storage disk id=%s info find more than 1", input.Id)
-		return nil, false, fmt.Errorf("query storage disk id=%s info find more than 1", input.Id)
+//queryStorageInfo returns the existing storage of every input, nil when the input has no id or the storage doesn't exist
+func queryStorageInfo(inputs []StorageInput) ([]*StorageOutput, error) {
+	providerParams := []string{}
+	ids := []string{}
+	for _, input := range inputs {
+		providerParams = append(providerParams, input.ProviderParams)
+		ids = append(ids, input.Id)
 	}
 
-	output.Guid = input.Guid
-	output.Id = input.Id
-	output.RequestId = *response.Response.RequestId
+	resources, err := BatchDescribe(providerParams, ids, describeStoragesByIds)
+	if err != nil {
+		return nil, err
+	}
 
-	return &output, true, nil
+	outputs := make([]*StorageOutput, len(inputs))
+	for i, resource := range resources {
+		if resource == nil {
+			continue
+		}
+		output := *resource.(*StorageOutput)
+		output.Guid = inputs[i].Guid
+		outputs[i] = &output
+	}
+	return outputs, nil
+}
+
+func getCbsDisk(client *cbs.Client, diskId string) (*cbs.Disk, error) {
+	request := cbs.NewDescribeDisksRequest()
+	request.DiskIds = []*string{&diskId}
+	response, err := client.DescribeDisks(request)
+	if err != nil {
+		logrus.Errorf("cbs DescribeDisks(diskId=%s) meet error=%v", diskId, err)
+		return nil, err
+	}
+	if len(response.Response.DiskSet) == 0 {
+		return nil, fmt.Errorf("storage(id = %v) not found", diskId)
+	}
+	return response.Response.DiskSet[0], nil
+}
+
+//waitCbsDiskReady waits until the disk is in desireState and not rolling back
+func waitCbsDiskReady(client *cbs.Client, diskId string, desireState string, timeout int) (*cbs.Disk, error) {
+	count := 0
+	for {
+		disk, err := getCbsDisk(client, diskId)
+		if err != nil {
+			return nil, err
+		}
+		if *disk.DiskState == desireState && (disk.Rollbacking == nil || !*disk.Rollbacking) {
+			return disk, nil
+		}
+
+		count++
+		if count*5 > timeout {
+			logrus.Errorf("wait storage(id = %v) in state %s timeout, current state is %s", diskId, desireState, *disk.DiskState)
+			return nil, fmt.Errorf("wait storage(id = %v) in state %s timeout", diskId, desireState)
+		}
+		time.Sleep(5 * time.Second)
+	}
 }
@@ -1,9 +1,8 @@
 package plugins
 
 import (
-	"errors"
 	"fmt"
-	"net"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
@@ -21,12 +20,13 @@ func init() {
 }
 
 func CreateSubnetClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("vpc", "vpc.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return vpc.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*vpc.Client), nil
 }
 
 type SubnetInputs struct {
@@ -35,12 +35,12 @@ type SubnetInputs struct {
 
 type SubnetInput struct {
 	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	Name           string `json:"name,omitempty"`
-	CidrBlock      string `json:"cidr_block,omitempty"`
-	VpcId          string `json:"vpc_id,omitempty"`
-	RouteTableId   string `json:"route_table_id,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Id             string `json:"id,omitempty" validate:"required=terminate|terminate-with-routetable"`
+	Name           string `json:"name,omitempty" validate:"required=create"`
+	CidrBlock      string `json:"cidr_block,omitempty" validate:"required=create,cidr"`
+	VpcId          string `json:"vpc_id,omitempty" validate:"required=create"`
+	RouteTableId   string `json:"route_table_id,omitempty" validate:"required=terminate-with-routetable"`
 }
 
 type SubnetOutputs struct {
@@ -85,19 +85,7 @@ func (action *SubnetCreateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("subnetCreateAtion:input type=%T not right", input)
 	}
 
-	for _, subnet := range subnets.Inputs {
-		if subnet.VpcId == "" {
-			return errors.New("subnetCreateAtion input vpc_id is empty")
-		}
-		if subnet.Name == "" {
-			return errors.New("subnetCreateAtion input name is empty")
-		}
-		if _, _, err := net.ParseCIDR(subnet.CidrBlock); err != nil {
-			return fmt.Errorf("subnetCreateAtion invalid cidr_block [%s]", subnet.CidrBlock)
-		}
-	}
-
-	return nil
+	return ValidateInputs(subnets, "create")
 }
 
 func (action *SubnetCreateAction) createSubnet(subnet *SubnetInput) (*SubnetOutput, error) {
@@ -107,18 +95,6 @@ func (action *SubnetCreateAction) createSubnet(subnet *SubnetInput) (*SubnetOutp
 		return nil, err
 	}
 
-	//check resource exist
-	if subnet.Id != "" {
-		querysubnetresponse, flag, err := querySubnetsInfo(client, subnet)
-		if err != nil && flag == false {
-			return nil, err
-		}
-
-		if err == nil && flag == true {
-			return querysubnetresponse, nil
-		}
-	}
-
 	request := vpc.NewCreateSubnetRequest()
 	request.VpcId = &subnet.VpcId
 	request.SubnetName = &subnet.Name
@@ -142,7 +118,19 @@ func (action *SubnetCreateAction) createSubnet(subnet *SubnetInput) (*SubnetOutp
 func (action *SubnetCreateAction) Do(input interface{}) (interface{}, error) {
 	subnets, _ := input.(SubnetInputs)
 	outputs := SubnetOutputs{}
-	for _, subnet := range subnets.Inputs {
+
+	//check resource exist
+	existSubnets, err := querySubnetsInfo(subnets.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, subnet := range subnets.Inputs {
+		if existSubnets[i] != nil {
+			outputs.Outputs = append(outputs.Outputs, *existSubnets[i])
+			continue
+		}
+
 		output, err := action.createSubnet(&subnet)
 		if err != nil {
 			return nil, err
@@ -173,12 +161,7 @@ func (action *SubnetTerminateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("subnetTerminateAtion:input type=%T not right", input)
 	}
 
-	for _, subnet := range subnets.Inputs {
-		if subnet.Id == "" {
-			return errors.New("subnetTerminateAtion param subnetId is empty")
-		}
-	}
-	return nil
+	return ValidateInputs(subnets, "terminate")
 }
 
 func (action *SubnetTerminateAction) terminateSubnet(subnet *SubnetInput) (*SubnetOutput, error) {
@@ -215,30 +198,57 @@ func (action *SubnetTerminateAction) Do(input interface{}) (interface{}, error)
 	return &outputs, nil
 }
 
-func querySubnetsInfo(client *vpc.Client, input *SubnetInput) (*SubnetOutput, bool, error) {
-	output := SubnetOutput{}
+func describeSubnetsByIds(paramsMap map[string]string, ids []string) (map[string]interface{}, error) {
+	client, err := CreateSubnetClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
 
 	request := vpc.NewDescribeSubnetsRequest()
-	request.SubnetIds = append(request.SubnetIds, &input.Id)
+	for i := range ids {
+		request.SubnetIds = append(request.SubnetIds, &ids[i])
+	}
+	limit := strconv.Itoa(DESCRIBE_BATCH_SIZE)
+	request.Limit = &limit
 	response, err := client.DescribeSubnets(request)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	if len(response.Response.SubnetSet) == 0 {
-		return nil, false, nil
+	subnets := make(map[string]interface{})
+	for _, subnet := range response.Response.SubnetSet {
+		subnets[*subnet.SubnetId] = &SubnetOutput{
+			RequestId: *response.Response.RequestId,
+			Id:        *subnet.SubnetId,
+		}
 	}
+	return subnets, nil
+}
 
-	if len(response.Response.SubnetSet) > 1 {
-		logrus.Errorf("query subnet id=%s info find more than 1", input.Id)
-		return nil, false, fmt.Errorf("query subnet id=%s info find more than 1", input.Id)
+//querySubnetsInfo returns the existing subnet of every input, nil when the input has no id or the subnet doesn't exist
+func querySubnetsInfo(inputs []SubnetInput) ([]*SubnetOutput, error) {
+	providerParams := []string{}
+	ids := []string{}
+	for _, input := range inputs {
+		providerParams = append(providerParams, input.ProviderParams)
+		ids = append(ids, input.Id)
 	}
 
-	output.Guid = input.Guid
-	output.Id = input.Id
-	output.RequestId = *response.Response.RequestId
+	resources, err := BatchDescribe(providerParams, ids, describeSubnetsByIds)
+	if err != nil {
+		return nil, err
+	}
 
-	return &output, true, nil
+	outputs := make([]*SubnetOutput, len(inputs))
+	for i, resource := range resources {
+		if resource == nil {
+			continue
+		}
+		output := *resource.(*SubnetOutput)
+		output.Guid = inputs[i].Guid
+		outputs[i] = &output
+	}
+	return outputs, nil
 }
 
 //CreateSubnetWithRouteTable
@@ -293,11 +303,20 @@ func createSubnetWithRouteTable(input *SubnetInput) (*SubnetOutput, error) {
 		}
 	}()
 
-	action := SubnetCreateAction{}
-	createSubnetOutput, err := action.createSubnet(input)
+	//check resource exist
+	existSubnets, err := querySubnetsInfo([]SubnetInput{*input})
 	if err != nil {
 		return output, err
 	}
+
+	createSubnetOutput := existSubnets[0]
+	if createSubnetOutput == nil {
+		action := SubnetCreateAction{}
+		createSubnetOutput, err = action.createSubnet(input)
+		if err != nil {
+			return output, err
+		}
+	}
 	output.Id = createSubnetOutput.Id
 
 	//create routeTable
@@ -344,18 +363,12 @@ func (action *TerminateSubnetWithRouteTableAction) ReadParam(param interface{})
 }
 
 func (action *TerminateSubnetWithRouteTableAction) CheckParam(input interface{}) error {
-	terminateAction := SubnetTerminateAction{}
-	if err := terminateAction.CheckParam(input); err != nil {
-		return err
+	subnets, ok := input.(SubnetInputs)
+	if !ok {
+		return fmt.Errorf("TerminateSubnetWithRouteTableAction:input type=%T not right", input)
 	}
 
-	subnets, _ := input.(SubnetInputs)
-	for _, subnet := range subnets.Inputs {
-		if subnet.RouteTableId == "" {
-			return errors.New("TerminateSubnetWithRouteTableAction param RouteTableId is empty")
-		}
-	}
-	return nil
+	return ValidateInputs(subnets, "terminate-with-routetable")
 }
 
 func (action *TerminateSubnetWithRouteTableAction) Do(input interface{}) (interface{}, error) {
@@ -0,0 +1,380 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	TASK_FILE_SUFFIX = ".json"
+)
+
+//resumableActions can be run again by the next process, the recorded resource of an input is passed as its id
+//so the instance created before is returned rather than a new one, and the batch creates reuse their ClientToken
+var resumableActions = map[string]bool{
+	"vm/create": true,
+}
+
+//Task is an action request tracked while it runs, the parameters hold the secrets and are persisted
+//only for the resumable actions, encrypted with the task secret
+type Task struct {
+	Id               string          `json:"id"`
+	ProviderName     string          `json:"provider_name"`
+	Version          string          `json:"version"`
+	Name             string          `json:"name"`
+	Action           string          `json:"action"`
+	Parameters       json.RawMessage `json:"-"`
+	ResumeParameters string          `json:"resume_parameters,omitempty"`
+	CallbackUrl      string          `json:"callback_url,omitempty"`
+	StrictJson       bool            `json:"strict_json,omitempty"`
+	StartTime        time.Time       `json:"start_time"`
+	Inputs           []TaskInput     `json:"inputs,omitempty"`
+}
+
+//TaskInput records the resources created for the input with the guid, so they can be checked after the process stops
+type TaskInput struct {
+	Guid        string   `json:"guid,omitempty"`
+	Region      string   `json:"region,omitempty"`
+	ResourceIds []string `json:"resource_ids,omitempty"`
+}
+
+type taskRef struct {
+	tracker *TaskTracker
+	task    *Task
+}
+
+type taskGuidKey struct {
+	taskId string
+	guid   string
+}
+
+//runningTaskGuids maps the task ids and guids of the running inputs to their tasks for RecordTaskResources,
+//the same guid may be in two requests at once
+var runningTaskGuids = struct {
+	sync.Mutex
+	refs map[taskGuidKey]taskRef
+}{refs: make(map[taskGuidKey]taskRef)}
+
+//taskIdSetter is implemented by the inputs of the actions which call RecordTaskResources
+type taskIdSetter interface {
+	setTaskId(taskId string)
+}
+
+func (task *Task) String() string {
+	return fmt.Sprintf("task(%s) provider[%s]-version[%s]-plugin[%s]-action[%s]", task.Id, task.ProviderName, task.Version, task.Name, task.Action)
+}
+
+//Resumable tells whether the task loaded by LoadUnfinished can be run again
+func (task *Task) Resumable() bool {
+	return len(task.Parameters) > 0
+}
+
+//TaskTracker persists the records of the running tasks in storeDir and removes them when they finish.
+//Drain stops new tasks and waits for the running ones, records left on disk are resumed or reported by the next process
+type TaskTracker struct {
+	storeDir string
+	//secret encrypts the parameters of the resumable tasks, they are not resumed when it's empty
+	secret string
+
+	mutex    sync.Mutex
+	tasks    map[string]*Task
+	draining bool
+	done     chan struct{}
+}
+
+func NewTaskTracker(storeDir string, secret string) (*TaskTracker, error) {
+	if storeDir == "" {
+		return nil, fmt.Errorf("task store dir is empty")
+	}
+	if err := os.MkdirAll(storeDir, 0700); err != nil {
+		return nil, fmt.Errorf("create task store dir(%s) meet error=%v", storeDir, err)
+	}
+
+	return &TaskTracker{
+		storeDir: storeDir,
+		secret:   secret,
+		tasks:    make(map[string]*Task),
+	}, nil
+}
+
+//Run tracks the task, processes it and removes it when the action finishes
+func (tracker *TaskTracker) Run(task *Task) (*PluginResponse, error) {
+	if err := tracker.begin(task); err != nil {
+		return &PluginResponse{ResultCode: "1", ResultMsg: err.Error()}, err
+	}
+	defer tracker.end(task)
+
+	return Process(&PluginRequest{
+		TaskId:       task.Id,
+		Version:      task.Version,
+		ProviderName: task.ProviderName,
+		Name:         task.Name,
+		Action:       task.Action,
+		Parameters:   bytes.NewReader(task.Parameters),
+		CallbackUrl:  task.CallbackUrl,
+		StrictJson:   task.StrictJson,
+	})
+}
+
+func (tracker *TaskTracker) begin(task *Task) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if tracker.draining {
+		return fmt.Errorf("server is shutting down, %v is rejected", task)
+	}
+	if _, found := tracker.tasks[task.Id]; found {
+		return fmt.Errorf("%v is already running", task)
+	}
+	if task.StartTime.IsZero() {
+		task.StartTime = time.Now()
+	}
+	//a resumed task keeps the resources recorded by the previous process
+	if task.Inputs == nil {
+		task.Inputs = newTaskInputs(task.Parameters)
+	}
+	if resumableActions[task.Name+"/"+task.Action] && tracker.secret != "" {
+		resumeParameters, err := utils.AesEncode(tracker.aesKey(), string(task.Parameters))
+		if err != nil {
+			return fmt.Errorf("encrypt parameters of %v meet error=%v", task, err)
+		}
+		task.ResumeParameters = resumeParameters
+	}
+	if err := tracker.persist(task); err != nil {
+		return err
+	}
+	tracker.tasks[task.Id] = task
+
+	runningTaskGuids.Lock()
+	for _, input := range task.Inputs {
+		if input.Guid != "" {
+			runningTaskGuids.refs[taskGuidKey{taskId: task.Id, guid: input.Guid}] = taskRef{tracker: tracker, task: task}
+		}
+	}
+	runningTaskGuids.Unlock()
+	return nil
+}
+
+func (tracker *TaskTracker) end(task *Task) {
+	runningTaskGuids.Lock()
+	for _, input := range task.Inputs {
+		key := taskGuidKey{taskId: task.Id, guid: input.Guid}
+		if ref, found := runningTaskGuids.refs[key]; found && ref.task == task {
+			delete(runningTaskGuids.refs, key)
+		}
+	}
+	runningTaskGuids.Unlock()
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	delete(tracker.tasks, task.Id)
+	tracker.Remove(task)
+	if tracker.draining && len(tracker.tasks) == 0 && tracker.done != nil {
+		close(tracker.done)
+		tracker.done = nil
+	}
+}
+
+//newTaskInputs keeps the guid and region of each input, parameters which are not json have no inputs
+func newTaskInputs(parameters json.RawMessage) []TaskInput {
+	request := struct {
+		Inputs []map[string]interface{} `json:"inputs"`
+	}{}
+	if err := json.Unmarshal(parameters, &request); err != nil {
+		return nil
+	}
+
+	inputs := []TaskInput{}
+	for _, input := range request.Inputs {
+		taskInput := TaskInput{}
+		for key, value := range input {
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if strings.EqualFold(key, "guid") {
+				taskInput.Guid = str
+			}
+			if strings.EqualFold(key, "provider_params") {
+				if paramsMap, err := GetMapFromProviderParams(str); err == nil {
+					taskInput.Region = paramsMap["Region"]
+				}
+			}
+		}
+		inputs = append(inputs, taskInput)
+	}
+	return inputs
+}
+
+//RecordTaskResources records the resources created for the input with the guid in the running task,
+//it should be called as soon as the ids are returned by the create api
+func RecordTaskResources(taskId string, guid string, resourceIds ...string) {
+	runningTaskGuids.Lock()
+	ref, found := runningTaskGuids.refs[taskGuidKey{taskId: taskId, guid: guid}]
+	runningTaskGuids.Unlock()
+	if guid == "" || !found {
+		return
+	}
+	ref.tracker.addResources(ref.task, guid, resourceIds)
+}
+
+func (tracker *TaskTracker) addResources(task *Task, guid string, resourceIds []string) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	for i := range task.Inputs {
+		if task.Inputs[i].Guid == guid {
+			task.Inputs[i].ResourceIds = append(task.Inputs[i].ResourceIds, resourceIds...)
+		}
+	}
+	if _, running := tracker.tasks[task.Id]; !running {
+		return
+	}
+	if err := tracker.persist(task); err != nil {
+		logrus.Errorf("persist resources %v of %v meet error=%v", resourceIds, task, err)
+	}
+}
+
+//Drain rejects new tasks and waits for the running ones until timeout, the unfinished tasks are returned
+func (tracker *TaskTracker) Drain(timeout time.Duration) []*Task {
+	tracker.mutex.Lock()
+	tracker.draining = true
+	done := make(chan struct{})
+	if len(tracker.tasks) == 0 {
+		close(done)
+	} else {
+		tracker.done = done
+	}
+	tracker.mutex.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	unfinished := []*Task{}
+	for _, task := range tracker.tasks {
+		unfinished = append(unfinished, task)
+	}
+	return unfinished
+}
+
+//LoadUnfinished returns the records left by a previous process in start order, the resumable ones get their parameters
+//back and should be Run again, the others can't be run twice and the caller should Remove them once reported
+func (tracker *TaskTracker) LoadUnfinished() ([]*Task, error) {
+	files, err := ioutil.ReadDir(tracker.storeDir)
+	if err != nil {
+		return nil, fmt.Errorf("read task store dir(%s) meet error=%v", tracker.storeDir, err)
+	}
+
+	tasks := []*Task{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), TASK_FILE_SUFFIX) {
+			continue
+		}
+
+		fileName := filepath.Join(tracker.storeDir, file.Name())
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			logrus.Errorf("read task file(%s) meet error=%v", fileName, err)
+			continue
+		}
+		task := &Task{}
+		if err = json.Unmarshal(data, task); err != nil {
+			logrus.Errorf("unmarshal task file(%s) meet error=%v", fileName, err)
+			continue
+		}
+		if err = tracker.loadResumeParameters(task); err != nil {
+			logrus.Errorf("load parameters of %v meet error=%v", task, err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartTime.Before(tasks[j].StartTime) })
+	return tasks, nil
+}
+
+//loadResumeParameters decrypts the parameters of the resumable task and sets the id of each input
+//to the resource recorded for it, inputs with more resources are batch creates and have their ClientToken
+func (tracker *TaskTracker) loadResumeParameters(task *Task) error {
+	if task.ResumeParameters == "" || tracker.secret == "" {
+		return nil
+	}
+	parameters, err := utils.AesDecode(tracker.aesKey(), task.ResumeParameters)
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{}
+	if err = json.Unmarshal([]byte(parameters), &request); err != nil {
+		return err
+	}
+	inputs, _ := request["inputs"].([]interface{})
+	for i, input := range inputs {
+		inputMap, ok := input.(map[string]interface{})
+		if !ok || i >= len(task.Inputs) || len(task.Inputs[i].ResourceIds) != 1 {
+			continue
+		}
+		if id, _ := inputMap["id"].(string); id == "" {
+			inputMap["id"] = task.Inputs[i].ResourceIds[0]
+		}
+	}
+	if task.Parameters, err = json.Marshal(request); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tracker *TaskTracker) aesKey() string {
+	return utils.Md5Encode(tracker.secret)
+}
+
+//Remove deletes the record of the task
+func (tracker *TaskTracker) Remove(task *Task) {
+	fileName := tracker.fileName(task)
+	if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		logrus.Errorf("remove task file(%s) meet error=%v", fileName, err)
+	}
+}
+
+//NewInterruptedTaskResponse reports the task stopped by the previous process which can't be resumed as failed with the resources it created
+func NewInterruptedTaskResponse(task *Task) *PluginResponse {
+	return &PluginResponse{
+		ResultCode: "1",
+		ResultMsg:  fmt.Sprintf("%v was interrupted by restart, check the created resources before retrying it", task),
+		Results:    map[string][]TaskInput{"outputs": task.Inputs},
+	}
+}
+
+func (tracker *TaskTracker) fileName(task *Task) string {
+	return filepath.Join(tracker.storeDir, task.Id+TASK_FILE_SUFFIX)
+}
+
+func (tracker *TaskTracker) persist(task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	fileName := tracker.fileName(task)
+	tmpFileName := fileName + ".tmp"
+	if err = ioutil.WriteFile(tmpFileName, data, 0600); err != nil {
+		return fmt.Errorf("write task file(%s) meet error=%v", tmpFileName, err)
+	}
+	return os.Rename(tmpFileName, fileName)
+}
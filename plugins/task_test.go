@@ -0,0 +1,173 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type blockingTestPlugin struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (plugin *blockingTestPlugin) GetActionByName(actionName string) (Action, error) {
+	return plugin, nil
+}
+
+func (plugin *blockingTestPlugin) ReadParam(param interface{}) (interface{}, error) {
+	return param, nil
+}
+
+func (plugin *blockingTestPlugin) CheckParam(param interface{}) error {
+	return nil
+}
+
+func (plugin *blockingTestPlugin) Do(param interface{}) (interface{}, error) {
+	plugin.started <- struct{}{}
+	<-plugin.release
+	return nil, nil
+}
+
+var blockingTestPluginOnce sync.Once
+var testBlockingPlugin = &blockingTestPlugin{}
+
+//newBlockingTestPlugin resets the plugin for a new test, plugins can't be registered twice
+func newBlockingTestPlugin() *blockingTestPlugin {
+	blockingTestPluginOnce.Do(func() {
+		RegisterProviderPlugin("task-test", DEFAULT_API_VERSION, "blocking", testBlockingPlugin)
+	})
+	testBlockingPlugin.started = make(chan struct{}, 1)
+	testBlockingPlugin.release = make(chan struct{})
+	return testBlockingPlugin
+}
+
+func runTestTask(tracker *TaskTracker, task *Task) chan struct{} {
+	finished := make(chan struct{})
+	go func() {
+		tracker.Run(task)
+		close(finished)
+	}()
+	return finished
+}
+
+func TestUnitTaskTrackerDrain(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", "tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storeDir)
+
+	plugin := newBlockingTestPlugin()
+	tracker, err := NewTaskTracker(storeDir, "task-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parameters := `{"inputs":[{"Guid":"guid-1","provider_params":"Region=ap-guangzhou;SecretID=id;SecretKey=top-secret"}]}`
+	task := &Task{Id: "task-1", ProviderName: "task-test", Version: DEFAULT_API_VERSION, Name: "blocking", Action: "do", Parameters: []byte(parameters), CallbackUrl: "http://127.0.0.1/callback"}
+	finished := runTestTask(tracker, task)
+	<-plugin.started
+	RecordTaskResources("task-1", "guid-1", "ins-1")
+
+	if unfinished := tracker.Drain(10 * time.Millisecond); len(unfinished) != 1 {
+		t.Fatalf("drain should return the running task, unfinished=%v", unfinished)
+	}
+	if _, err = tracker.Run(&Task{Id: "task-2"}); err == nil {
+		t.Errorf("draining tracker should reject new tasks")
+	}
+
+	data, err := ioutil.ReadFile(tracker.fileName(task))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "top-secret") {
+		t.Errorf("task record should not keep the parameters, record=%s", data)
+	}
+
+	//the next process gets the record with the created resources rather than the request
+	next, _ := NewTaskTracker(storeDir, "task-secret")
+	tasks, err := next.LoadUnfinished()
+	if err != nil || len(tasks) != 1 || tasks[0].Id != "task-1" || len(tasks[0].Parameters) != 0 {
+		t.Fatalf("load unfinished tasks=%v err=%v", tasks, err)
+	}
+	if inputs := tasks[0].Inputs; len(inputs) != 1 || inputs[0].Region != "ap-guangzhou" || len(inputs[0].ResourceIds) != 1 || inputs[0].ResourceIds[0] != "ins-1" {
+		t.Errorf("task record inputs=%++v", inputs)
+	}
+
+	close(plugin.release)
+	<-finished
+	if tasks, _ = next.LoadUnfinished(); len(tasks) != 0 {
+		t.Errorf("task file should be removed after the task finished, tasks=%v", tasks)
+	}
+}
+
+func TestUnitTaskTrackerPersistsTaskWithoutCallback(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", "tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storeDir)
+
+	plugin := newBlockingTestPlugin()
+	tracker, _ := NewTaskTracker(storeDir, "task-secret")
+	finished := runTestTask(tracker, &Task{Id: "task-1", ProviderName: "task-test", Version: DEFAULT_API_VERSION, Name: "blocking", Action: "do", Parameters: []byte(`{"inputs":[{"guid":"guid-1"}]}`)})
+	<-plugin.started
+
+	//the same guid in another request is not mixed up with the running one
+	RecordTaskResources("task-2", "guid-1", "ins-2")
+	RecordTaskResources("task-1", "guid-1", "ins-1")
+	tasks, _ := tracker.LoadUnfinished()
+	if len(tasks) != 1 || tasks[0].Resumable() || len(tasks[0].Inputs) != 1 || len(tasks[0].Inputs[0].ResourceIds) != 1 || tasks[0].Inputs[0].ResourceIds[0] != "ins-1" {
+		t.Errorf("task without callback_url should be persisted with its resources, tasks=%++v", tasks)
+	}
+	close(plugin.release)
+	<-finished
+}
+
+func TestUnitTaskTrackerResumeVmCreate(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", "tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storeDir)
+
+	tracker, _ := NewTaskTracker(storeDir, "task-secret")
+	parameters := `{"inputs":[{"guid":"guid-1","provider_params":"SecretKey=top-secret"},{"guid":"guid-2","count":2}]}`
+	task := &Task{Id: "task-1", Name: "vm", Action: "create", Parameters: []byte(parameters)}
+	if err = tracker.begin(task); err != nil {
+		t.Fatal(err)
+	}
+	RecordTaskResources("task-1", "guid-1", "ins-1")
+	RecordTaskResources("task-1", "guid-2", "ins-2", "ins-3")
+
+	data, _ := ioutil.ReadFile(tracker.fileName(task))
+	if strings.Contains(string(data), "top-secret") {
+		t.Errorf("parameters should be encrypted, record=%s", data)
+	}
+
+	//the recorded instance is passed as id, the batch create has its ClientToken instead
+	next, _ := NewTaskTracker(storeDir, "task-secret")
+	tasks, err := next.LoadUnfinished()
+	if err != nil || len(tasks) != 1 || !tasks[0].Resumable() {
+		t.Fatalf("vm create should be resumable, tasks=%v err=%v", tasks, err)
+	}
+	inputs := VmInputs{}
+	if err = json.Unmarshal(tasks[0].Parameters, &inputs); err != nil || len(inputs.Inputs) != 2 {
+		t.Fatalf("resumed parameters=%s err=%v", tasks[0].Parameters, err)
+	}
+	if inputs.Inputs[0].Id != "ins-1" || inputs.Inputs[0].ProviderParams != "SecretKey=top-secret" || inputs.Inputs[1].Id != "" {
+		t.Errorf("resumed inputs=%++v not right", inputs.Inputs)
+	}
+
+	//without the secret the parameters can't be read back
+	noSecret, _ := NewTaskTracker(storeDir, "")
+	if tasks, _ = noSecret.LoadUnfinished(); len(tasks) != 1 || tasks[0].Resumable() {
+		t.Errorf("task should not be resumable without secret, tasks=%v", tasks)
+	}
+	tracker.end(task)
+}
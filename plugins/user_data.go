@@ -0,0 +1,103 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"text/template"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	USER_DATA_TEMPLATE_DIR = "./scripts/user_data"
+	USER_DATA_TEMPLATE_EXT = ".tpl"
+
+	//qcloud limits the base64 encoded user data to 16KB
+	MAX_USER_DATA_SIZE = 16 * 1024
+)
+
+var userDataTemplateNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+//UserDataTemplateData has the vm input fields which the user data templates can use,
+//the secrets like password and provider_params are left out since the user data is readable on the instance
+type UserDataTemplateData struct {
+	Guid         string
+	HostName     string
+	InstanceName string
+	InstanceType string
+	ImageId      string
+	VpcId        string
+	SubnetId     string
+	ProjectId    int64
+	Region       string
+	Zone         string
+}
+
+func newUserDataTemplateData(vm VmInput) UserDataTemplateData {
+	data := UserDataTemplateData{
+		Guid:         vm.Guid,
+		HostName:     vm.HostName,
+		InstanceName: vm.InstanceName,
+		InstanceType: vm.InstanceType,
+		ImageId:      vm.ImageId,
+		VpcId:        vm.VpcId,
+		SubnetId:     vm.SubnetId,
+		ProjectId:    vm.ProjectId,
+	}
+	if paramsMap, err := GetMapFromProviderParams(vm.ProviderParams); err == nil {
+		data.Region = paramsMap["Region"]
+		data.Zone = paramsMap["AvailableZone"]
+	}
+	return data
+}
+
+//renderUserDataTemplate renders the named template in USER_DATA_TEMPLATE_DIR with the non-secret vm input fields,
+//the fields used in shell scripts should be quoted, e.g. {{shellQuote .HostName}}
+func renderUserDataTemplate(templateDir string, name string, vm VmInput) (string, error) {
+	if !userDataTemplateNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("user data template name(%s) is invalid", name)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(templateDir, name+USER_DATA_TEMPLATE_EXT))
+	if err != nil {
+		logrus.Errorf("read user data template(%s) meet error=%v", name, err)
+		return "", fmt.Errorf("user data template(%s) not found", name)
+	}
+
+	tpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{"shellQuote": shellQuote}).Parse(string(content))
+	if err != nil {
+		return "", fmt.Errorf("parse user data template(%s) meet error=%v", name, err)
+	}
+
+	buffer := bytes.Buffer{}
+	if err = tpl.Execute(&buffer, newUserDataTemplateData(vm)); err != nil {
+		return "", fmt.Errorf("render user data template(%s) meet error=%v", name, err)
+	}
+	return buffer.String(), nil
+}
+
+//buildUserData returns the base64 encoded user data passed to RunInstances,
+//user_data_template takes precedence over the raw user_data
+func buildUserData(vm VmInput) (string, error) {
+	userData := vm.UserData
+	if vm.UserDataTemplate != "" {
+		rendered, err := renderUserDataTemplate(USER_DATA_TEMPLATE_DIR, vm.UserDataTemplate, vm)
+		if err != nil {
+			return "", err
+		}
+		userData = rendered
+	}
+	if userData == "" {
+		return "", nil
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(userData))
+	if len(encoded) > MAX_USER_DATA_SIZE {
+		return "", fmt.Errorf("user data is %d bytes after base64 encoded, more than %d bytes", len(encoded), MAX_USER_DATA_SIZE)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,301 @@
+package plugins
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//validate tag rules, separated by comma:
+//  required            value can't be empty
+//  required=a|b        value can't be empty when validating scene a or b
+//  enum=a|b            value should be one of a,b; add ignorecase to compare case-insensitively
+//  range=min-max       number or numeric string should be in [min,max]
+//  cidr                value should be a cidr block
+//  ip                  value should be an ip address
+//  region              value should be a region id like ap-guangzhou
+//  provider_params     value should be provider params with valid Region,SecretID and SecretKey
+//  port                value should be ALL or ports/port ranges separated by comma like 80,443,8000-8010
+//rules other than required are skipped when the value is empty, rules on a slice of basic type apply to every item
+const VALIDATE_TAG = "validate"
+
+var regionRegexp = regexp.MustCompile(`^[a-z]{2,}-[a-z]+(-[a-z0-9]+)*$`)
+
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (fieldError FieldError) Error() string {
+	return fmt.Sprintf("%s %s", fieldError.Path, fieldError.Message)
+}
+
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := []string{}
+	for _, fieldError := range errs {
+		messages = append(messages, fieldError.Error())
+	}
+	return "invalid parameters: " + strings.Join(messages, "; ")
+}
+
+type validateRule struct {
+	name string
+	arg  string
+}
+
+//ValidateInputs checks the validate tags of input recursively and returns all field errors at once,
+//scene selects the scoped rules such as required=create
+func ValidateInputs(input interface{}, scene string) error {
+	errs := ValidationErrors{}
+	validateValue(reflect.ValueOf(input), "", scene, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateValue(value reflect.Value, path string, scene string, errs *ValidationErrors) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		valueType := value.Type()
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			fieldPath := joinFieldPath(path, getJsonFieldName(field))
+			if tag := field.Tag.Get(VALIDATE_TAG); tag != "" && tag != "-" {
+				validateField(value.Field(i), fieldPath, parseValidateRules(tag), scene, errs)
+			}
+			validateValue(value.Field(i), fieldPath, scene, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			validateValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), scene, errs)
+		}
+	}
+}
+
+func getJsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func joinFieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func parseValidateRules(tag string) []validateRule {
+	rules := []validateRule{}
+	for _, item := range strings.Split(tag, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		rule := validateRule{name: kv[0]}
+		if len(kv) == 2 {
+			rule.arg = kv[1]
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func validateField(value reflect.Value, path string, rules []validateRule, scene string, errs *ValidationErrors) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			break
+		}
+		value = value.Elem()
+	}
+
+	ignoreCase := false
+	for _, rule := range rules {
+		if rule.name == "ignorecase" {
+			ignoreCase = true
+		}
+	}
+
+	empty := isEmptyValue(value)
+	for _, rule := range rules {
+		if rule.name == "required" {
+			if empty && isRuleInScene(rule, scene) {
+				*errs = append(*errs, FieldError{Path: path, Message: "is required"})
+				return
+			}
+			continue
+		}
+		if empty || rule.name == "ignorecase" {
+			continue
+		}
+
+		if (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && value.Type().Elem().Kind() != reflect.Struct {
+			for i := 0; i < value.Len(); i++ {
+				if message := checkRule(rule, value.Index(i), ignoreCase); message != "" {
+					*errs = append(*errs, FieldError{Path: fmt.Sprintf("%s[%d]", path, i), Message: message})
+				}
+			}
+			continue
+		}
+
+		if message := checkRule(rule, value, ignoreCase); message != "" {
+			*errs = append(*errs, FieldError{Path: path, Message: message})
+		}
+	}
+}
+
+func isRuleInScene(rule validateRule, scene string) bool {
+	if rule.arg == "" {
+		return true
+	}
+	for _, ruleScene := range strings.Split(rule.arg, "|") {
+		if ruleScene == scene {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.Invalid:
+		return true
+	}
+	return false
+}
+
+func checkRule(rule validateRule, value reflect.Value, ignoreCase bool) string {
+	switch rule.name {
+	case "enum":
+		return checkEnum(value, rule.arg, ignoreCase)
+	case "range":
+		return checkRange(value, rule.arg)
+	case "cidr":
+		if _, _, err := net.ParseCIDR(fmt.Sprint(value.Interface())); err != nil {
+			return fmt.Sprintf("value(%v) is not a valid cidr", value.Interface())
+		}
+	case "ip":
+		if net.ParseIP(fmt.Sprint(value.Interface())) == nil {
+			return fmt.Sprintf("value(%v) is not a valid ip", value.Interface())
+		}
+	case "region":
+		if !regionRegexp.MatchString(fmt.Sprint(value.Interface())) {
+			return fmt.Sprintf("value(%v) is not a valid region", value.Interface())
+		}
+	case "provider_params":
+		return checkProviderParams(fmt.Sprint(value.Interface()))
+	case "port":
+		return checkPorts(fmt.Sprint(value.Interface()))
+	default:
+		return fmt.Sprintf("has unknown validate rule(%s)", rule.name)
+	}
+	return ""
+}
+
+func checkEnum(value reflect.Value, arg string, ignoreCase bool) string {
+	str := fmt.Sprint(value.Interface())
+	validValues := strings.Split(arg, "|")
+	for _, validValue := range validValues {
+		if str == validValue || (ignoreCase && strings.EqualFold(str, validValue)) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("value(%s) should be one of %v", str, validValues)
+}
+
+func checkRange(value reflect.Value, arg string) string {
+	bounds := strings.SplitN(arg, "-", 2)
+	if len(bounds) != 2 {
+		return fmt.Sprintf("has invalid range rule(%s)", arg)
+	}
+	min, minErr := strconv.ParseFloat(bounds[0], 64)
+	max, maxErr := strconv.ParseFloat(bounds[1], 64)
+	if minErr != nil || maxErr != nil {
+		return fmt.Sprintf("has invalid range rule(%s)", arg)
+	}
+
+	var number float64
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		number = value.Float()
+	case reflect.String:
+		var err error
+		if number, err = strconv.ParseFloat(strings.TrimSpace(value.String()), 64); err != nil {
+			return fmt.Sprintf("value(%s) is not a number", value.String())
+		}
+	default:
+		return fmt.Sprintf("type %v does not support range rule", value.Type())
+	}
+
+	if number < min || number > max {
+		return fmt.Sprintf("value(%v) should be in range [%s, %s]", value.Interface(), bounds[0], bounds[1])
+	}
+	return ""
+}
+
+func checkProviderParams(providerParams string) string {
+	paramsMap, err := GetMapFromProviderParams(providerParams)
+	if err != nil {
+		return "is not in format key1=value1;key2=value2"
+	}
+	if !regionRegexp.MatchString(paramsMap["Region"]) {
+		return fmt.Sprintf("has invalid Region(%s)", paramsMap["Region"])
+	}
+	if paramsMap["SecretID"] == "" || paramsMap["SecretKey"] == "" {
+		return "should contain SecretID and SecretKey"
+	}
+	return ""
+}
+
+func checkPorts(ports string) string {
+	if strings.EqualFold(ports, "ALL") {
+		return ""
+	}
+	for _, port := range strings.Split(ports, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(port), "-", 2)
+		last := 0
+		for _, bound := range bounds {
+			portInt, err := strconv.Atoi(bound)
+			if err != nil || portInt <= 0 || portInt > 65535 || portInt < last {
+				return fmt.Sprintf("value(%s) is not a valid port or port range", ports)
+			}
+			last = portInt
+		}
+	}
+	return ""
+}
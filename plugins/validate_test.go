@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnitValidateInputs(t *testing.T) {
+	inputs := SubnetInputs{
+		Inputs: []SubnetInput{
+			{ProviderParams: "Region=ap-guangzhou;SecretID=id;SecretKey=key", Name: "subnet", VpcId: "vpc-1", CidrBlock: "10.0.1.0/24"},
+			{ProviderParams: "Region=Guangzhou;SecretID=id;SecretKey=key", Name: "subnet", CidrBlock: "10.0.1.0"},
+		},
+	}
+
+	if err := ValidateInputs(inputs, "terminate"); err == nil || !strings.Contains(err.Error(), "inputs[0].id is required") {
+		t.Errorf("terminate scene should require id, err=%v", err)
+	}
+
+	err := ValidateInputs(inputs, "create")
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("create scene should report 3 errors, err=%v", err)
+	}
+	for i, path := range []string{"inputs[1].provider_params", "inputs[1].cidr_block", "inputs[1].vpc_id"} {
+		if errs[i].Path != path {
+			t.Errorf("errs[%d].Path=%s, want %s", i, errs[i].Path, path)
+		}
+	}
+}
+
+func TestUnitValidatePortsAndRange(t *testing.T) {
+	policies := SecurityGroupPolicyInputs{
+		Inputs: []SecurityGroupPolicyInput{
+			{ProviderParams: "Region=ap-guangzhou;SecretID=id;SecretKey=key", Id: "sg-1", PolicyType: "Ingress", PolicyCidrBlock: "0.0.0.0/0", PolicyPort: "22,8000-8010", PolicyAction: "accept"},
+		},
+	}
+	if err := ValidateInputs(policies, ""); err != nil {
+		t.Errorf("valid policy meet err=%v", err)
+	}
+
+	policies.Inputs[0].PolicyPort = "8010-8000"
+	if err := ValidateInputs(policies, ""); err == nil {
+		t.Errorf("reversed port range should be invalid")
+	}
+
+	storages := StorageInputs{Inputs: []StorageInput{{ProviderParams: "Region=ap-guangzhou;SecretID=id;SecretKey=key", Id: "disk-1", DiskChargePeriod: "48"}}}
+	if err := ValidateInputs(storages, "terminate"); err == nil || !strings.Contains(err.Error(), "disk_charge_period") {
+		t.Errorf("disk_charge_period out of range should be invalid, err=%v", err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"encoding/json"
 	"errors"
+	"io"
+	"net"
 	"strconv"
 	"time"
         "strings"
@@ -17,6 +19,23 @@ import (
 
 const (
 	INSTANCE_STATE_RUNNING = "RUNNING"
+	INSTANCE_STATE_STOPPED = "STOPPED"
+
+	INSTANCE_TYPE_STATUS_SELL = "SELL"
+
+	OPERATION_STATE_SUCCESS = "SUCCESS"
+	OPERATION_STATE_FAILED  = "FAILED"
+
+	DEFAULT_SYSTEM_DISK_TYPE           = "CLOUD_PREMIUM"
+	DEFAULT_INTERNET_MAX_BANDWIDTH_OUT = 10
+
+	REBOOT_MODE_SOFT  = "soft"
+	REBOOT_MODE_FORCE = "force"
+
+	SSH_PORT          = 22
+	SSH_READY_TIMEOUT = 300
+	SSH_DIAL_TIMEOUT  = 5 * time.Second
+	SSH_BANNER_PREFIX = "SSH-"
 )
 
 const (
@@ -31,6 +50,9 @@ var (
 	VM_NOT_FOUND_ERROR          = errors.New("qcloud vm not found")
 )
 
+//interval between the polls of waitVmInDesireState and waitVmOperationDone, the timeouts count 5 seconds for each poll
+var vmStatePollInterval = 5 * time.Second
+
 type VmInputs struct {
 	Inputs []VmInput `json:"inputs,omitempty"`
 }
@@ -38,19 +60,61 @@ type VmInputs struct {
 type VmInput struct {
 	Guid                 string `json:"guid,omitempty"`
 	Seed                 string `json:"seed,omitempty"`
-	ProviderParams       string `json:"provider_params,omitempty"`
-	VpcId                string `json:"vpc_id,omitempty"`
-	SubnetId             string `json:"subnet_id,omitempty"`
+	ProviderParams       string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	VpcId                string `json:"vpc_id,omitempty" validate:"required=create"`
+	SubnetId             string `json:"subnet_id,omitempty" validate:"required=create"`
 	InstanceName         string `json:"instance_name,omitempty"`
-	Id                   string `json:"id,omitempty"`
-	InstanceType         string `json:"instance_type,omitempty"`
-	ImageId              string `json:"image_id,omitempty"`
+	Id                   string `json:"id,omitempty" validate:"required=operate|resize|reinstall"`
+	InstanceType         string `json:"instance_type,omitempty" validate:"required=create|resize"`
+	ImageId              string `json:"image_id,omitempty" validate:"required=create|reinstall"`
 	SystemDiskSize       int64  `json:"system_disk_size,omitempty"`
-	InstanceChargeType   string `json:"instance_charge_type,omitempty"`
+	SystemDiskType       string `json:"system_disk_type,omitempty" validate:"enum=CLOUD_BASIC|CLOUD_PREMIUM|CLOUD_SSD|LOCAL_BASIC|LOCAL_SSD"`
+	InstanceChargeType   string `json:"instance_charge_type,omitempty" validate:"enum=PREPAID|POSTPAID_BY_HOUR|SPOTPAID|CDHPAID"`
 	InstanceChargePeriod int64  `json:"instance_charge_period,omitempty"`
-	InstancePrivateIp    string `json:"instance_private_ip,omitempty"`
+	InstancePrivateIp    string `json:"instance_private_ip,omitempty" validate:"ip"`
 	Password             string `json:"password,omitempty"`
+	KeyIds               string `json:"key_ids,omitempty"`
 	ProjectId            int64  `json:"project_id,omitempty"`
+	RebootMode           string `json:"reboot_mode,omitempty" validate:"enum=soft|force,ignorecase"`
+
+	//internet_max_bandwidth_out is DEFAULT_INTERNET_MAX_BANDWIDTH_OUT when it's not set, 0 means no public bandwidth
+	DataDisks               []VmDataDiskInput `json:"data_disks,omitempty"`
+	PublicIpAssigned        bool              `json:"public_ip_assigned,omitempty"`
+	InternetChargeType      string            `json:"internet_charge_type,omitempty" validate:"enum=BANDWIDTH_PREPAID|TRAFFIC_POSTPAID_BY_HOUR|BANDWIDTH_POSTPAID_BY_HOUR|BANDWIDTH_PACKAGE"`
+	InternetMaxBandwidthOut *int64            `json:"internet_max_bandwidth_out,omitempty" validate:"range=0-1000"`
+
+	HostName string `json:"host_name,omitempty"`
+	//raw user data, it's base64 encoded by the plugin
+	UserData string `json:"user_data,omitempty"`
+	//name of the template in scripts/user_data, it's rendered with the non-secret vm input fields and takes precedence over user_data
+	UserDataTemplate string `json:"user_data_template,omitempty"`
+	//wait until ssh of the created instance answers, only for linux images whose port 22 is reachable from the plugin
+	WaitSshReady bool `json:"wait_ssh_ready,omitempty"`
+
+	//create count instances in batch, instance_name and host_name can have {index} pattern like app-{index},
+	//the batch is retried with the same guid without creating the instances again
+	Count int64 `json:"count,omitempty" validate:"range=0-100"`
+	//candidate zones separated by comma, AvailableZone of provider_params is used when it's empty
+	Zones             string `json:"zones,omitempty"`
+	PlacementStrategy string `json:"placement_strategy,omitempty" validate:"enum=spread|pack,ignorecase"`
+
+	//id of the task running the request, it's set by Process for RecordTaskResources
+	TaskId string `json:"-"`
+}
+
+func (vms VmInputs) setTaskId(taskId string) {
+	for i := range vms.Inputs {
+		vms.Inputs[i].TaskId = taskId
+	}
+}
+
+type VmDataDiskInput struct {
+	DiskType   string `json:"disk_type,omitempty" validate:"required,enum=CLOUD_BASIC|CLOUD_PREMIUM|CLOUD_SSD|LOCAL_BASIC|LOCAL_SSD"`
+	DiskSize   int64  `json:"disk_size,omitempty" validate:"required,range=10-32000"`
+	SnapshotId string `json:"snapshot_id,omitempty"`
+	Encrypt    bool   `json:"encrypt,omitempty"`
+	//the disk is deleted with the instance when it's not set
+	DeleteWithInstance *bool `json:"delete_with_instance,omitempty"`
 }
 
 type VmOutputs struct {
@@ -66,6 +130,12 @@ type VmOutput struct {
 	Password          string `json:"password,omitempty"`
 	InstanceState     string `json:"instance_state,omitempty"`
 	InstancePrivateIp string `json:"instance_private_ip,omitempty"`
+
+	//only set when creating vms in batch
+	Index        int64  `json:"index,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	InstanceName string `json:"instance_name,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
 }
 
 type VmPlugin struct{}
@@ -78,6 +148,11 @@ func init() {
 	VMActions["start"] = new(VMStartAction)
 	VMActions["stop"] = new(VMStopAction)
 	VMActions["bind-security-groups"] = new(VMBindSecurityGroupsAction)
+	VMActions["resize"] = new(VMResizeAction)
+	VMActions["reboot"] = new(VMRebootAction)
+	VMActions["reinstall"] = new(VMReinstallAction)
+	VMActions["reset-password"] = new(VMResetPasswordAction)
+	VMActions["query"] = new(VMQueryAction)
 }
 
 func (plugin *VmPlugin) GetActionByName(actionName string) (Action, error) {
@@ -106,13 +181,7 @@ func (action *VMAction) CheckParam(input interface{}) error {
 		return INVALID_PARAMETERS
 	}
 
-	for _, vm := range vms.Inputs {
-		if vm.Id == "" {
-			return errors.New("input instance_id is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(vms, "operate")
 }
 
 type QcloudRunInstanceStruct struct {
@@ -127,11 +196,15 @@ type QcloudRunInstanceStruct struct {
 	LoginSettings         LoginSettingsStruct       `json:"LoginSettings,omitempty"`
 	SecurityGroupIds      []string
 	InternetAccessible    InternetAccessible
+	InstanceCount         int64  `json:"InstanceCount,omitempty"`
+	HostName              string `json:"HostName,omitempty"`
+	UserData              string `json:"UserData,omitempty"`
 }
 
 type InternetAccessible struct {
-	PublicIpAssigned        bool `json:"PublicIpAssigned"`
-	InternetMaxBandwidthOut int  `json:"InternetMaxBandwidthOut"`
+	InternetChargeType      string `json:"InternetChargeType,omitempty"`
+	PublicIpAssigned        bool   `json:"PublicIpAssigned"`
+	InternetMaxBandwidthOut int64  `json:"InternetMaxBandwidthOut"`
 }
 
 type PlacementStruct struct {
@@ -150,7 +223,9 @@ type SystemDiskStruct struct {
 type DataDisksStruct struct {
 	DiskSize           int64
 	DiskType           string
-	DeleteWithInstance bool
+	DeleteWithInstance *bool  `json:"DeleteWithInstance,omitempty"`
+	SnapshotId         string `json:"SnapshotId,omitempty"`
+	Encrypt            bool   `json:"Encrypt,omitempty"`
 }
 type VirtualPrivateCloudStruct struct {
 	VpcId              string
@@ -158,20 +233,19 @@ type VirtualPrivateCloudStruct struct {
 	PrivateIpAddresses []string `json:"PrivateIpAddresses,omitempty"`
 }
 type LoginSettingsStruct struct {
-	Password string
+	Password string   `json:"Password,omitempty"`
+	KeyIds   []string `json:"KeyIds,omitempty"`
 }
 
 func createCvmClient(region, secretId, secretKey string) (client *cvm.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = QCLOUD_ENDPOINT_CVM
-
-	client, err = cvm.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("cvm", QCLOUD_ENDPOINT_CVM, region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return cvm.NewClient(credential, region, clientProfile)
+	})
 	if err != nil {
 		logrus.Errorf("Create Qcloud vm client failed,err=%v", err)
+		return nil, err
 	}
-	return
+	return cachedClient.(*cvm.Client), nil
 }
 
 func describeInstancesFromCvm(client *cvm.Client, describeInstancesParams cvm.DescribeInstancesRequest) (response *cvm.DescribeInstancesResponse, err error) {
@@ -223,7 +297,7 @@ func waitVmInDesireState(client *cvm.Client, instanceId string, desireState stri
 	count := 0
 
 	for {
-		time.Sleep(5 * time.Second)
+		time.Sleep(vmStatePollInterval)
 		instance, err := getInstanceByInstanceId(client, instanceId)
 		if err != nil {
 			return err
@@ -241,6 +315,34 @@ func waitVmInDesireState(client *cvm.Client, instanceId string, desireState stri
 	return nil
 }
 
+//waitVmOperationDone waits until the latest operation of the instance is the request and it's finished,
+//the instance may still be in the state before the operation right after the request is submitted
+func waitVmOperationDone(client *cvm.Client, instanceId string, requestId string, timeout int) error {
+	count := 0
+	for {
+		time.Sleep(vmStatePollInterval)
+		instance, err := getInstanceByInstanceId(client, instanceId)
+		if err != nil {
+			return err
+		}
+
+		if stringValue(instance.LatestOperationRequestId) == requestId {
+			switch stringValue(instance.LatestOperationState) {
+			case OPERATION_STATE_SUCCESS:
+				return nil
+			case OPERATION_STATE_FAILED:
+				return fmt.Errorf("%s of vm[%s] failed, RequestID is [%s]", stringValue(instance.LatestOperation), instanceId, requestId)
+			}
+		}
+
+		count++
+		if count*5 > timeout {
+			logrus.Errorf("wait operation(RequestID=%s) of vm[%s] done timeout", requestId, instanceId)
+			return VM_WAIT_STATE_TIMEOUT_ERROR
+		}
+	}
+}
+
 func waitVmTerminateDone(client *cvm.Client, instanceId string, timeout int) error {
 	count := 0
 	describeInstancesParams := cvm.DescribeInstancesRequest{
@@ -264,17 +366,95 @@ func waitVmTerminateDone(client *cvm.Client, instanceId string, timeout int) err
 	return nil
 }
 
+//isSshReady checks whether the ssh service answers its banner, an open port isn't enough since sshd may be still starting
+func isSshReady(ip string, port int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", ip, port), SSH_DIAL_TIMEOUT)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(SSH_DIAL_TIMEOUT))
+	banner := make([]byte, len(SSH_BANNER_PREFIX))
+	if _, err = io.ReadFull(conn, banner); err != nil {
+		return false
+	}
+	return string(banner) == SSH_BANNER_PREFIX
+}
+
+func waitVmSshReady(ip string, timeout int) error {
+	count := 0
+
+	for {
+		if isSshReady(ip, SSH_PORT) {
+			break
+		}
+
+		count++
+		if count*5 > timeout {
+			logrus.Errorf("wait ssh of vm(%s) ready timeout", ip)
+			return VM_WAIT_STATE_TIMEOUT_ERROR
+		}
+		time.Sleep(5 * time.Second)
+	}
+	return nil
+}
+
+func getInternetMaxBandwidthOut(vm VmInput) int64 {
+	if vm.InternetMaxBandwidthOut == nil {
+		return DEFAULT_INTERNET_MAX_BANDWIDTH_OUT
+	}
+	return *vm.InternetMaxBandwidthOut
+}
+
+func buildDataDisks(dataDisks []VmDataDiskInput) []DataDisksStruct {
+	disks := []DataDisksStruct{}
+	for _, dataDisk := range dataDisks {
+		disks = append(disks, DataDisksStruct{
+			DiskSize:           dataDisk.DiskSize,
+			DiskType:           dataDisk.DiskType,
+			DeleteWithInstance: dataDisk.DeleteWithInstance,
+			SnapshotId:         dataDisk.SnapshotId,
+			Encrypt:            dataDisk.Encrypt,
+		})
+	}
+	return disks
+}
+
+//encryptSecret encrypts the password or private key with the key derived from guid and seed, which is how WeCube stores it
+func encryptSecret(guid string, seed string, secret string) (string, error) {
+	md5sum := utils.Md5Encode(guid + seed)
+	encryptedSecret, err := utils.AesEncode(md5sum[0:16], secret)
+	if err != nil {
+		logrus.Errorf("AesEncode meet error(%v)", err)
+		return "", errors.New("aes encode error")
+	}
+	return encryptedSecret, nil
+}
+
 type VMCreateAction struct {
 	VMAction
 }
 
 func (action *VMCreateAction) CheckParam(input interface{}) error {
-	_, ok := input.(VmInputs)
+	vms, ok := input.(VmInputs)
 	if !ok {
 		return INVALID_PARAMETERS
 	}
 
-	return nil
+	for _, vm := range vms.Inputs {
+		//cvm accepts only one of the login settings
+		if vm.Password != "" && len(splitAndTrim(vm.KeyIds, ",")) > 0 {
+			return fmt.Errorf("vm(%s) should have either password or key_ids", vm.Guid)
+		}
+		if !isVmBatchCreate(vm) {
+			continue
+		}
+		if err := checkVmBatchCreateInput(vm); err != nil {
+			return err
+		}
+	}
+	return ValidateInputs(vms, "create")
 }
 
 func (action *VMCreateAction) Do(input interface{}) (interface{}, error) {
@@ -289,9 +469,22 @@ func (action *VMCreateAction) Do(input interface{}) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
-		if vm.Password == ""{
+		//instances logging in with keys only have no password
+		keyIds := splitAndTrim(vm.KeyIds, ",")
+		if vm.Password == "" && len(keyIds) == 0 {
 			vm.Password = utils.CreateRandomPassword()
 		}
+		if vm.SystemDiskType == "" {
+			vm.SystemDiskType = DEFAULT_SYSTEM_DISK_TYPE
+		}
+		//image_id can be the image name as well
+		if vm.ImageId, err = resolveImageId(client, vm.ImageId); err != nil {
+			return nil, err
+		}
+		userData, err := buildUserData(vm)
+		if err != nil {
+			return nil, err
+		}
 		
 		runInstanceRequest := QcloudRunInstanceStruct{
 			Placement: PlacementStruct{
@@ -301,20 +494,25 @@ func (action *VMCreateAction) Do(input interface{}) (interface{}, error) {
 			InstanceChargeType: vm.InstanceChargeType,
 			InstanceType:       vm.InstanceType,
 			SystemDisk: SystemDiskStruct{
-				DiskType: "CLOUD_PREMIUM",
+				DiskType: vm.SystemDiskType,
 				DiskSize: vm.SystemDiskSize,
 			},
+			DataDisks: buildDataDisks(vm.DataDisks),
 			VirtualPrivateCloud: VirtualPrivateCloudStruct{
 				VpcId:    vm.VpcId,
 				SubnetId: vm.SubnetId,
 			},
 			LoginSettings: LoginSettingsStruct{
 				Password: vm.Password,
+				KeyIds:   keyIds,
 			},
 			InternetAccessible: InternetAccessible{
-				PublicIpAssigned:        false,
-				InternetMaxBandwidthOut: 10,
+				InternetChargeType:      vm.InternetChargeType,
+				PublicIpAssigned:        vm.PublicIpAssigned,
+				InternetMaxBandwidthOut: getInternetMaxBandwidthOut(vm),
 			},
+			HostName: vm.HostName,
+			UserData: userData,
 		}
 		if vm.ProjectId != 0 {
 			runInstanceRequest.Placement.ProjectId=vm.ProjectId
@@ -331,6 +529,11 @@ func (action *VMCreateAction) Do(input interface{}) (interface{}, error) {
 			}
 		}
 
+		if isVmBatchCreate(vm) {
+			outputs.Outputs = append(outputs.Outputs, createVmInstancesInBatch(client, vm, runInstanceRequest, paramsMap["AvailableZone"])...)
+			continue
+		}
+
 		//check resources exsit
 		if vm.Id != "" {
 			describeInstancesParams := cvm.DescribeInstancesRequest{
@@ -404,39 +607,64 @@ func (action *VMCreateAction) Do(input interface{}) (interface{}, error) {
 		}
 
 		vm.Id = *resp.Response.InstanceIdSet[0]
+		RecordTaskResources(vm.TaskId, vm.Guid, vm.Id)
 		logrus.Infof("Create VM's request has been submitted, InstanceId is [%v], RequestID is [%v]", vm.Id, *resp.Response.RequestId)
 
-		if err = waitVmInDesireState(client, vm.Id, INSTANCE_STATE_RUNNING, 120); err != nil {
-			return nil, err
+		createdOutput, err := waitVmCreated(client, vm, vm.Id)
+		if err != nil {
+			//the instance has been created, the caller needs its id to retry or terminate it
+			outputs.Outputs = append(outputs.Outputs, VmOutput{Guid: vm.Guid, Id: vm.Id, ErrorMessage: err.Error()})
+			return &outputs, fmt.Errorf("vm[%s] is created but %v", vm.Id, err)
 		}
-		logrus.Infof("Created VM's state is [%v] now", INSTANCE_STATE_RUNNING)
+		outputs.Outputs = append(outputs.Outputs, *createdOutput)
+	}
 
-		describeInstancesParams := cvm.DescribeInstancesRequest{
-			InstanceIds: []*string{&vm.Id},
-		}
+	return &outputs, nil
+}
 
-		describeInstancesResponse, err := describeInstancesFromCvm(client, describeInstancesParams)
-		if err != nil {
+//waitVmCreated waits the created instance running and its ssh ready when wait_ssh_ready is set, then returns the output of it
+func waitVmCreated(client *cvm.Client, vm VmInput, instanceId string) (*VmOutput, error) {
+	if err := waitVmInDesireState(client, instanceId, INSTANCE_STATE_RUNNING, 120); err != nil {
+		return nil, err
+	}
+	logrus.Infof("Created VM[%v]'s state is [%v] now", instanceId, INSTANCE_STATE_RUNNING)
+
+	describeInstancesParams := cvm.DescribeInstancesRequest{
+		InstanceIds: []*string{&instanceId},
+	}
+
+	describeInstancesResponse, err := describeInstancesFromCvm(client, describeInstancesParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(describeInstancesResponse.Response.InstanceSet) == 0 {
+		return nil, VM_NOT_FOUND_ERROR
+	}
+	instance := describeInstancesResponse.Response.InstanceSet[0]
+
+	//running doesn't mean the os has been booted, wait until ssh answers
+	if vm.WaitSshReady {
+		if err = waitVmSshReady(*instance.PrivateIpAddresses[0], SSH_READY_TIMEOUT); err != nil {
 			return nil, err
 		}
+		logrus.Infof("Created VM[%v]'s ssh is ready now", instanceId)
+	}
 
-		md5sum := utils.Md5Encode(vm.Guid + vm.Seed)
-		if output.Password, err = utils.AesEncode(md5sum[0:16], vm.Password); err != nil {
-			logrus.Errorf("AesEncode meet error(%v)", err)
-			return nil, errors.New("aes encode error")
+	output := VmOutput{}
+	if vm.Password != "" {
+		if output.Password, err = encryptSecret(vm.Guid, vm.Seed, vm.Password); err != nil {
+			return nil, err
 		}
-
-		output.RequestId = *describeInstancesResponse.Response.RequestId
-		output.Guid = vm.Guid
-		output.Id = vm.Id
-		output.Memory = strconv.Itoa(int(*describeInstancesResponse.Response.InstanceSet[0].Memory))
-		output.Cpu = strconv.Itoa(int(*describeInstancesResponse.Response.InstanceSet[0].CPU))
-		output.InstanceState = *describeInstancesResponse.Response.InstanceSet[0].InstanceState
-		output.InstancePrivateIp = *describeInstancesResponse.Response.InstanceSet[0].PrivateIpAddresses[0]
-		outputs.Outputs = append(outputs.Outputs, output)
 	}
 
-	return &outputs, nil
+	output.RequestId = *describeInstancesResponse.Response.RequestId
+	output.Guid = vm.Guid
+	output.Id = instanceId
+	output.Memory = strconv.Itoa(int(*instance.Memory))
+	output.Cpu = strconv.Itoa(int(*instance.CPU))
+	output.InstanceState = *instance.InstanceState
+	output.InstancePrivateIp = *instance.PrivateIpAddresses[0]
+	return &output, nil
 }
 
 type VMTerminateAction struct {
@@ -448,40 +676,50 @@ func (action *VMTerminateAction) Do(input interface{}) (interface{}, error) {
 	outputs := VmOutputs{}
 
 	for _, vm := range vms.Inputs {
-		paramsMap, err := GetMapFromProviderParams(vm.ProviderParams)
-
-		terminateInstancesRequestData := cvm.TerminateInstancesRequest{
-			InstanceIds: []*string{&vm.Id},
-		}
-
-		client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+		output, err := action.terminateInstance(vm)
 		if err != nil {
 			return nil, err
 		}
-		terminateInstancesRequest := cvm.NewTerminateInstancesRequest()
-		byteTerminateInstancesRequestData, _ := json.Marshal(terminateInstancesRequestData)
-		terminateInstancesRequest.FromJsonString(string(byteTerminateInstancesRequestData))
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
 
-		response, err := client.TerminateInstances(terminateInstancesRequest)
-		if err != nil {
-			return nil, err
-		}
-		logrus.Infof("Terminate VM[%v] has been submitted in Qcloud, RequestID is [%v]", vm.Id, *response.Response.RequestId)
+	return &outputs, nil
+}
 
-		if err = waitVmTerminateDone(client, vm.Id, 600); err != nil {
-			return nil, err
-		}
-		output := VmOutput{}
-		output.RequestId = *response.Response.RequestId
-		output.Guid = vm.Guid
-		output.Id = vm.Id
+func (action *VMTerminateAction) terminateInstance(vm VmInput) (*VmOutput, error) {
+	unlock := LockResources("vm/terminate", vm.Id)
+	defer unlock()
 
-		outputs.Outputs = append(outputs.Outputs, output)
+	paramsMap, err := GetMapFromProviderParams(vm.ProviderParams)
 
-		logrus.Infof("Terminated VM[%v] has been done", vm.Id)
+	terminateInstancesRequestData := cvm.TerminateInstancesRequest{
+		InstanceIds: []*string{&vm.Id},
 	}
 
-	return &outputs, nil
+	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+	terminateInstancesRequest := cvm.NewTerminateInstancesRequest()
+	byteTerminateInstancesRequestData, _ := json.Marshal(terminateInstancesRequestData)
+	terminateInstancesRequest.FromJsonString(string(byteTerminateInstancesRequestData))
+
+	response, err := client.TerminateInstances(terminateInstancesRequest)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("Terminate VM[%v] has been submitted in Qcloud, RequestID is [%v]", vm.Id, *response.Response.RequestId)
+
+	if err = waitVmTerminateDone(client, vm.Id, 600); err != nil {
+		return nil, err
+	}
+	output := VmOutput{}
+	output.RequestId = *response.Response.RequestId
+	output.Guid = vm.Guid
+	output.Id = vm.Id
+
+	logrus.Infof("Terminated VM[%v] has been done", vm.Id)
+	return &output, nil
 }
 
 type VMStartAction struct {
@@ -508,6 +746,9 @@ func (action *VMStartAction) Do(input interface{}) (interface{}, error) {
 }
 
 func (action *VMStartAction) startInstance(vm VmInput) (string, error) {
+	unlock := LockResources("vm/start", vm.Id)
+	defer unlock()
+
 	paramsMap, _ := GetMapFromProviderParams(vm.ProviderParams)
 
 	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
@@ -545,6 +786,9 @@ func (action *VMStopAction) Do(input interface{}) (interface{}, error) {
 }
 
 func (action *VMStopAction) stopInstance(vm *VmInput) (*VmOutput, error) {
+	unlock := LockResources("vm/stop", vm.Id)
+	defer unlock()
+
 	paramsMap, _ := GetMapFromProviderParams(vm.ProviderParams)
 
 	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
@@ -570,9 +814,6 @@ func (action *VMStopAction) stopInstance(vm *VmInput) (*VmOutput, error) {
 
 func QueryCvmInstance(providerParams string, filter Filter) ([]*cvm.Instance, error) {
 	validFilterNames := []string{"instanceId", "privateIpAddress"}
-	filterValues := common.StringPtrs(filter.Values)
-	var limit int64
-
 	paramsMap, err := GetMapFromProviderParams(providerParams)
 	if err != nil {
 		return nil, err
@@ -586,30 +827,42 @@ func QueryCvmInstance(providerParams string, filter Filter) ([]*cvm.Instance, er
 		return nil, err
 	}
 
-	request := cvm.NewDescribeInstancesRequest()
-	limit = int64(len(filterValues))
-	request.Limit = &limit
 	name, err := TransLittleCamelcaseToShortLineFormat(filter.Name)
 	if err != nil {
 		return nil, err
 	}
-	
-	cvmFilter := &cvm.Filter{
-		Name:   common.StringPtr(name),
-		Values: common.StringPtrs(filter.Values),
-	}
-	request.Filters = append(request.Filters, cvmFilter)
 
-	response, err := client.DescribeInstances(request)
+	instances := []*cvm.Instance{}
+	err = PaginateByOffset(func(offset int, limit int) (int, int, error) {
+		request := cvm.NewDescribeInstancesRequest()
+		request.Offset = common.Int64Ptr(int64(offset))
+		request.Limit = common.Int64Ptr(int64(limit))
+		cvmFilter := &cvm.Filter{
+			Name:   common.StringPtr(name),
+			Values: common.StringPtrs(filter.Values),
+		}
+		request.Filters = append(request.Filters, cvmFilter)
+
+		response, err := client.DescribeInstances(request)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		instances = append(instances, response.Response.InstanceSet...)
+		return len(response.Response.InstanceSet), int(*response.Response.TotalCount), nil
+	})
 	if err != nil {
 		logrus.Errorf("cvm DescribeInstances meet err=%v", err)
 		return nil, err
 	}
 
-	return response.Response.InstanceSet, nil
+	return instances, nil
 }
 
 func BindCvmInstanceSecurityGroups(providerParams string, instanceId string, securityGroups []string) error {
+	unlock := LockResources("vm/bind-security-groups", instanceId)
+	defer unlock()
+
 	paramsMap, err := GetMapFromProviderParams(providerParams)
 	if err != nil {
 		return err
@@ -640,9 +893,9 @@ type VmBindSecurityGroupInputs struct {
 
 type VmBindSecurityGroupInput struct {
 	Guid                 string `json:"guid,omitempty"`
-	ProviderParams       string `json:"provider_params,omitempty"`
-	InstanceId           string `json:"instance_id,omitempty"`
-	SecurityGroupIds     string `json:"security_group_ids,omitempty"`
+	ProviderParams       string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	InstanceId           string `json:"instance_id,omitempty" validate:"required"`
+	SecurityGroupIds     string `json:"security_group_ids,omitempty" validate:"required"`
 }
 
 type VmBindSecurityGroupOutputs struct {
@@ -668,21 +921,7 @@ func (action *VMBindSecurityGroupsAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("VMBindSecurityGroupsAction:input type=%T not right", input)
 	}
 
-	for _,input:=range inputs.Inputs{
-		if input.ProviderParams == ""{
-			return errors.New("providerParams is empty")
-		}
-
-		if input.InstanceId == "" {
-			return errors.New("instanceId is empty")
-		}
-
-		if input.SecurityGroupIds == "" {
-			return errors.New("securityGroupIds is empty")
-		}
-	}
-
-	return nil
+	return ValidateInputs(inputs, "")
 }
 
 func (action *VMBindSecurityGroupsAction)Do(input interface{}) (interface{}, error) {
@@ -703,3 +942,431 @@ func (action *VMBindSecurityGroupsAction)Do(input interface{}) (interface{}, err
 
 	return outputs,nil 
 }
+
+//--------------resize vm--------------------//
+type VMResizeAction struct {
+	VMAction
+}
+
+func (action *VMResizeAction) CheckParam(input interface{}) error {
+	vms, ok := input.(VmInputs)
+	if !ok {
+		return INVALID_PARAMETERS
+	}
+
+	return ValidateInputs(vms, "resize")
+}
+
+func (action *VMResizeAction) Do(input interface{}) (interface{}, error) {
+	vms, _ := input.(VmInputs)
+	outputs := VmOutputs{}
+	for _, vm := range vms.Inputs {
+		output, err := action.resizeInstance(&vm)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//resizeInstance stops the running instance, changes its instance type and starts it again
+func (action *VMResizeAction) resizeInstance(vm *VmInput) (*VmOutput, error) {
+	unlock := LockResources("vm/resize", vm.Id)
+	defer unlock()
+
+	paramsMap, _ := GetMapFromProviderParams(vm.ProviderParams)
+	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := getInstanceByInstanceId(client, vm.Id)
+	if err != nil {
+		return nil, err
+	}
+	originalState := *instance.InstanceState
+
+	//the instance stopped for resizing is started again when the resize fails
+	stopped := false
+	defer func() {
+		if stopped {
+			restartStoppedInstance(client, vm.Id)
+		}
+	}()
+
+	requestId := ""
+	if *instance.InstanceType != vm.InstanceType {
+		if err = checkInstanceTypeOnSale(client, *instance.Placement.Zone, vm.InstanceType, *instance.InstanceChargeType); err != nil {
+			return nil, err
+		}
+
+		if originalState == INSTANCE_STATE_RUNNING {
+			if err = stopInstanceAndWait(client, vm.Id); err != nil {
+				return nil, err
+			}
+			stopped = true
+		}
+
+		request := cvm.NewResetInstancesTypeRequest()
+		request.InstanceIds = []*string{&vm.Id}
+		request.InstanceType = &vm.InstanceType
+		response, err := client.ResetInstancesType(request)
+		if err != nil {
+			logrus.Errorf("cvm ResetInstancesType of vm[%s] meet error=%v", vm.Id, err)
+			return nil, err
+		}
+		requestId = *response.Response.RequestId
+		logrus.Infof("resize vm[%s] from %s to %s has been submitted, RequestID is [%v]", vm.Id, *instance.InstanceType, vm.InstanceType, requestId)
+
+		if err = waitVmInstanceType(client, vm.Id, vm.InstanceType, 600); err != nil {
+			return nil, err
+		}
+
+		if originalState == INSTANCE_STATE_RUNNING {
+			stopped = false
+			if err = startInstanceAndWait(client, vm.Id); err != nil {
+				return nil, err
+			}
+		}
+
+		if instance, err = getInstanceByInstanceId(client, vm.Id); err != nil {
+			return nil, err
+		}
+	} else {
+		logrus.Infof("vm[%s] is already of instance type %s", vm.Id, vm.InstanceType)
+	}
+
+	output := VmOutput{}
+	output.RequestId = requestId
+	output.Guid = vm.Guid
+	output.Id = vm.Id
+	output.Cpu = strconv.Itoa(int(*instance.CPU))
+	output.Memory = strconv.Itoa(int(*instance.Memory))
+	output.InstanceState = *instance.InstanceState
+	if len(instance.PrivateIpAddresses) > 0 {
+		output.InstancePrivateIp = *instance.PrivateIpAddresses[0]
+	}
+
+	return &output, nil
+}
+
+func checkInstanceTypeOnSale(client *cvm.Client, zone string, instanceType string, instanceChargeType string) error {
+	request := cvm.NewDescribeZoneInstanceConfigInfosRequest()
+	request.Filters = []*cvm.Filter{
+		&cvm.Filter{Name: common.StringPtr("zone"), Values: common.StringPtrs([]string{zone})},
+		&cvm.Filter{Name: common.StringPtr("instance-type"), Values: common.StringPtrs([]string{instanceType})},
+		&cvm.Filter{Name: common.StringPtr("instance-charge-type"), Values: common.StringPtrs([]string{instanceChargeType})},
+	}
+
+	response, err := client.DescribeZoneInstanceConfigInfos(request)
+	if err != nil {
+		logrus.Errorf("cvm DescribeZoneInstanceConfigInfos meet error=%v", err)
+		return err
+	}
+
+	for _, item := range response.Response.InstanceTypeQuotaSet {
+		if *item.InstanceType == instanceType && item.Status != nil && *item.Status == INSTANCE_TYPE_STATUS_SELL {
+			return nil
+		}
+	}
+	return fmt.Errorf("instance type %s(%s) is not on sale in zone %s", instanceType, instanceChargeType, zone)
+}
+
+//stopInstanceAndWait is used when the caller already holds the lock of the instance
+func stopInstanceAndWait(client *cvm.Client, instanceId string) error {
+	request := cvm.NewStopInstancesRequest()
+	request.InstanceIds = []*string{&instanceId}
+	response, err := client.StopInstances(request)
+	if err != nil {
+		logrus.Errorf("cvm StopInstances of vm[%s] meet error=%v", instanceId, err)
+		return err
+	}
+	logrus.Infof("stop vm[%s] has been submitted, RequestID is [%v]", instanceId, *response.Response.RequestId)
+
+	return waitVmInDesireState(client, instanceId, INSTANCE_STATE_STOPPED, 300)
+}
+
+//startInstanceAndWait is used when the caller already holds the lock of the instance
+func startInstanceAndWait(client *cvm.Client, instanceId string) error {
+	request := cvm.NewStartInstancesRequest()
+	request.InstanceIds = []*string{&instanceId}
+	response, err := client.StartInstances(request)
+	if err != nil {
+		logrus.Errorf("cvm StartInstances of vm[%s] meet error=%v", instanceId, err)
+		return err
+	}
+	logrus.Infof("start vm[%s] has been submitted, RequestID is [%v]", instanceId, *response.Response.RequestId)
+
+	return waitVmInDesireState(client, instanceId, INSTANCE_STATE_RUNNING, 300)
+}
+
+//restartStoppedInstance starts the instance stopped by a failed action, so the failure doesn't leave it stopped
+func restartStoppedInstance(client *cvm.Client, instanceId string) {
+	if err := startInstanceAndWait(client, instanceId); err != nil {
+		logrus.Errorf("start vm[%s] stopped by the failed action meet error=%v", instanceId, err)
+	}
+}
+
+//waitVmInstanceType waits until the instance is of the instance type and the resize is finished
+func waitVmInstanceType(client *cvm.Client, instanceId string, instanceType string, timeout int) error {
+	count := 0
+	for {
+		time.Sleep(5 * time.Second)
+		instance, err := getInstanceByInstanceId(client, instanceId)
+		if err != nil {
+			return err
+		}
+
+		if *instance.InstanceType == instanceType && *instance.InstanceState == INSTANCE_STATE_STOPPED {
+			break
+		}
+
+		count++
+		if count*5 > timeout {
+			return VM_WAIT_STATE_TIMEOUT_ERROR
+		}
+	}
+	return nil
+}
+
+//--------------reboot vm--------------------//
+type VMRebootAction struct {
+	VMAction
+}
+
+func (action *VMRebootAction) Do(input interface{}) (interface{}, error) {
+	vms, _ := input.(VmInputs)
+	outputs := VmOutputs{}
+	for _, vm := range vms.Inputs {
+		output, err := action.rebootInstance(&vm)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//rebootInstance shuts down the instance normally in soft mode, force mode powers it off directly
+func (action *VMRebootAction) rebootInstance(vm *VmInput) (*VmOutput, error) {
+	unlock := LockResources("vm/reboot", vm.Id)
+	defer unlock()
+
+	paramsMap, _ := GetMapFromProviderParams(vm.ProviderParams)
+	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	stopType := "SOFT"
+	if strings.EqualFold(vm.RebootMode, REBOOT_MODE_FORCE) {
+		stopType = "HARD"
+	}
+	request := cvm.NewRebootInstancesRequest()
+	request.InstanceIds = []*string{&vm.Id}
+	request.StopType = &stopType
+
+	response, err := client.RebootInstances(request)
+	if err != nil {
+		logrus.Errorf("cvm RebootInstances of vm[%s] meet error=%v", vm.Id, err)
+		return nil, err
+	}
+	logrus.Infof("reboot vm[%s] with stop type %s has been submitted, RequestID is [%v]", vm.Id, stopType, *response.Response.RequestId)
+
+	if err = waitVmOperationDone(client, vm.Id, *response.Response.RequestId, 300); err != nil {
+		return nil, err
+	}
+	if err = waitVmInDesireState(client, vm.Id, INSTANCE_STATE_RUNNING, 300); err != nil {
+		return nil, err
+	}
+
+	output := VmOutput{}
+	output.RequestId = *response.Response.RequestId
+	output.Guid = vm.Guid
+	output.Id = vm.Id
+	output.InstanceState = INSTANCE_STATE_RUNNING
+
+	return &output, nil
+}
+
+//--------------reinstall vm--------------------//
+type VMReinstallAction struct {
+	VMAction
+}
+
+func (action *VMReinstallAction) CheckParam(input interface{}) error {
+	vms, ok := input.(VmInputs)
+	if !ok {
+		return INVALID_PARAMETERS
+	}
+
+	return ValidateInputs(vms, "reinstall")
+}
+
+func (action *VMReinstallAction) Do(input interface{}) (interface{}, error) {
+	vms, _ := input.(VmInputs)
+	outputs := VmOutputs{}
+	for _, vm := range vms.Inputs {
+		output, err := action.reinstallInstance(&vm)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//reinstallInstance reinstalls the system disk of the instance with image_id and resets its password
+func (action *VMReinstallAction) reinstallInstance(vm *VmInput) (*VmOutput, error) {
+	unlock := LockResources("vm/reinstall", vm.Id)
+	defer unlock()
+
+	paramsMap, _ := GetMapFromProviderParams(vm.ProviderParams)
+	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.Password == "" {
+		vm.Password = utils.CreateRandomPassword()
+	}
+	if vm.ImageId, err = resolveImageId(client, vm.ImageId); err != nil {
+		return nil, err
+	}
+
+	request := cvm.NewResetInstanceRequest()
+	request.InstanceId = &vm.Id
+	request.ImageId = &vm.ImageId
+	request.LoginSettings = &cvm.LoginSettings{Password: &vm.Password}
+
+	response, err := client.ResetInstance(request)
+	if err != nil {
+		logrus.Errorf("cvm ResetInstance of vm[%s] meet error=%v", vm.Id, err)
+		return nil, err
+	}
+	logrus.Infof("reinstall vm[%s] with image %s has been submitted, RequestID is [%v]", vm.Id, vm.ImageId, *response.Response.RequestId)
+
+	if err = waitVmOperationDone(client, vm.Id, *response.Response.RequestId, 600); err != nil {
+		return nil, err
+	}
+	if err = waitVmInDesireState(client, vm.Id, INSTANCE_STATE_RUNNING, 600); err != nil {
+		return nil, err
+	}
+
+	instance, err := getInstanceByInstanceId(client, vm.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	output := VmOutput{}
+	if output.Password, err = encryptSecret(vm.Guid, vm.Seed, vm.Password); err != nil {
+		return nil, err
+	}
+
+	output.RequestId = *response.Response.RequestId
+	output.Guid = vm.Guid
+	output.Id = vm.Id
+	output.Cpu = strconv.Itoa(int(*instance.CPU))
+	output.Memory = strconv.Itoa(int(*instance.Memory))
+	output.InstanceState = *instance.InstanceState
+	if len(instance.PrivateIpAddresses) > 0 {
+		output.InstancePrivateIp = *instance.PrivateIpAddresses[0]
+	}
+
+	return &output, nil
+}
+
+//--------------reset vm password--------------------//
+type VMResetPasswordAction struct {
+	VMAction
+}
+
+func (action *VMResetPasswordAction) Do(input interface{}) (interface{}, error) {
+	vms, _ := input.(VmInputs)
+	outputs := VmOutputs{}
+	for _, vm := range vms.Inputs {
+		output, err := action.resetPassword(&vm)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+func (action *VMResetPasswordAction) resetPassword(vm *VmInput) (*VmOutput, error) {
+	unlock := LockResources("vm/reset-password", vm.Id)
+	defer unlock()
+
+	paramsMap, _ := GetMapFromProviderParams(vm.ProviderParams)
+	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+	return resetVmPassword(client, vm)
+}
+
+//resetVmPassword resets the password of the stopped instance, a running instance is stopped first and started again
+func resetVmPassword(client *cvm.Client, vm *VmInput) (*VmOutput, error) {
+	instance, err := getInstanceByInstanceId(client, vm.Id)
+	if err != nil {
+		return nil, err
+	}
+	originalState := *instance.InstanceState
+
+	//the instance stopped for resetting is started again when the reset fails
+	stopped := false
+	defer func() {
+		if stopped {
+			restartStoppedInstance(client, vm.Id)
+		}
+	}()
+	if originalState == INSTANCE_STATE_RUNNING {
+		if err = stopInstanceAndWait(client, vm.Id); err != nil {
+			return nil, err
+		}
+		stopped = true
+	}
+
+	if vm.Password == "" {
+		vm.Password = utils.CreateRandomPassword()
+	}
+
+	request := cvm.NewResetInstancesPasswordRequest()
+	request.InstanceIds = []*string{&vm.Id}
+	request.Password = &vm.Password
+	response, err := client.ResetInstancesPassword(request)
+	if err != nil {
+		logrus.Errorf("cvm ResetInstancesPassword of vm[%s] meet error=%v", vm.Id, err)
+		return nil, err
+	}
+	logrus.Infof("reset password of vm[%s] has been submitted, RequestID is [%v]", vm.Id, *response.Response.RequestId)
+
+	//the instance stays stopped during the reset, so the reset operation itself is waited
+	if err = waitVmOperationDone(client, vm.Id, *response.Response.RequestId, 300); err != nil {
+		return nil, err
+	}
+	if originalState == INSTANCE_STATE_RUNNING {
+		stopped = false
+		if err = startInstanceAndWait(client, vm.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	output := VmOutput{}
+	if output.Password, err = encryptSecret(vm.Guid, vm.Seed, vm.Password); err != nil {
+		return nil, err
+	}
+
+	output.RequestId = *response.Response.RequestId
+	output.Guid = vm.Guid
+	output.Id = vm.Id
+	output.InstanceState = originalState
+
+	return &output, nil
+}
@@ -0,0 +1,170 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	//index of the instance in the batch, it starts from 1
+	VM_INDEX_PATTERN = "{index}"
+	//appended to instance_name without {index} when creating more than one instance
+	VM_DEFAULT_INDEX_SUFFIX = "-" + VM_INDEX_PATTERN
+
+	//spread distributes the instances across the zones evenly,
+	//pack creates all the instances in the first zone and falls back to the next zone when it fails
+	PLACEMENT_STRATEGY_SPREAD = "spread"
+	PLACEMENT_STRATEGY_PACK   = "pack"
+)
+
+type vmZonePlacement struct {
+	Zone       string
+	StartIndex int64
+	Count      int64
+}
+
+func isVmBatchCreate(vm VmInput) bool {
+	return vm.Count > 1 || vm.Zones != ""
+}
+
+func checkVmBatchCreateInput(vm VmInput) error {
+	if vm.Id != "" {
+		return fmt.Errorf("id can't be used when creating vms in batch")
+	}
+	if vm.InstancePrivateIp != "" {
+		return fmt.Errorf("instance_private_ip can't be used when creating vms in batch")
+	}
+	return nil
+}
+
+//planVmPlacements decides how many instances are created in each zone and the index range of them
+func planVmPlacements(zones []string, count int64, strategy string) []vmZonePlacement {
+	placements := []vmZonePlacement{}
+	if len(zones) == 0 || count <= 0 {
+		return placements
+	}
+
+	if strings.ToLower(strategy) == PLACEMENT_STRATEGY_PACK {
+		return append(placements, vmZonePlacement{Zone: zones[0], StartIndex: 1, Count: count})
+	}
+
+	zoneCount := int64(len(zones))
+	startIndex := int64(1)
+	for i, zone := range zones {
+		zoneInstances := count / zoneCount
+		if int64(i) < count%zoneCount {
+			zoneInstances++
+		}
+		if zoneInstances == 0 {
+			continue
+		}
+		placements = append(placements, vmZonePlacement{Zone: zone, StartIndex: startIndex, Count: zoneInstances})
+		startIndex += zoneInstances
+	}
+	return placements
+}
+
+//toQcloudIndexPattern converts {index} to {R:x} of RunInstances, which generates the numbers from x for the instances in one request
+func toQcloudIndexPattern(pattern string, startIndex int64) string {
+	return strings.Replace(pattern, VM_INDEX_PATTERN, fmt.Sprintf("{R:%d}", startIndex), -1)
+}
+
+func renderVmIndexPattern(pattern string, index int64) string {
+	return strings.Replace(pattern, VM_INDEX_PATTERN, strconv.FormatInt(index, 10), -1)
+}
+
+//buildRunInstancesClientToken derives the ClientToken of RunInstances from the guid and the placement,
+//so a retried batch gets the instances created before rather than new ones, it's empty without guid
+func buildRunInstancesClientToken(guid string, placement vmZonePlacement) string {
+	if guid == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%d/%d", guid, placement.Zone, placement.StartIndex, placement.Count)))
+	return hex.EncodeToString(sum[:])
+}
+
+func runVmInstancesInZone(client *cvm.Client, vm VmInput, runInstanceRequest QcloudRunInstanceStruct, placement vmZonePlacement) ([]string, error) {
+	runInstanceRequest.Placement.Zone = placement.Zone
+	runInstanceRequest.InstanceCount = placement.Count
+	runInstanceRequest.HostName = toQcloudIndexPattern(vm.HostName, placement.StartIndex)
+
+	request := cvm.NewRunInstancesRequest()
+	byteRunInstancesRequestData, _ := json.Marshal(runInstanceRequest)
+	logrus.Debugf("byteRunInstancesRequestData=%v", string(byteRunInstancesRequestData))
+	request.FromJsonString(string(byteRunInstancesRequestData))
+	if vm.InstanceName != "" {
+		instanceName := toQcloudIndexPattern(vm.InstanceName, placement.StartIndex)
+		request.InstanceName = &instanceName
+	}
+	if clientToken := buildRunInstancesClientToken(vm.Guid, placement); clientToken != "" {
+		request.ClientToken = &clientToken
+	}
+
+	response, err := client.RunInstances(request)
+	if err != nil {
+		logrus.Errorf("cvm RunInstances in zone(%s) with %d instances meet error=%v", placement.Zone, placement.Count, err)
+		return nil, err
+	}
+	logrus.Infof("Create %d VMs in zone[%v] has been submitted, RequestID is [%v]", placement.Count, placement.Zone, *response.Response.RequestId)
+	instanceIds := stringValues(response.Response.InstanceIdSet)
+	RecordTaskResources(vm.TaskId, vm.Guid, instanceIds...)
+	return instanceIds, nil
+}
+
+//createVmInstancesInBatch creates vm.Count instances in the candidate zones, it returns one output for each instance
+//and the failed instances have the error message in their outputs rather than failing the whole batch
+func createVmInstancesInBatch(client *cvm.Client, vm VmInput, runInstanceRequest QcloudRunInstanceStruct, defaultZone string) []VmOutput {
+	zones := splitAndTrim(vm.Zones, ",")
+	if len(zones) == 0 {
+		zones = []string{defaultZone}
+	}
+	count := vm.Count
+	if count <= 0 {
+		count = 1
+	}
+	//qcloud appends 1..n to the names of each request, which is duplicated across zones
+	if vm.InstanceName != "" && count > 1 && !strings.Contains(vm.InstanceName, VM_INDEX_PATTERN) {
+		vm.InstanceName += VM_DEFAULT_INDEX_SUFFIX
+	}
+
+	outputs := []VmOutput{}
+	for _, placement := range planVmPlacements(zones, count, vm.PlacementStrategy) {
+		instanceIds, err := runVmInstancesInZone(client, vm, runInstanceRequest, placement)
+		if err != nil && strings.ToLower(vm.PlacementStrategy) == PLACEMENT_STRATEGY_PACK {
+			for _, zone := range zones[1:] {
+				placement.Zone = zone
+				if instanceIds, err = runVmInstancesInZone(client, vm, runInstanceRequest, placement); err == nil {
+					break
+				}
+			}
+		}
+
+		for i := int64(0); i < placement.Count; i++ {
+			index := placement.StartIndex + i
+			output := &VmOutput{Guid: vm.Guid}
+			if err != nil {
+				output.ErrorMessage = err.Error()
+			} else if i >= int64(len(instanceIds)) {
+				output.ErrorMessage = "instance is not created"
+			} else if createdOutput, waitErr := waitVmCreated(client, vm, instanceIds[i]); waitErr != nil {
+				output.Id = instanceIds[i]
+				output.ErrorMessage = waitErr.Error()
+			} else {
+				output = createdOutput
+			}
+			output.Index = index
+			output.Zone = placement.Zone
+			output.InstanceName = renderVmIndexPattern(vm.InstanceName, index)
+			outputs = append(outputs, *output)
+		}
+	}
+
+	return outputs
+}
@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnitPlanVmPlacements(t *testing.T) {
+	zones := []string{"ap-guangzhou-3", "ap-guangzhou-4", "ap-guangzhou-6"}
+
+	spread := planVmPlacements(zones, 5, "")
+	expect := []vmZonePlacement{
+		{Zone: "ap-guangzhou-3", StartIndex: 1, Count: 2},
+		{Zone: "ap-guangzhou-4", StartIndex: 3, Count: 2},
+		{Zone: "ap-guangzhou-6", StartIndex: 5, Count: 1},
+	}
+	if !reflect.DeepEqual(spread, expect) {
+		t.Errorf("spread placements=%+v, want %+v", spread, expect)
+	}
+
+	if spread = planVmPlacements(zones, 2, PLACEMENT_STRATEGY_SPREAD); len(spread) != 2 {
+		t.Errorf("zones without instances should be skipped, placements=%+v", spread)
+	}
+
+	pack := planVmPlacements(zones, 5, "PACK")
+	if !reflect.DeepEqual(pack, []vmZonePlacement{{Zone: "ap-guangzhou-3", StartIndex: 1, Count: 5}}) {
+		t.Errorf("pack placements=%+v not right", pack)
+	}
+}
+
+func TestUnitVmIndexPattern(t *testing.T) {
+	if name := toQcloudIndexPattern("app-{index}", 3); name != "app-{R:3}" {
+		t.Errorf("qcloud pattern=%s not right", name)
+	}
+	if name := renderVmIndexPattern("app-{index}", 3); name != "app-3" {
+		t.Errorf("rendered name=%s not right", name)
+	}
+	if name := toQcloudIndexPattern("app", 3); name != "app" {
+		t.Errorf("name without pattern should not be changed, got %s", name)
+	}
+}
+
+func TestUnitCheckVmBatchCreateInput(t *testing.T) {
+	if isVmBatchCreate(VmInput{Count: 1}) || !isVmBatchCreate(VmInput{Count: 2}) || !isVmBatchCreate(VmInput{Zones: "ap-guangzhou-3"}) {
+		t.Errorf("batch create is not detected by count and zones")
+	}
+	if err := checkVmBatchCreateInput(VmInput{Count: 2, InstancePrivateIp: "10.0.0.1"}); err == nil {
+		t.Errorf("instance_private_ip in batch should be an error")
+	}
+	if err := checkVmBatchCreateInput(VmInput{Count: 2}); err != nil {
+		t.Errorf("batch input meet error=%v", err)
+	}
+}
+
+func TestUnitBuildRunInstancesClientToken(t *testing.T) {
+	placement := vmZonePlacement{Zone: "ap-guangzhou-3", StartIndex: 1, Count: 2}
+	token := buildRunInstancesClientToken("0001_0000000001", placement)
+	if len(token) != 64 || token != buildRunInstancesClientToken("0001_0000000001", placement) {
+		t.Errorf("client token=%s should be stable and at most 64 characters", token)
+	}
+	placement.Zone = "ap-guangzhou-4"
+	if buildRunInstancesClientToken("0001_0000000001", placement) == token {
+		t.Errorf("client token should change with the zone")
+	}
+	if token = buildRunInstancesClientToken("", placement); token != "" {
+		t.Errorf("client token without guid should be empty, got %s", token)
+	}
+}
@@ -0,0 +1,312 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	//DescribeInstances accepts at most 10 filters and 5 values in each filter
+	MAX_CVM_FILTERS                  = 10
+	MAX_CVM_FILTER_VALUES            = 5
+	MAX_CVM_DESCRIBE_IDS             = 100
+	CVM_TAG_FILTER_PREFIX            = "tag:"
+	CVM_TAG_KEY_FILTER_NAME          = "tag-key"
+	VM_QUERY_TAG_SEPARATOR           = ";"
+	VM_QUERY_TAG_KEY_VALUE_SEPARATOR = "="
+)
+
+//--------------query vm--------------------//
+type VMQueryAction struct {
+}
+
+type VmQueryInputs struct {
+	Inputs []VmQueryInput `json:"inputs,omitempty"`
+}
+
+//VmQueryInput filters are separated by comma and combined with AND,
+//instance_ids can't be used together with the other filters
+type VmQueryInput struct {
+	Guid               string `json:"guid,omitempty"`
+	ProviderParams     string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	InstanceIds        string `json:"instance_ids,omitempty"`
+	InstanceName       string `json:"instance_name,omitempty"`
+	PrivateIps         string `json:"private_ips,omitempty"`
+	PublicIps          string `json:"public_ips,omitempty"`
+	VpcId              string `json:"vpc_id,omitempty"`
+	SubnetId           string `json:"subnet_id,omitempty"`
+	Zone               string `json:"zone,omitempty"`
+	SecurityGroupId    string `json:"security_group_id,omitempty"`
+	InstanceState      string `json:"instance_state,omitempty"`
+	InstanceChargeType string `json:"instance_charge_type,omitempty"`
+	//tags like key1=value1;key2, a tag without value matches the tag key only
+	Tags string `json:"tags,omitempty"`
+
+	//all the matched instances are returned when limit is 0
+	Offset int64 `json:"offset,omitempty"`
+	Limit  int64 `json:"limit,omitempty" validate:"range=0-100"`
+}
+
+type VmQueryOutputs struct {
+	Outputs []VmQueryOutput `json:"outputs,omitempty"`
+}
+
+type VmQueryOutput struct {
+	Guid       string             `json:"guid,omitempty"`
+	TotalCount int64              `json:"total_count"`
+	Instances  []VmInstanceDetail `json:"instances"`
+}
+
+type VmInstanceDetail struct {
+	Id                 string            `json:"id,omitempty"`
+	Name               string            `json:"name,omitempty"`
+	Zone               string            `json:"zone,omitempty"`
+	ProjectId          int64             `json:"project_id,omitempty"`
+	InstanceType       string            `json:"instance_type,omitempty"`
+	Cpu                int64             `json:"cpu,omitempty"`
+	Memory             int64             `json:"memory,omitempty"`
+	InstanceState      string            `json:"instance_state,omitempty"`
+	ImageId            string            `json:"image_id,omitempty"`
+	OsName             string            `json:"os_name,omitempty"`
+	VpcId              string            `json:"vpc_id,omitempty"`
+	SubnetId           string            `json:"subnet_id,omitempty"`
+	PrivateIps         []string          `json:"private_ips,omitempty"`
+	PublicIps          []string          `json:"public_ips,omitempty"`
+	SecurityGroupIds   []string          `json:"security_group_ids,omitempty"`
+	SystemDisk         *VmDiskDetail     `json:"system_disk,omitempty"`
+	DataDisks          []VmDiskDetail    `json:"data_disks,omitempty"`
+	InstanceChargeType string            `json:"instance_charge_type,omitempty"`
+	RenewFlag          string            `json:"renew_flag,omitempty"`
+	CreatedTime        string            `json:"created_time,omitempty"`
+	ExpiredTime        string            `json:"expired_time,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty"`
+}
+
+type VmDiskDetail struct {
+	DiskId   string `json:"disk_id,omitempty"`
+	DiskType string `json:"disk_type,omitempty"`
+	DiskSize int64  `json:"disk_size,omitempty"`
+}
+
+func (action *VMQueryAction) ReadParam(param interface{}) (interface{}, error) {
+	var inputs VmQueryInputs
+	err := UnmarshalJson(param, &inputs)
+	if err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+func (action *VMQueryAction) CheckParam(input interface{}) error {
+	inputs, ok := input.(VmQueryInputs)
+	if !ok {
+		return fmt.Errorf("VMQueryAction:input type=%T not right", input)
+	}
+
+	for _, input := range inputs.Inputs {
+		if _, _, err := buildVmQueryFilters(input); err != nil {
+			return err
+		}
+	}
+	return ValidateInputs(inputs, "")
+}
+
+func (action *VMQueryAction) Do(input interface{}) (interface{}, error) {
+	inputs, _ := input.(VmQueryInputs)
+	outputs := VmQueryOutputs{}
+	for _, input := range inputs.Inputs {
+		output, err := queryVmInstances(input)
+		if err != nil {
+			return nil, err
+		}
+		outputs.Outputs = append(outputs.Outputs, *output)
+	}
+
+	return &outputs, nil
+}
+
+//buildVmQueryFilters converts the query input to the instance ids and the filters of DescribeInstances
+func buildVmQueryFilters(input VmQueryInput) ([]string, []*cvm.Filter, error) {
+	filters := []*cvm.Filter{}
+	addFilter := func(name string, value string) error {
+		values := splitAndTrim(value, ",")
+		if len(values) == 0 {
+			return nil
+		}
+		if len(values) > MAX_CVM_FILTER_VALUES {
+			return fmt.Errorf("filter %s has %d values, more than %d", name, len(values), MAX_CVM_FILTER_VALUES)
+		}
+		filters = append(filters, &cvm.Filter{
+			Name:   common.StringPtr(name),
+			Values: common.StringPtrs(values),
+		})
+		return nil
+	}
+
+	filterValues := [][2]string{
+		{"instance-name", input.InstanceName},
+		{"private-ip-address", input.PrivateIps},
+		{"public-ip-address", input.PublicIps},
+		{"vpc-id", input.VpcId},
+		{"subnet-id", input.SubnetId},
+		{"zone", input.Zone},
+		{"security-group-id", input.SecurityGroupId},
+		{"instance-state", input.InstanceState},
+		{"instance-charge-type", input.InstanceChargeType},
+	}
+	for _, filterValue := range filterValues {
+		if err := addFilter(filterValue[0], filterValue[1]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, tag := range splitAndTrim(input.Tags, VM_QUERY_TAG_SEPARATOR) {
+		keyValue := strings.SplitN(tag, VM_QUERY_TAG_KEY_VALUE_SEPARATOR, 2)
+		key := strings.TrimSpace(keyValue[0])
+		if key == "" {
+			return nil, nil, fmt.Errorf("tag(%s) has no key", tag)
+		}
+		var err error
+		if len(keyValue) == 1 {
+			err = addFilter(CVM_TAG_KEY_FILTER_NAME, key)
+		} else {
+			err = addFilter(CVM_TAG_FILTER_PREFIX+key, keyValue[1])
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(filters) > MAX_CVM_FILTERS {
+		return nil, nil, fmt.Errorf("%d filters are more than %d", len(filters), MAX_CVM_FILTERS)
+	}
+
+	instanceIds := splitAndTrim(input.InstanceIds, ",")
+	if len(instanceIds) > 0 && len(filters) > 0 {
+		return nil, nil, fmt.Errorf("instance_ids can't be used together with the other filters")
+	}
+	if len(instanceIds) > MAX_CVM_DESCRIBE_IDS {
+		return nil, nil, fmt.Errorf("%d instance ids are more than %d", len(instanceIds), MAX_CVM_DESCRIBE_IDS)
+	}
+
+	return instanceIds, filters, nil
+}
+
+func queryVmInstances(input VmQueryInput) (*VmQueryOutput, error) {
+	instanceIds, filters, err := buildVmQueryFilters(input)
+	if err != nil {
+		return nil, err
+	}
+
+	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	output := VmQueryOutput{
+		Guid:      input.Guid,
+		Instances: []VmInstanceDetail{},
+	}
+	describePage := func(offset int, limit int) (int, int, error) {
+		request := cvm.NewDescribeInstancesRequest()
+		if len(instanceIds) > 0 {
+			request.InstanceIds = common.StringPtrs(instanceIds)
+		}
+		request.Filters = filters
+		request.Offset = common.Int64Ptr(int64(offset))
+		request.Limit = common.Int64Ptr(int64(limit))
+
+		response, err := client.DescribeInstances(request)
+		if err != nil {
+			logrus.Errorf("cvm DescribeInstances meet error=%v", err)
+			return 0, 0, err
+		}
+
+		for _, instance := range response.Response.InstanceSet {
+			output.Instances = append(output.Instances, buildVmInstanceDetail(instance))
+		}
+		output.TotalCount = *response.Response.TotalCount
+		return len(response.Response.InstanceSet), int(*response.Response.TotalCount), nil
+	}
+
+	//only the requested page is returned when limit is set
+	if input.Limit > 0 {
+		_, _, err = describePage(int(input.Offset), int(input.Limit))
+	} else {
+		err = PaginateByOffset(describePage)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &output, nil
+}
+
+func buildVmInstanceDetail(instance *cvm.Instance) VmInstanceDetail {
+	detail := VmInstanceDetail{
+		Id:                 stringValue(instance.InstanceId),
+		Name:               stringValue(instance.InstanceName),
+		InstanceType:       stringValue(instance.InstanceType),
+		InstanceState:      stringValue(instance.InstanceState),
+		ImageId:            stringValue(instance.ImageId),
+		OsName:             stringValue(instance.OsName),
+		InstanceChargeType: stringValue(instance.InstanceChargeType),
+		RenewFlag:          stringValue(instance.RenewFlag),
+		CreatedTime:        stringValue(instance.CreatedTime),
+		ExpiredTime:        stringValue(instance.ExpiredTime),
+		PrivateIps:         stringValues(instance.PrivateIpAddresses),
+		PublicIps:          stringValues(instance.PublicIpAddresses),
+		SecurityGroupIds:   stringValues(instance.SecurityGroupIds),
+	}
+	if instance.CPU != nil {
+		detail.Cpu = *instance.CPU
+	}
+	if instance.Memory != nil {
+		detail.Memory = *instance.Memory
+	}
+	if instance.Placement != nil {
+		detail.Zone = stringValue(instance.Placement.Zone)
+		if instance.Placement.ProjectId != nil {
+			detail.ProjectId = *instance.Placement.ProjectId
+		}
+	}
+	if instance.VirtualPrivateCloud != nil {
+		detail.VpcId = stringValue(instance.VirtualPrivateCloud.VpcId)
+		detail.SubnetId = stringValue(instance.VirtualPrivateCloud.SubnetId)
+	}
+	if instance.SystemDisk != nil {
+		detail.SystemDisk = &VmDiskDetail{
+			DiskId:   stringValue(instance.SystemDisk.DiskId),
+			DiskType: stringValue(instance.SystemDisk.DiskType),
+		}
+		if instance.SystemDisk.DiskSize != nil {
+			detail.SystemDisk.DiskSize = *instance.SystemDisk.DiskSize
+		}
+	}
+	for _, dataDisk := range instance.DataDisks {
+		disk := VmDiskDetail{
+			DiskId:   stringValue(dataDisk.DiskId),
+			DiskType: stringValue(dataDisk.DiskType),
+		}
+		if dataDisk.DiskSize != nil {
+			disk.DiskSize = *dataDisk.DiskSize
+		}
+		detail.DataDisks = append(detail.DataDisks, disk)
+	}
+	if len(instance.Tags) > 0 {
+		detail.Tags = map[string]string{}
+		for _, tag := range instance.Tags {
+			detail.Tags[stringValue(tag.Key)] = stringValue(tag.Value)
+		}
+	}
+
+	return detail
+}
@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func TestUnitBuildVmQueryFilters(t *testing.T) {
+	_, filters, err := buildVmQueryFilters(VmQueryInput{
+		PrivateIps: "10.0.0.1, 10.0.0.2",
+		VpcId:      "vpc-1",
+		Tags:       "env=prod;owner",
+	})
+	if err != nil {
+		t.Fatalf("buildVmQueryFilters meet error=%v", err)
+	}
+
+	expect := map[string]int{"private-ip-address": 2, "vpc-id": 1, "tag:env": 1, "tag-key": 1}
+	if len(filters) != len(expect) {
+		t.Fatalf("expect %d filters, got %d", len(expect), len(filters))
+	}
+	for _, filter := range filters {
+		if expect[*filter.Name] != len(filter.Values) {
+			t.Errorf("filter %s has %d values", *filter.Name, len(filter.Values))
+		}
+	}
+
+	if _, _, err = buildVmQueryFilters(VmQueryInput{InstanceIds: "ins-1", Zone: "ap-guangzhou-4"}); err == nil {
+		t.Errorf("instance_ids with other filters should be an error")
+	}
+	if _, _, err = buildVmQueryFilters(VmQueryInput{PrivateIps: "1.1.1.1,1.1.1.2,1.1.1.3,1.1.1.4,1.1.1.5,1.1.1.6"}); err == nil {
+		t.Errorf("more than %d filter values should be an error", MAX_CVM_FILTER_VALUES)
+	}
+
+	instanceIds, filters, err := buildVmQueryFilters(VmQueryInput{InstanceIds: "ins-1,ins-2"})
+	if err != nil || len(instanceIds) != 2 || len(filters) != 0 {
+		t.Errorf("instance ids=%v, filters=%d, err=%v", instanceIds, len(filters), err)
+	}
+}
+
+func TestUnitBuildVmInstanceDetail(t *testing.T) {
+	instance := &cvm.Instance{
+		InstanceId:         common.StringPtr("ins-1"),
+		CPU:                common.Int64Ptr(2),
+		PrivateIpAddresses: common.StringPtrs([]string{"10.0.0.1"}),
+		SystemDisk:         &cvm.SystemDisk{DiskId: common.StringPtr("disk-1"), DiskSize: common.Int64Ptr(50)},
+		DataDisks:          []*cvm.DataDisk{{DiskId: common.StringPtr("disk-2"), DiskSize: common.Int64Ptr(100)}},
+		Tags:               []*cvm.Tag{{Key: common.StringPtr("env"), Value: common.StringPtr("prod")}},
+	}
+
+	detail := buildVmInstanceDetail(instance)
+	if detail.Id != "ins-1" || detail.Cpu != 2 || detail.PrivateIps[0] != "10.0.0.1" || detail.Zone != "" {
+		t.Errorf("instance detail=%+v not right", detail)
+	}
+	if detail.SystemDisk.DiskSize != 50 || len(detail.DataDisks) != 1 || detail.Tags["env"] != "prod" {
+		t.Errorf("instance disks or tags=%+v not right", detail)
+	}
+}
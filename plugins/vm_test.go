@@ -0,0 +1,244 @@
+package plugins
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func TestUnitEncryptVmPassword(t *testing.T) {
+	guid, seed := "0001_0000001", "seed"
+	encryptedPassword, err := encryptSecret(guid, seed, "Qcloud@123")
+	if err != nil {
+		t.Fatalf("encryptSecret meet error=%v", err)
+	}
+
+	md5sum := utils.Md5Encode(guid + seed)
+	password, err := utils.AesDecode(md5sum[0:16], encryptedPassword)
+	if err != nil || password != "Qcloud@123" {
+		t.Errorf("decrypted password=%s, err=%v", password, err)
+	}
+}
+
+func TestUnitBuildDataDisks(t *testing.T) {
+	keep := false
+	dataDisks := buildDataDisks([]VmDataDiskInput{
+		{DiskType: "CLOUD_SSD", DiskSize: 100, Encrypt: true},
+		{DiskType: "CLOUD_PREMIUM", DiskSize: 50, SnapshotId: "snap-1", DeleteWithInstance: &keep},
+	})
+
+	data, _ := json.Marshal(dataDisks)
+	expect := `[{"DiskSize":100,"DiskType":"CLOUD_SSD","Encrypt":true},{"DiskSize":50,"DiskType":"CLOUD_PREMIUM","DeleteWithInstance":false,"SnapshotId":"snap-1"}]`
+	if string(data) != expect {
+		t.Errorf("data disks=%s, want %s", data, expect)
+	}
+
+	request := cvm.NewRunInstancesRequest()
+	request.FromJsonString(`{"DataDisks":` + string(data) + `}`)
+	if len(request.DataDisks) != 2 || request.DataDisks[0].Encrypt == nil || !*request.DataDisks[0].Encrypt || request.DataDisks[1].DeleteWithInstance == nil {
+		t.Errorf("data disks are not passed to RunInstancesRequest, request=%s", request.ToJsonString())
+	}
+}
+
+func TestUnitGetInternetMaxBandwidthOut(t *testing.T) {
+	vm := VmInput{}
+	if err := json.Unmarshal([]byte(`{"internet_max_bandwidth_out":0}`), &vm); err != nil || getInternetMaxBandwidthOut(vm) != 0 {
+		t.Errorf("bandwidth 0 should be kept, err=%v", err)
+	}
+	if bandwidth := getInternetMaxBandwidthOut(VmInput{}); bandwidth != DEFAULT_INTERNET_MAX_BANDWIDTH_OUT {
+		t.Errorf("bandwidth not set should be the default, got %d", bandwidth)
+	}
+}
+
+func TestUnitRenderUserDataTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "user_data")
+	if err != nil {
+		t.Fatalf("TempDir meet error=%v", err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "init"+USER_DATA_TEMPLATE_EXT), []byte("hostnamectl set-hostname {{.HostName}}"), 0644)
+
+	userData, err := renderUserDataTemplate(dir, "init", VmInput{HostName: "app-1"})
+	if err != nil || userData != "hostnamectl set-hostname app-1" {
+		t.Errorf("rendered user data=%s, err=%v", userData, err)
+	}
+
+	userData, err = renderUserDataTemplate("../scripts/user_data", "agent-install", VmInput{HostName: "app-1$(reboot)", InstanceName: "app'1"})
+	if err != nil || !strings.Contains(userData, `hostnamectl set-hostname 'app-1$(reboot)'`) || !strings.Contains(userData, `"instance "'app'\''1'" initialized`) {
+		t.Errorf("inputs should be quoted in agent-install, rendered user data=%s, err=%v", userData, err)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "zone"+USER_DATA_TEMPLATE_EXT), []byte("{{.Region}}/{{.Zone}}"), 0644)
+	userData, err = renderUserDataTemplate(dir, "zone", VmInput{ProviderParams: "Region=ap-guangzhou;AvailableZone=ap-guangzhou-4;SecretID=id;SecretKey=key"})
+	if err != nil || userData != "ap-guangzhou/ap-guangzhou-4" {
+		t.Errorf("rendered user data=%s, err=%v", userData, err)
+	}
+
+	for _, secret := range []string{"Password", "ProviderParams", "Seed"} {
+		ioutil.WriteFile(filepath.Join(dir, "secret"+USER_DATA_TEMPLATE_EXT), []byte("{{."+secret+"}}"), 0644)
+		if userData, err = renderUserDataTemplate(dir, "secret", VmInput{Password: "Qcloud@123", ProviderParams: "SecretKey=key", Seed: "seed"}); err == nil {
+			t.Errorf("%s should not be rendered, got %s", secret, userData)
+		}
+	}
+
+	if _, err = renderUserDataTemplate(dir, "../init", VmInput{}); err == nil {
+		t.Errorf("template name with path should be an error")
+	}
+	if _, err = renderUserDataTemplate(dir, "missing", VmInput{}); err == nil {
+		t.Errorf("missing template should be an error")
+	}
+}
+
+func TestUnitBuildUserData(t *testing.T) {
+	userData, err := buildUserData(VmInput{UserData: "#!/bin/bash\necho ok"})
+	decoded, _ := base64.StdEncoding.DecodeString(userData)
+	if err != nil || string(decoded) != "#!/bin/bash\necho ok" {
+		t.Errorf("user data=%s, err=%v", decoded, err)
+	}
+
+	if userData, err = buildUserData(VmInput{}); err != nil || userData != "" {
+		t.Errorf("empty user data should be empty, got %s err=%v", userData, err)
+	}
+
+	if _, err = buildUserData(VmInput{UserData: strings.Repeat("a", MAX_USER_DATA_SIZE)}); err == nil {
+		t.Errorf("too large user data should be an error")
+	}
+}
+
+func TestUnitVmCreateCheckLoginSettings(t *testing.T) {
+	vm := VmInput{
+		ProviderParams: "Region=ap-guangzhou;AvailableZone=ap-guangzhou-4;SecretID=id;SecretKey=key",
+		VpcId:          "vpc-1",
+		SubnetId:       "subnet-1",
+		InstanceType:   "S5.MEDIUM4",
+		ImageId:        "img-1",
+		Password:       "Passw0rd!",
+	}
+	action := &VMCreateAction{}
+	if err := action.CheckParam(VmInputs{Inputs: []VmInput{vm}}); err != nil {
+		t.Errorf("vm with password meet error=%v", err)
+	}
+
+	vm.KeyIds = "skey-1"
+	if err := action.CheckParam(VmInputs{Inputs: []VmInput{vm}}); err == nil {
+		t.Errorf("vm with both password and key_ids should be an error")
+	}
+
+	vm.Password = ""
+	if err := action.CheckParam(VmInputs{Inputs: []VmInput{vm}}); err != nil {
+		t.Errorf("vm with key_ids meet error=%v", err)
+	}
+}
+
+func TestUnitIsSshReady(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen meet error=%v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("SSH-2.0-OpenSSH_7.4\r\n"))
+			conn.Close()
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	if !isSshReady("127.0.0.1", port) {
+		t.Errorf("ssh should be ready when the banner is answered")
+	}
+
+	listener.Close()
+	if isSshReady("127.0.0.1", port) {
+		t.Errorf("ssh should not be ready when the port is closed")
+	}
+}
+
+//newFakeCvmClient returns a cvm client calling the server, handle returns the Response of the action
+func newFakeCvmClient(t *testing.T, handle func(action string, body []byte) interface{}) (*cvm.Client, func()) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.NewEncoder(w).Encode(map[string]interface{}{"Response": handle(r.Header.Get("X-TC-Action"), body)})
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+
+	clientProfile := profile.NewClientProfile()
+	clientProfile.HttpProfile.Endpoint = serverUrl.Host
+	client, err := cvm.NewClient(common.NewCredential("id", "key"), "ap-guangzhou", clientProfile)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	client.WithHttpTransport(server.Client().Transport)
+	return client, server.Close
+}
+
+func TestUnitResetPasswordWaitsOperationBeforeStart(t *testing.T) {
+	defer func(interval time.Duration) { vmStatePollInterval = interval }(vmStatePollInterval)
+	vmStatePollInterval = time.Millisecond
+
+	var mutex sync.Mutex
+	state, operationState, actions := INSTANCE_STATE_RUNNING, "", []string{}
+	client, closeServer := newFakeCvmClient(t, func(action string, body []byte) interface{} {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch action {
+		case "DescribeInstances":
+			instance := map[string]interface{}{"InstanceId": "ins-1", "InstanceState": state}
+			if operationState != "" {
+				instance["LatestOperation"] = "ResetInstancesPassword"
+				instance["LatestOperationRequestId"] = "req-reset"
+				instance["LatestOperationState"] = operationState
+				//the reset is finished at the second poll
+				if operationState == "OPERATING" {
+					operationState = OPERATION_STATE_SUCCESS
+				} else if operationState == OPERATION_STATE_SUCCESS {
+					actions = append(actions, "ResetDone")
+				}
+			}
+			return map[string]interface{}{"TotalCount": 1, "InstanceSet": []interface{}{instance}, "RequestId": "req-describe"}
+		case "StopInstances":
+			state = INSTANCE_STATE_STOPPED
+		case "StartInstances":
+			state = INSTANCE_STATE_RUNNING
+		case "ResetInstancesPassword":
+			operationState = "OPERATING"
+			actions = append(actions, action)
+			return map[string]interface{}{"RequestId": "req-reset"}
+		}
+		actions = append(actions, action)
+		return map[string]interface{}{"RequestId": "req-" + action}
+	})
+	defer closeServer()
+
+	vm := &VmInput{Guid: "0001_0000001", Seed: "seed", Id: "ins-1", Password: "Qcloud@123"}
+	if _, err := resetVmPassword(client, vm); err != nil {
+		t.Fatalf("reset password meet error=%v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	expect := "StopInstances,ResetInstancesPassword,ResetDone,StartInstances"
+	if got := strings.Join(actions, ","); !strings.HasPrefix(got, expect) {
+		t.Errorf("actions=%s, want %s", got, expect)
+	}
+}
@@ -1,9 +1,8 @@
 package plugins
 
 import (
-	"errors"
 	"fmt"
-	"net"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
@@ -19,12 +18,13 @@ func init() {
 }
 
 func CreateVpcClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+	cachedClient, err := GetCachedClient("vpc", "vpc.tencentcloudapi.com", region, secretId, secretKey, func(credential *common.Credential, region string, clientProfile *profile.ClientProfile) (interface{}, error) {
+		return vpc.NewClient(credential, region, clientProfile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cachedClient.(*vpc.Client), nil
 }
 
 type VpcInputs struct {
@@ -33,10 +33,10 @@ type VpcInputs struct {
 
 type VpcInput struct {
 	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	Name           string `json:"name,omitempty"`
-	CidrBlock      string `json:"cidr_block,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty" validate:"required,provider_params"`
+	Id             string `json:"id,omitempty" validate:"required=terminate"`
+	Name           string `json:"name,omitempty" validate:"required=create"`
+	CidrBlock      string `json:"cidr_block,omitempty" validate:"required=create,cidr"`
 }
 
 type VpcOutputs struct {
@@ -80,34 +80,13 @@ func (action *VpcCreateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("vpcCreateAtion:input type=%T not right", input)
 	}
 
-	for _, vpc := range vpcs.Inputs {
-		if vpc.Name == "" {
-			return errors.New("vpcCreateAtion input name is empty")
-		}
-		if _, _, err := net.ParseCIDR(vpc.CidrBlock); err != nil {
-			return fmt.Errorf("vpcCreateAtion invalid vpcCidr[%s]", vpc.CidrBlock)
-		}
-	}
-
-	return nil
+	return ValidateInputs(vpcs, "create")
 }
 
 func (action *VpcCreateAction) createVpc(vpcInput *VpcInput) (*VpcOutput, error) {
 	paramsMap, err := GetMapFromProviderParams(vpcInput.ProviderParams)
 	client, _ := CreateVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
 
-	//check resource exist
-	if vpcInput.Id != "" {
-		queryVpcsResponse, flag, err := queryVpcsInfo(client, vpcInput)
-		if err != nil && flag == false {
-			return nil, err
-		}
-
-		if err == nil && flag == true {
-			return queryVpcsResponse, nil
-		}
-	}
-
 	request := vpc.NewCreateVpcRequest()
 	request.VpcName = &vpcInput.Name
 	request.CidrBlock = &vpcInput.CidrBlock
@@ -129,7 +108,19 @@ func (action *VpcCreateAction) createVpc(vpcInput *VpcInput) (*VpcOutput, error)
 func (action *VpcCreateAction) Do(input interface{}) (interface{}, error) {
 	vpcs, _ := input.(VpcInputs)
 	outputs := VpcOutputs{}
-	for _, vpc := range vpcs.Inputs {
+
+	//check resource exist
+	existVpcs, err := queryVpcsInfo(vpcs.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, vpc := range vpcs.Inputs {
+		if existVpcs[i] != nil {
+			outputs.Outputs = append(outputs.Outputs, *existVpcs[i])
+			continue
+		}
+
 		vpcOutput, err := action.createVpc(&vpc)
 		if err != nil {
 			return nil, err
@@ -159,12 +150,7 @@ func (action *VpcTerminateAction) CheckParam(input interface{}) error {
 		return fmt.Errorf("vpcTerminateAtion:input type=%T not right", input)
 	}
 
-	for _, vpc := range vpcs.Inputs {
-		if vpc.Id == "" {
-			return errors.New("vpcTerminateAtion input vpc_id is empty")
-		}
-	}
-	return nil
+	return ValidateInputs(vpcs, "terminate")
 }
 
 func (action *VpcTerminateAction) terminateVpc(vpcInput *VpcInput) (*VpcOutput, error) {
@@ -200,28 +186,55 @@ func (action *VpcTerminateAction) Do(input interface{}) (interface{}, error) {
 	return &outputs, nil
 }
 
-func queryVpcsInfo(client *vpc.Client, input *VpcInput) (*VpcOutput, bool, error) {
-	output := VpcOutput{}
+func describeVpcsByIds(paramsMap map[string]string, ids []string) (map[string]interface{}, error) {
+	client, err := CreateVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return nil, err
+	}
 
 	request := vpc.NewDescribeVpcsRequest()
-	request.VpcIds = append(request.VpcIds, &input.Id)
+	for i := range ids {
+		request.VpcIds = append(request.VpcIds, &ids[i])
+	}
+	limit := strconv.Itoa(DESCRIBE_BATCH_SIZE)
+	request.Limit = &limit
 	response, err := client.DescribeVpcs(request)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	if len(response.Response.VpcSet) == 0 {
-		return nil, false, nil
+	vpcs := make(map[string]interface{})
+	for _, vpcSet := range response.Response.VpcSet {
+		vpcs[*vpcSet.VpcId] = &VpcOutput{
+			RequestId: *response.Response.RequestId,
+			Id:        *vpcSet.VpcId,
+		}
 	}
+	return vpcs, nil
+}
 
-	if len(response.Response.VpcSet) > 1 {
-		logrus.Errorf("query vpcs id=%s info find more than 1", input.Id)
-		return nil, false, fmt.Errorf("query vpcs id=%s info find more than 1", input.Id)
+//queryVpcsInfo returns the existing vpc of every input, nil when the input has no id or the vpc doesn't exist
+func queryVpcsInfo(inputs []VpcInput) ([]*VpcOutput, error) {
+	providerParams := []string{}
+	ids := []string{}
+	for _, input := range inputs {
+		providerParams = append(providerParams, input.ProviderParams)
+		ids = append(ids, input.Id)
 	}
 
-	output.Guid = input.Guid
-	output.Id = input.Id
-	output.RequestId = *response.Response.RequestId
+	resources, err := BatchDescribe(providerParams, ids, describeVpcsByIds)
+	if err != nil {
+		return nil, err
+	}
 
-	return &output, true, nil
+	outputs := make([]*VpcOutput, len(inputs))
+	for i, resource := range resources {
+		if resource == nil {
+			continue
+		}
+		output := *resource.(*VpcOutput)
+		output.Guid = inputs[i].Guid
+		outputs[i] = &output
+	}
+	return outputs, nil
 }
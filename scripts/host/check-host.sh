@@ -0,0 +1,13 @@
+#!/bin/sh
+#prints the basic status of the host, usage: check-host.sh [mount_dir...]
+
+echo "hostname: $(hostname)"
+echo "uptime: $(uptime)"
+echo "memory:"
+free -m
+echo "disk:"
+if [ $# -gt 0 ]; then
+    df -h "$@"
+else
+    df -h
+fi
@@ -0,0 +1,4 @@
+#!/bin/bash
+#rendered by the qcloud plugin with the vm create inputs, the inputs are quoted by shellQuote
+{{if .HostName}}hostnamectl set-hostname {{shellQuote .HostName}}
+{{end}}echo "instance "{{shellQuote .InstanceName}}" initialized by cloud-init at $(date)" >> /var/log/wecube-init.log